@@ -1,25 +1,53 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmpoly"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/infrastructure"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/interface/http"
+	"github.com/paulkoehlerdev/OsmInTile/static"
+	"github.com/paulkoehlerdev/OsmInTile/styles"
+	"github.com/paulmach/orb"
+	"io"
+	"io/fs"
 	"log"
 	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
 	publicUrl := flag.String("public-url", "http://localhost:8080", "Public URL of OsmInTile")
 	databasePath := flag.String("database", "file::memory:?cache=shared", "Database file path")
 	osmFile := flag.String("osm-file", "", "Import OSM file")
+	importBBox := flag.String("import-bbox", "", "Import only features intersecting this bounding box: minLon,minLat,maxLon,maxLat")
+	importPoly := flag.String("import-poly", "", "Import only features intersecting the polygon of this Osmosis .poly file")
+	adminToken := flag.String("admin-token", "", "Bearer token for /admin/import/status to include the file path and error, which are omitted without it")
+	importInBackground := flag.Bool("import-in-background", false, "Start the server while importing, other routes than /admin/import/status answer 503 until the import finished")
+	fontsDir := flag.String("fonts-dir", "fonts", "Directory with pre-generated glyph pbfs, laid out as <font name>/<start>-<end>.pbf")
+	iconsDir := flag.String("icons-dir", "", "Directory with svg icons for the sprite sheets (default: embedded icons)")
+	devStylesDir := flag.String("dev-styles-dir", "", "Dev mode: load style templates from this directory and reload them on change")
+	devStaticDir := flag.String("dev-static-dir", "", "Dev mode: serve static files from this directory and reload browsers on change")
+	styleValidation := flag.String("style-validation", "warn", "Validate the styles against the tile layers at startup: error, warn or off")
+	devPollInterval := flag.Duration("dev-poll-interval", time.Second, "Dev mode: interval for polling the dev directories for changes")
+	exportGraph := flag.String("export-graph", "", "Export the navigation graph to this file and exit, the format is taken from the extension: .geojson, .graphml or .csv")
+	validate := flag.String("validate", "", "Validate the indoor data, write the report to this file and exit, the format is taken from the extension: .json or .geojson")
 	flag.Parse()
 
-	listener, err := net.Listen("tcp", "0.0.0.0:8080")
-	if err != nil {
-		panic(err)
+	switch *styleValidation {
+	case "error", "warn", "off":
+	default:
+		panic(fmt.Errorf("invalid --style-validation %q: expected error, warn or off", *styleValidation))
 	}
 
 	osmDataRepo, err := infrastructure.NewSqliteOsmDataRepository(*databasePath)
@@ -27,26 +55,242 @@ func main() {
 		panic(err)
 	}
 
+	importSvc := service.NewImportService(osmDataRepo)
+
 	if *osmFile != "" {
-		log.Println("Loading osm file", *osmFile)
-		err := osmDataRepo.Import(context.Background(), *osmFile)
+		boundary, err := parseImportBoundary(*importBBox, *importPoly)
 		if err != nil {
 			panic(err)
 		}
+
+		log.Println("Loading osm file", *osmFile)
+		options := repository.ImportOptions{Boundary: boundary}
+		if *importInBackground && *exportGraph == "" && *validate == "" {
+			if err := importSvc.ImportInBackground(context.Background(), *osmFile, options); err != nil {
+				panic(err)
+			}
+		} else if err := importSvc.Import(context.Background(), *osmFile, options); err != nil {
+			panic(err)
+		}
 	}
 
-	styleSvc, err := service.NewMapStyleService(*publicUrl, osmDataRepo)
+	if *exportGraph != "" {
+		if err := exportNavGraph(service.NewRoutingService(osmDataRepo), *exportGraph); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *validate != "" {
+		if err := writeValidationReport(service.NewValidationService(osmDataRepo), *validate); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	listener, err := net.Listen("tcp", "0.0.0.0:8080")
 	if err != nil {
 		panic(err)
 	}
 
+	var stylesFS fs.FS
+	var styleSvc service.MapStyleService
+	if *devStylesDir != "" {
+		stylesFS = os.DirFS(*devStylesDir)
+		styleSvc = service.NewDevMapStyleService(*publicUrl, stylesFS, osmDataRepo)
+	} else {
+		styleSvc, err = service.NewMapStyleService(*publicUrl, osmDataRepo)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var staticFS fs.FS = static.FS
+	var devStaticFS fs.FS
+	if *devStaticDir != "" {
+		devStaticFS = os.DirFS(*devStaticDir)
+		staticFS = devStaticFS
+	}
+
 	tilesSvc := service.NewMapTilesService(osmDataRepo)
+	tileJSONSvc := service.NewTileJSONService(*publicUrl, tilesSvc, osmDataRepo)
+
+	if *styleValidation != "off" {
+		err := styleSvc.ValidateTemplates(tilesSvc.GetVectorLayers())
+		if err != nil && *styleValidation == "error" {
+			panic(err)
+		}
+		if err != nil {
+			log.Printf("style validation failed: %v", err)
+		}
+	}
+
+	iconFS, err := fs.Sub(styles.FS, "icons")
+	if err != nil {
+		panic(err)
+	}
+	if *iconsDir != "" {
+		iconFS = os.DirFS(*iconsDir)
+	}
+
+	spriteSvc, err := service.NewSpriteService(iconFS)
+	if err != nil {
+		panic(err)
+	}
 
-	app := application.New(styleSvc, tilesSvc)
+	glyphSvc := service.NewGlyphService(os.DirFS(*fontsDir))
+	buildingSvc := service.NewBuildingService(osmDataRepo)
+	levelSvc := service.NewLevelService(osmDataRepo)
+	featureSvc := service.NewFeatureService(osmDataRepo)
+	locationSvc := service.NewLocationService(osmDataRepo)
+	searchSvc := service.NewSearchService(osmDataRepo)
+	routingSvc := service.NewRoutingService(osmDataRepo)
+	validationSvc := service.NewValidationService(osmDataRepo)
+	importSvc.OnImported(validationSvc.Invalidate)
+	importSvc.OnImported(routingSvc.Invalidate)
+
+	var devReloadSvc service.DevReloadService
+	if stylesFS != nil || devStaticFS != nil {
+		log.Println("Dev mode enabled, watching for changes")
+		devReloadSvc = service.NewDevReloadService(styleSvc, stylesFS, devStaticFS, *devPollInterval)
+		go func() {
+			if err := devReloadSvc.Watch(context.Background()); err != nil {
+				log.Printf("dev reload watcher stopped: %v", err)
+			}
+		}()
+	}
+
+	app := application.New(styleSvc, tilesSvc, tileJSONSvc, spriteSvc, glyphSvc, buildingSvc, levelSvc, featureSvc, locationSvc, searchSvc, routingSvc, validationSvc, importSvc, devReloadSvc)
 
 	log.Println("Starting OsmInTile server")
-	err = http.ServeApplication(listener, app)
+	err = http.ServeApplication(listener, app, staticFS, *adminToken)
 	if err != nil {
 		panic(err)
 	}
 }
+
+func exportNavGraph(routingSvc service.RoutingService, path string) error {
+	format, err := service.GraphExportFormat(path)
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(path, func(w io.Writer) error {
+		return routingSvc.ExportGraph(context.Background(), w, format)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Println("Exported navigation graph to", path)
+	return nil
+}
+
+func writeValidationReport(validationSvc service.ValidationService, path string) error {
+	var report any
+	var err error
+	switch filepath.Ext(path) {
+	case ".json":
+		report, err = validationSvc.Validate(context.Background())
+	case ".geojson":
+		report, err = validationSvc.GetValidationGeoJSON(context.Background())
+	default:
+		return fmt.Errorf("unknown validation report format of %s: expected .json or .geojson", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(path, func(w io.Writer) error {
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Println("Wrote validation report to", path)
+	return nil
+}
+
+// writeFile writes to a temporary file next to path, which replaces path once it is completely written.
+// On failure, path is left untouched.
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := write(writer); err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := file.Chmod(0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseImportBoundary returns the polygon of the bounding box or the .poly file, nil is returned if both are empty
+func parseImportBoundary(bbox string, polyPath string) (orb.MultiPolygon, error) {
+	switch {
+	case bbox != "" && polyPath != "":
+		return nil, fmt.Errorf("only one of --import-bbox and --import-poly can be given")
+	case polyPath != "":
+		file, err := os.Open(polyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", polyPath, err)
+		}
+		defer file.Close()
+
+		return osmpoly.Parse(file)
+	case bbox != "":
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("invalid bbox %q: expected minLon,minLat,maxLon,maxLat", bbox)
+		}
+
+		coords := [4]float64{}
+		for i, part := range parts {
+			coord, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bbox %q: %w", bbox, err)
+			}
+			coords[i] = coord
+		}
+
+		// comparisons with NaN are false, so they fail the range checks as well
+		for i, coord := range coords {
+			if limit := [2]float64{180, 90}[i%2]; !(coord >= -limit && coord <= limit) {
+				return nil, fmt.Errorf("invalid bbox %q: %v is out of range", bbox, coord)
+			}
+		}
+
+		if coords[0] >= coords[2] || coords[1] >= coords[3] {
+			return nil, fmt.Errorf("invalid bbox %q: min is not less than max", bbox)
+		}
+
+		bound := orb.Bound{Min: orb.Point{coords[0], coords[1]}, Max: orb.Point{coords[2], coords[3]}}
+		return orb.MultiPolygon{bound.ToPolygon()}, nil
+	default:
+		return nil, nil
+	}
+}
@@ -6,6 +6,9 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/paulmach/orb v0.11.1
 	github.com/paulmach/osm v0.8.0
+	github.com/srwiley/oksvg v0.0.0-20221011165216-be6e8873101c
+	github.com/srwiley/rasterx v0.0.0-20220730225603-2ab79fcdd4ef
+	google.golang.org/protobuf v1.27.1
 )
 
 require (
@@ -13,5 +16,7 @@ require (
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/paulmach/protoscan v0.2.1 // indirect
 	go.mongodb.org/mongo-driver v1.11.4 // indirect
-	google.golang.org/protobuf v1.27.1 // indirect
+	golang.org/x/image v0.18.0 // indirect
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
 )
@@ -61,4 +61,80 @@ CREATE TABLE IF NOT EXISTS relation_member
     member_role text                                                                                   NOT NULL,
     sequence_id int                                                                                    NOT NULL
 );
-CREATE INDEX IF NOT EXISTS relation_member_relation_id ON relation_member (relation_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS relation_member_relation_id ON relation_member (relation_id);
+CREATE TABLE IF NOT EXISTS building
+(
+    building_id text NOT NULL PRIMARY KEY,
+    name        text,
+    levels      text NOT NULL DEFAULT '[]'
+);
+SELECT AddGeometryColumn('building', 'outline', 4326, 'GEOMETRY');
+-- the spatial index idx_building_outline links the features to the buildings at import without scanning all buildings
+SELECT CreateSpatialIndex('building', 'outline')
+WHERE NOT EXISTS (SELECT 1 FROM geometry_columns WHERE f_table_name = 'building' AND f_geometry_column = 'outline' AND spatial_index_enabled = 1);
+
+CREATE TABLE IF NOT EXISTS building_feature
+(
+    building_id  text                                                                                      NOT NULL,
+    feature_type text CHECK ( feature_type = 'way' OR feature_type = 'node' OR feature_type = 'relation' ) NOT NULL,
+    feature_id   bigint                                                                                    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS building_feature_building_id ON building_feature (building_id);
+CREATE INDEX IF NOT EXISTS building_feature_feature_id ON building_feature (feature_type, feature_id);
+
+-- repaired_area holds the polygons of indoor features and buildings which were repaired at import,
+-- they are preferred over the polygons built from the nodes. repairs is a json array of the applied repairs.
+CREATE TABLE IF NOT EXISTS repaired_area
+(
+    feature_type text CHECK ( feature_type = 'way' OR feature_type = 'relation' ) NOT NULL,
+    feature_id   bigint                                                          NOT NULL,
+    repairs      text                                                            NOT NULL DEFAULT '[]'
+);
+SELECT AddGeometryColumn('repaired_area', 'geom', 4326, 'GEOMETRY');
+CREATE INDEX IF NOT EXISTS repaired_area_feature_id ON repaired_area (feature_type, feature_id);
+
+-- indoor_area holds the polygons and tags of the indoor=room|area|corridor features, built at import.
+-- The spatial index idx_indoor_area_geom finds the areas at a point without building the geometries of all of them.
+CREATE TABLE IF NOT EXISTS indoor_area
+(
+    feature_type text CHECK ( feature_type = 'way' OR feature_type = 'relation' ) NOT NULL,
+    feature_id   bigint                                                          NOT NULL,
+    tags         text                                                            NOT NULL DEFAULT '{}'
+);
+SELECT AddGeometryColumn('indoor_area', 'geom', 4326, 'GEOMETRY');
+SELECT CreateSpatialIndex('indoor_area', 'geom')
+WHERE NOT EXISTS (SELECT 1 FROM geometry_columns WHERE f_table_name = 'indoor_area' AND f_geometry_column = 'geom' AND spatial_index_enabled = 1);
+
+-- nav_node, nav_edge and nav_area hold the indoor navigation graph derived at import,
+-- node_id is the index of the node in the graph
+CREATE TABLE IF NOT EXISTS nav_node
+(
+    node_id bigint NOT NULL PRIMARY KEY,
+    level   real   NOT NULL,
+    kind    text   NOT NULL,
+    feature text   NOT NULL,
+    tags    text   NOT NULL DEFAULT '{}'
+);
+SELECT AddGeometryColumn('nav_node', 'geom', 4326, 'POINT');
+
+CREATE TABLE IF NOT EXISTS nav_edge
+(
+    from_node  bigint  NOT NULL,
+    to_node    bigint  NOT NULL,
+    kind       text    NOT NULL,
+    length     real    NOT NULL,
+    levels     real    NOT NULL,
+    oneway     boolean NOT NULL,
+    wheelchair boolean NOT NULL,
+    feature    text    NOT NULL DEFAULT '',
+    cost       real    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS nav_area
+(
+    area_id text NOT NULL,
+    level   real NOT NULL,
+    tags    text NOT NULL DEFAULT '{}',
+    nodes   text NOT NULL DEFAULT '[]'
+);
+SELECT AddGeometryColumn('nav_area', 'geom', 4326, 'GEOMETRY');
@@ -0,0 +1,18 @@
+-- search is filled at import over the names, refs and categories of indoor features and pois,
+-- requires sqlite to be built with fts5 (build tag sqlite_fts5), otherwise search is disabled.
+-- levels holds the parsed levels of the level tag as ;0;1; for filtering by level
+CREATE VIRTUAL TABLE IF NOT EXISTS search USING fts5
+(
+    feature_type UNINDEXED,
+    feature_id UNINDEXED,
+    level UNINDEXED,
+    levels UNINDEXED,
+    lon UNINDEXED,
+    lat UNINDEXED,
+    name,
+    names,
+    ref,
+    category,
+    tokenize = 'unicode61 remove_diacritics 2',
+    prefix = '2 3'
+);
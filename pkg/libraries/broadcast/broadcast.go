@@ -0,0 +1,52 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+)
+
+// Broadcaster fans out notifications to all current subscribers.
+// Notifications are coalesced, a slow subscriber only ever sees one pending notification.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+func New() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving notifications until ctx is cancelled.
+func (b *Broadcaster) Subscribe(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+
+		close(ch)
+	}()
+
+	return ch
+}
+
+func (b *Broadcaster) Notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
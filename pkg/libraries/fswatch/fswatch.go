@@ -0,0 +1,79 @@
+package fswatch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"time"
+)
+
+type fileState struct {
+	size    int64
+	modTime int64
+}
+
+// Poll walks fsys every interval and calls onChange whenever a file was added, removed or modified.
+// It blocks until ctx is cancelled.
+func Poll(ctx context.Context, fsys fs.FS, interval time.Duration, onChange func()) error {
+	last, err := snapshot(fsys)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot fs: %w", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := snapshot(fsys)
+		if err != nil {
+			// editors tend to replace files non-atomically, so we just retry on the next tick
+			log.Printf("failed to snapshot fs: %v", err)
+			continue
+		}
+
+		if maps.Equal(last, current) {
+			continue
+		}
+
+		last = current
+		onChange()
+	}
+}
+
+func snapshot(fsys fs.FS) (map[string]fileState, error) {
+	out := make(map[string]fileState)
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		out[path] = fileState{
+			size:    info.Size(),
+			modTime: info.ModTime().UnixNano(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
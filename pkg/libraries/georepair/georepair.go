@@ -0,0 +1,382 @@
+package georepair
+
+import (
+	"cmp"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"math"
+	"slices"
+)
+
+type Repair string
+
+const (
+	// RepairClosedRing joined the ends of a ring which were not connected but close to each other
+	RepairClosedRing Repair = "closed_ring"
+	// RepairSpike removed a vertex where the ring went out and came back along the same line
+	RepairSpike Repair = "spike"
+	// RepairSelfIntersection split a ring crossing or touching itself into separate rings
+	RepairSelfIntersection Repair = "self_intersection"
+	// RepairOrientation reversed a hole which had the orientation of its outer ring
+	RepairOrientation Repair = "orientation"
+	// RepairUnrepairable kept a ring unsplit, it had too many vertices or self intersections to be repaired
+	RepairUnrepairable Repair = "unrepairable"
+)
+
+const (
+	// CloseTolerance is the distance in meters up to which the ends of an unclosed ring are joined
+	CloseTolerance = 0.5
+	// tolerance is the distance in meters below which points are considered equal
+	tolerance = 0.01
+	// minArea is the area in square meters below which rings are dropped as degenerate
+	minArea = tolerance * tolerance
+	// maxRepairVertices is the number of vertices above which a ring is not checked for self intersections
+	maxRepairVertices = 5000
+	// maxRepairIntersections is the number of self intersections above which a ring is not split
+	maxRepairIntersections = 1000
+)
+
+// spikeSine is the sine of the angle below which a vertex going back along its incoming segment is a spike
+var spikeSine = math.Sin(1 * math.Pi / 180)
+
+// Polygons assembles the lines into rings and repairs them. Outer rings of the result are counter-clockwise and holes
+// are clockwise, lines which can not be closed are dropped. The repairs are returned sorted and without duplicates,
+// orienting rings whose orientation does not affect how they are read is not counted as a repair.
+func Polygons(lines []orb.LineString) (orb.MultiPolygon, []Repair) {
+	var bound orb.Bound
+	for i, line := range lines {
+		if i == 0 {
+			bound = line.Bound()
+		} else {
+			bound = bound.Union(line.Bound())
+		}
+	}
+
+	r := &repairer{proj: geoutil.NewProjection(bound.Center())}
+
+	xyLines := make([]orb.LineString, 0, len(lines))
+	for _, line := range lines {
+		xyLines = append(xyLines, r.proj.GeometryToXY(line).(orb.LineString))
+	}
+
+	closed, open := geoutil.AssembleRings(xyLines)
+	for _, chain := range open {
+		if len(chain) >= 3 && planar.Distance(chain[0], chain[len(chain)-1]) <= CloseTolerance {
+			closed = append(closed, orb.Ring(append(chain, chain[0])))
+			r.add(RepairClosedRing)
+		}
+	}
+
+	var rings []orb.Ring
+	for _, ring := range closed {
+		rings = append(rings, r.repairRing(ring)...)
+	}
+
+	polygons := r.buildPolygons(rings)
+
+	out := make(orb.MultiPolygon, 0, len(polygons))
+	for _, polygon := range polygons {
+		out = append(out, r.proj.GeometryFromXY(polygon).(orb.Polygon))
+	}
+
+	slices.Sort(r.repairs)
+	return out, slices.Compact(r.repairs)
+}
+
+type repairer struct {
+	proj    geoutil.Projection
+	repairs []Repair
+}
+
+func (r *repairer) add(repair Repair) {
+	r.repairs = append(r.repairs, repair)
+}
+
+// repairRing removes the spikes of the closed ring and splits it into simple rings, degenerate rings are dropped
+func (r *repairer) repairRing(ring orb.Ring) []orb.Ring {
+	// the ring is handled without the closing point, as a cycle of vertices
+	points := removeDuplicates(ring[:len(ring)-1])
+
+	points, removed := removeSpikes(points)
+	if removed {
+		r.add(RepairSpike)
+	}
+
+	parts, split, ok := splitRing(points)
+	if split {
+		r.add(RepairSelfIntersection)
+	}
+	if !ok {
+		r.add(RepairUnrepairable)
+	}
+
+	out := make([]orb.Ring, 0, len(parts))
+	for _, part := range parts {
+		part, removed := removeSpikes(part)
+		if removed {
+			r.add(RepairSpike)
+		}
+		if len(part) < 3 {
+			continue
+		}
+
+		ring := append(orb.Ring(part), part[0])
+		if math.Abs(planar.Area(ring)) < minArea {
+			continue
+		}
+		out = append(out, ring)
+	}
+
+	return out
+}
+
+// buildPolygons assigns the rings to polygons, rings within the outer ring of a larger polygon become its holes.
+// Holes with the orientation of their outer ring are counted as repaired, all rings are oriented afterward.
+func (r *repairer) buildPolygons(rings []orb.Ring) []orb.Polygon {
+	slices.SortStableFunc(rings, func(a, b orb.Ring) int {
+		return cmp.Compare(math.Abs(planar.Area(b)), math.Abs(planar.Area(a)))
+	})
+
+	var out []orb.Polygon
+	for _, ring := range rings {
+		// the smallest polygon containing the ring, rings in holes are islands and become polygons of their own
+		parent := -1
+		for i := len(out) - 1; i >= 0; i-- {
+			if ringWithin(ring, out[i][0]) {
+				parent = i
+				break
+			}
+		}
+
+		if parent >= 0 && !slices.ContainsFunc(out[parent][1:], func(hole orb.Ring) bool { return ringWithin(ring, hole) }) {
+			if ring.Orientation() == out[parent][0].Orientation() {
+				r.add(RepairOrientation)
+			}
+			out[parent] = append(out[parent], ring)
+			continue
+		}
+
+		out = append(out, orb.Polygon{ring})
+	}
+
+	for _, polygon := range out {
+		for i, ring := range polygon {
+			if (i == 0) != (ring.Orientation() == orb.CCW) {
+				ring.Reverse()
+			}
+		}
+	}
+
+	return out
+}
+
+// ringWithin reports whether the ring lies within the outer ring. Vertices on the boundary of the outer ring are
+// ignored, as rings split from the same ring share them, the centroid decides if all vertices are on the boundary.
+func ringWithin(ring, outer orb.Ring) bool {
+	inside := false
+	for _, point := range ring {
+		if onBoundary(outer, point) {
+			continue
+		}
+		if !planar.RingContains(outer, point) {
+			return false
+		}
+		inside = true
+	}
+
+	if inside {
+		return true
+	}
+
+	center, _ := planar.CentroidArea(ring)
+	return !onBoundary(outer, center) && planar.RingContains(outer, center)
+}
+
+func onBoundary(ring orb.Ring, point orb.Point) bool {
+	for i := 0; i+1 < len(ring); i++ {
+		if geoutil.SegmentDistance(point, ring[i], ring[i+1]) < tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// removeDuplicates removes consecutive equal vertices of the cycle
+func removeDuplicates(points []orb.Point) []orb.Point {
+	out := make([]orb.Point, 0, len(points))
+	for _, point := range points {
+		if len(out) == 0 || planar.Distance(out[len(out)-1], point) >= tolerance {
+			out = append(out, point)
+		}
+	}
+
+	for len(out) > 1 && planar.Distance(out[0], out[len(out)-1]) < tolerance {
+		out = out[:len(out)-1]
+	}
+
+	return out
+}
+
+// removeSpikes removes the vertices of the cycle where it goes back along its incoming segment
+func removeSpikes(points []orb.Point) ([]orb.Point, bool) {
+	removed := false
+	for i := 0; len(points) >= 3 && i < len(points); {
+		prev, next := points[(i+len(points)-1)%len(points)], points[(i+1)%len(points)]
+		if !isSpike(prev, points[i], next) {
+			i++
+			continue
+		}
+
+		points = slices.Delete(points, i, i+1)
+		points = removeDuplicates(points)
+		removed = true
+		// the previous vertex may have become a spike
+		i = max(i-1, 0)
+	}
+
+	return points, removed
+}
+
+func isSpike(a, b, c orb.Point) bool {
+	abX, abY := b.X()-a.X(), b.Y()-a.Y()
+	bcX, bcY := c.X()-b.X(), c.Y()-b.Y()
+	if abX*bcX+abY*bcY >= 0 {
+		return false
+	}
+
+	cross := abX*bcY - abY*bcX
+	return math.Abs(cross) <= spikeSine*math.Hypot(abX, abY)*math.Hypot(bcX, bcY)
+}
+
+// splitRing splits the cycle where it crosses or touches itself into cycles which do not.
+// Crossings and touching vertices are inserted as vertices first, which leaves splitting at repeated vertices.
+// It returns false and the cycle unsplit if it has too many vertices or intersections.
+func splitRing(points []orb.Point) ([][]orb.Point, bool, bool) {
+	points, inserted, ok := insertIntersections(points)
+	if !ok {
+		return [][]orb.Point{points}, false, false
+	}
+
+	split := false
+	var out [][]orb.Point
+	stack := [][]orb.Point{points}
+	for len(stack) > 0 {
+		cycle := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		k, m, ok := repeatedVertex(cycle)
+		if !ok {
+			out = append(out, cycle)
+			continue
+		}
+
+		split = true
+		first := slices.Clone(cycle[k:m])
+		second := append(slices.Clone(cycle[m:]), cycle[:k]...)
+		stack = append(stack, first, second)
+	}
+
+	return out, split || inserted, true
+}
+
+// insertIntersections inserts the points where the segments of the cycle cross and the vertices touching other
+// segments into these segments. All pairs of segments are checked once and the points are inserted afterward,
+// it returns false and the cycle unchanged if it has more than maxRepairVertices vertices or more than
+// maxRepairIntersections intersections.
+func insertIntersections(points []orb.Point) ([]orb.Point, bool, bool) {
+	n := len(points)
+	if n > maxRepairVertices {
+		return points, false, false
+	}
+
+	segment := func(i int) (orb.Point, orb.Point) { return points[i], points[(i+1)%n] }
+	bounds := make([]orb.Bound, n)
+	for i := range bounds {
+		a, b := segment(i)
+		bounds[i] = orb.Bound{Min: a, Max: a}.Extend(b).Pad(tolerance)
+	}
+
+	// insert holds the points to insert into the segment starting at each vertex
+	insert := make([][]orb.Point, n)
+	intersections := 0
+	for i := 0; i < n; i++ {
+		a, b := segment(i)
+		for j := i + 1; j < n; j++ {
+			if j == i+1 || (j+1)%n == i || !bounds[i].Intersects(bounds[j]) {
+				continue
+			}
+
+			c, d := segment(j)
+			if point, ok := geoutil.Crossing(a, b, c, d, tolerance); ok {
+				insert[i] = append(insert[i], point)
+				insert[j] = append(insert[j], point)
+				intersections++
+			}
+
+			for _, point := range [2]orb.Point{c, d} {
+				if touches(point, a, b) {
+					insert[i] = append(insert[i], point)
+					intersections++
+				}
+			}
+			for _, point := range [2]orb.Point{a, b} {
+				if touches(point, c, d) {
+					insert[j] = append(insert[j], point)
+					intersections++
+				}
+			}
+
+			if intersections > maxRepairIntersections {
+				return points, false, false
+			}
+		}
+	}
+
+	if intersections == 0 {
+		return points, false, true
+	}
+
+	out := make([]orb.Point, 0, n+2*intersections)
+	for i, point := range points {
+		out = append(out, point)
+
+		next := points[(i+1)%n]
+		slices.SortFunc(insert[i], func(p, q orb.Point) int {
+			return cmp.Compare(planar.Distance(point, p), planar.Distance(point, q))
+		})
+		for _, p := range insert[i] {
+			if planar.Distance(out[len(out)-1], p) >= tolerance && planar.Distance(p, next) >= tolerance {
+				out = append(out, p)
+			}
+		}
+	}
+
+	return out, true, true
+}
+
+// touches reports whether the point touches the interior of the segment ab
+func touches(point, a, b orb.Point) bool {
+	return geoutil.SegmentDistance(point, a, b) < tolerance &&
+		planar.Distance(point, a) >= tolerance && planar.Distance(point, b) >= tolerance
+}
+
+// repeatedVertex returns a pair of vertices of the cycle at the same position.
+// The vertices are looked up in a grid of cells of the tolerance, equal vertices are in the same or adjacent cells.
+func repeatedVertex(points []orb.Point) (int, int, bool) {
+	grid := make(map[[2]int][]int, len(points))
+	for m, point := range points {
+		cell := [2]int{int(math.Floor(point.X() / tolerance)), int(math.Floor(point.Y() / tolerance))}
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				for _, k := range grid[[2]int{cell[0] + dx, cell[1] + dy}] {
+					if planar.Distance(points[k], point) < tolerance {
+						return k, m, true
+					}
+				}
+			}
+		}
+		grid[cell] = append(grid[cell], m)
+	}
+	return 0, 0, false
+}
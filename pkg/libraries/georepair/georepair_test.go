@@ -0,0 +1,149 @@
+package georepair_test
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/georepair"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// scale turns the test coordinates into degrees, a unit is about 1.1 m at the equator
+const scale = 1e-5
+
+func line(coords ...[2]float64) orb.LineString {
+	out := make(orb.LineString, 0, len(coords))
+	for _, c := range coords {
+		out = append(out, orb.Point{c[0] * scale, c[1] * scale})
+	}
+	return out
+}
+
+func TestPolygons(t *testing.T) {
+	square := line([2]float64{0, 0}, [2]float64{10, 0}, [2]float64{10, 10}, [2]float64{0, 10}, [2]float64{0, 0})
+	clockwiseHole := line([2]float64{2, 2}, [2]float64{2, 8}, [2]float64{8, 8}, [2]float64{8, 2}, [2]float64{2, 2})
+	counterClockwiseHole := line([2]float64{2, 2}, [2]float64{8, 2}, [2]float64{8, 8}, [2]float64{2, 8}, [2]float64{2, 2})
+
+	tests := []struct {
+		name     string
+		lines    []orb.LineString
+		polygons int
+		area     float64
+		repairs  []georepair.Repair
+	}{
+		{
+			name:     "valid",
+			lines:    []orb.LineString{square},
+			polygons: 1,
+			area:     100,
+		},
+		{
+			name:     "valid with hole",
+			lines:    []orb.LineString{square, clockwiseHole},
+			polygons: 1,
+			area:     64,
+		},
+		{
+			name:     "hole with outer orientation",
+			lines:    []orb.LineString{square, counterClockwiseHole},
+			polygons: 1,
+			area:     64,
+			repairs:  []georepair.Repair{georepair.RepairOrientation},
+		},
+		{
+			name:     "nearly closed",
+			lines:    []orb.LineString{line([2]float64{0, 0}, [2]float64{10, 0}, [2]float64{10, 10}, [2]float64{0, 10}, [2]float64{0, 0.2})},
+			polygons: 1,
+			area:     100,
+			repairs:  []georepair.Repair{georepair.RepairClosedRing},
+		},
+		{
+			name:     "open",
+			lines:    []orb.LineString{line([2]float64{0, 0}, [2]float64{10, 0}, [2]float64{10, 10}, [2]float64{0, 10})},
+			polygons: 0,
+		},
+		{
+			name:     "spike",
+			lines:    []orb.LineString{line([2]float64{0, 0}, [2]float64{10, 0}, [2]float64{20, 0}, [2]float64{10, 0}, [2]float64{10, 10}, [2]float64{0, 10}, [2]float64{0, 0})},
+			polygons: 1,
+			area:     100,
+			repairs:  []georepair.Repair{georepair.RepairSpike},
+		},
+		{
+			name:     "bow tie",
+			lines:    []orb.LineString{line([2]float64{0, 0}, [2]float64{10, 10}, [2]float64{10, 0}, [2]float64{0, 10}, [2]float64{0, 0})},
+			polygons: 2,
+			area:     50,
+			repairs:  []georepair.Repair{georepair.RepairSelfIntersection},
+		},
+		{
+			name:     "touching itself",
+			lines:    []orb.LineString{line([2]float64{0, 0}, [2]float64{10, 0}, [2]float64{5, 5}, [2]float64{10, 10}, [2]float64{0, 10}, [2]float64{5, 5}, [2]float64{0, 0})},
+			polygons: 2,
+			area:     50,
+			repairs:  []georepair.Repair{georepair.RepairSelfIntersection},
+		},
+		{
+			name: "zigzags crossing each other",
+			lines: []orb.LineString{line([2]float64{0, 0}, [2]float64{10, 10}, [2]float64{20, 0}, [2]float64{30, 10}, [2]float64{40, 0},
+				[2]float64{40, 10}, [2]float64{30, 0}, [2]float64{20, 10}, [2]float64{10, 0}, [2]float64{0, 10}, [2]float64{0, 0})},
+			polygons: 5,
+			area:     200,
+			repairs:  []georepair.Repair{georepair.RepairSelfIntersection},
+		},
+		{
+			name:     "split ways",
+			lines:    []orb.LineString{line([2]float64{0, 0}, [2]float64{10, 0}, [2]float64{10, 10}), line([2]float64{0, 0}, [2]float64{0, 10}, [2]float64{10, 10})},
+			polygons: 1,
+			area:     100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygons, repairs := georepair.Polygons(tt.lines)
+
+			if len(polygons) != tt.polygons {
+				t.Fatalf("expected %d polygons, got %d: %v", tt.polygons, len(polygons), polygons)
+			}
+
+			if !reflect.DeepEqual(repairs, tt.repairs) {
+				t.Errorf("expected repairs %v, got %v", tt.repairs, repairs)
+			}
+
+			// 1 unit is 1.11 m, areas are compared in square units
+			unit := geo.Distance(orb.Point{0, 0}, orb.Point{scale, 0})
+			if area := geo.Area(polygons) / (unit * unit); math.Abs(area-tt.area) > 0.5 {
+				t.Errorf("expected an area of %.1f, got %.1f", tt.area, area)
+			}
+
+			for _, polygon := range polygons {
+				for i, ring := range polygon {
+					if want := i == 0; (ring.Orientation() == orb.CCW) != want || !ring.Closed() {
+						t.Errorf("ring %d of %v is not closed or wrongly oriented", i, polygon)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPolygons_Unrepairable(t *testing.T) {
+	// a weave of 40 horizontal and 40 vertical lines crosses itself 1600 times
+	var coords [][2]float64
+	for i := 0; i < 40; i++ {
+		y := float64(i) + 0.5
+		coords = append(coords, [2]float64{float64(-1 + 42*(i%2)), y}, [2]float64{float64(41 - 42*(i%2)), y})
+	}
+	for i := 0; i < 40; i++ {
+		x := float64(i) + 0.5
+		coords = append(coords, [2]float64{x, float64(41 - 42*(i%2))}, [2]float64{x, float64(-1 + 42*(i%2))})
+	}
+	coords = append(coords, coords[0])
+
+	polygons, repairs := georepair.Polygons([]orb.LineString{line(coords...)})
+	if len(polygons) != 1 || !reflect.DeepEqual(repairs, []georepair.Repair{georepair.RepairUnrepairable}) {
+		t.Errorf("expected the ring to be kept unsplit as unrepairable, got %d polygons and repairs %v", len(polygons), repairs)
+	}
+}
@@ -0,0 +1,23 @@
+package geoutil
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// Contains reports whether the point lies within an areal part of geom, other geometries never contain a point.
+func Contains(geom orb.Geometry, point orb.Point) bool {
+	switch g := geom.(type) {
+	case orb.Polygon:
+		return planar.PolygonContains(g, point)
+	case orb.MultiPolygon:
+		return planar.MultiPolygonContains(g, point)
+	case orb.Collection:
+		for _, child := range g {
+			if Contains(child, point) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package geoutil_test
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"math"
+	"testing"
+)
+
+func TestProjection(t *testing.T) {
+	origin := orb.Point{11.5655, 48.1391}
+	proj := geoutil.NewProjection(origin)
+
+	point := orb.Point{11.5665, 48.1401}
+	xy := proj.ToXY(point)
+	if d := math.Hypot(xy.X(), xy.Y()); math.Abs(d-geo.Distance(origin, point)) > 0.5 {
+		t.Errorf("expected the projected distance to be about %v m, got %v", geo.Distance(origin, point), d)
+	}
+
+	if back := proj.FromXY(xy); math.Abs(back.Lon()-point.Lon()) > 1e-12 || math.Abs(back.Lat()-point.Lat()) > 1e-12 {
+		t.Errorf("expected %v after projecting back, got %v", point, back)
+	}
+
+	polygon := orb.Polygon{{origin, point, {11.5655, 48.1401}, origin}}
+	projected := proj.GeometryToXY(polygon).(orb.Polygon)
+	if !polygon[0][1].Equal(point) || !projected[0][1].Equal(xy) {
+		t.Errorf("expected a projected copy, got %v of %v", projected, polygon)
+	}
+}
+
+func TestCrossing(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b, c, d orb.Point
+		crosses    bool
+	}{
+		{"crossing", orb.Point{0, 0}, orb.Point{2, 2}, orb.Point{0, 2}, orb.Point{2, 0}, true},
+		{"touching", orb.Point{0, 0}, orb.Point{2, 0}, orb.Point{1, 0}, orb.Point{1, 2}, false},
+		{"within tolerance", orb.Point{0, 0}, orb.Point{2, 0}, orb.Point{1, -0.005}, orb.Point{1, 2}, false},
+		{"parallel", orb.Point{0, 0}, orb.Point{2, 0}, orb.Point{0, 1}, orb.Point{2, 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point, ok := geoutil.Crossing(tt.a, tt.b, tt.c, tt.d, 0.01)
+			if ok != tt.crosses {
+				t.Fatalf("expected crossing %v, got %v", tt.crosses, ok)
+			}
+			if ok && !point.Equal(orb.Point{1, 1}) {
+				t.Errorf("expected the crossing at (1, 1), got %v", point)
+			}
+		})
+	}
+}
+
+func TestSegmentDistance(t *testing.T) {
+	a, b := orb.Point{0, 0}, orb.Point{4, 0}
+	for point, expected := range map[orb.Point]float64{{2, 3}: 3, {-3, 4}: 5, {4, 0}: 0, {7, -4}: 5} {
+		if d := geoutil.SegmentDistance(point, a, b); math.Abs(d-expected) > 1e-12 {
+			t.Errorf("expected the distance of %v to be %v, got %v", point, expected, d)
+		}
+	}
+}
+
+func TestAssembleRings(t *testing.T) {
+	lines := []orb.LineString{
+		{{0, 0}, {1, 0}},
+		{{1, 1}, {1, 0}},
+		{{1, 1}, {0, 1}, {0, 0}},
+		{{5, 5}, {6, 5}},
+	}
+
+	rings, open := geoutil.AssembleRings(lines)
+	if len(rings) != 1 || len(rings[0]) != 5 || !rings[0][0].Equal(rings[0][4]) {
+		t.Errorf("expected one closed ring, got %v", rings)
+	}
+
+	if len(open) != 1 || len(open[0]) != 2 {
+		t.Errorf("expected the unclosed line, got %v", open)
+	}
+}
@@ -0,0 +1,45 @@
+package geoutil
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/project"
+	"math"
+)
+
+// MetersPerDegree is the length of a degree of latitude
+const MetersPerDegree = orb.EarthRadius * math.Pi / 180
+
+// Projection is an equirectangular projection into meters around an origin, precise enough within a building.
+type Projection struct {
+	origin orb.Point
+	kx     float64
+}
+
+func NewProjection(origin orb.Point) Projection {
+	return Projection{origin: origin, kx: MetersPerDegree * math.Cos(origin.Lat()*math.Pi/180)}
+}
+
+// ToXY returns the point in meters relative to the origin
+func (p Projection) ToXY(point orb.Point) orb.Point {
+	return orb.Point{(point.Lon() - p.origin.Lon()) * p.kx, (point.Lat() - p.origin.Lat()) * MetersPerDegree}
+}
+
+// FromXY returns the longitude and latitude of the point given in meters relative to the origin
+func (p Projection) FromXY(point orb.Point) orb.Point {
+	return orb.Point{point.X()/p.kx + p.origin.Lon(), point.Y()/MetersPerDegree + p.origin.Lat()}
+}
+
+// LonDegrees returns the degrees of longitude spanning the meters at the origin
+func (p Projection) LonDegrees(meters float64) float64 {
+	return meters / p.kx
+}
+
+// GeometryToXY returns a projected copy of the geometry
+func (p Projection) GeometryToXY(geom orb.Geometry) orb.Geometry {
+	return project.Geometry(orb.Clone(geom), p.ToXY)
+}
+
+// GeometryFromXY returns a copy of the projected geometry in longitude and latitude
+func (p Projection) GeometryFromXY(geom orb.Geometry) orb.Geometry {
+	return project.Geometry(orb.Clone(geom), p.FromXY)
+}
@@ -0,0 +1,51 @@
+package geoutil
+
+import (
+	"github.com/paulmach/orb"
+	"slices"
+)
+
+// AssembleRings joins the lines at their ends into closed rings, the lines which can not be closed are returned
+// joined as far as possible
+func AssembleRings(lines []orb.LineString) ([]orb.Ring, []orb.LineString) {
+	var rings []orb.Ring
+	var open []orb.LineString
+
+	used := make([]bool, len(lines))
+	for i, line := range lines {
+		if used[i] || len(line) < 2 {
+			continue
+		}
+		used[i] = true
+
+		chain := slices.Clone(line)
+		for !chain[0].Equal(chain[len(chain)-1]) {
+			end := chain[len(chain)-1]
+			next := -1
+			for j, other := range lines {
+				if !used[j] && len(other) >= 2 && (other[0].Equal(end) || other[len(other)-1].Equal(end)) {
+					next = j
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+
+			used[next] = true
+			other := slices.Clone(lines[next])
+			if !other[0].Equal(end) {
+				slices.Reverse(other)
+			}
+			chain = append(chain, other[1:]...)
+		}
+
+		if len(chain) >= 4 && chain[0].Equal(chain[len(chain)-1]) {
+			rings = append(rings, orb.Ring(chain))
+		} else {
+			open = append(open, chain)
+		}
+	}
+
+	return rings, open
+}
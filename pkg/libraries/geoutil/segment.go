@@ -0,0 +1,46 @@
+package geoutil
+
+import (
+	"github.com/paulmach/orb"
+	"math"
+)
+
+// Crossing returns where the planar segments ab and cd properly cross, segments only touching within the tolerance
+// do not cross
+func Crossing(a, b, c, d orb.Point, tolerance float64) (orb.Point, bool) {
+	d1, d2 := Side(c, d, a, tolerance), Side(c, d, b, tolerance)
+	d3, d4 := Side(a, b, c, tolerance), Side(a, b, d, tolerance)
+	if d1*d2 >= 0 || d3*d4 >= 0 {
+		return orb.Point{}, false
+	}
+
+	t := d1 / (d1 - d2)
+	return orb.Point{a.X() + t*(b.X()-a.X()), a.Y() + t*(b.Y()-a.Y())}, true
+}
+
+// Side returns the signed distance of the point to the line through ab, positive on the left.
+// Distances within the tolerance are zero.
+func Side(a, b, point orb.Point, tolerance float64) float64 {
+	l := math.Hypot(b.X()-a.X(), b.Y()-a.Y())
+	if l == 0 {
+		return 0
+	}
+
+	d := ((b.X()-a.X())*(point.Y()-a.Y()) - (b.Y()-a.Y())*(point.X()-a.X())) / l
+	if math.Abs(d) < tolerance {
+		return 0
+	}
+	return d
+}
+
+// SegmentDistance returns the planar distance of the point to the segment ab
+func SegmentDistance(point, a, b orb.Point) float64 {
+	abX, abY := b.X()-a.X(), b.Y()-a.Y()
+	l := abX*abX + abY*abY
+	if l == 0 {
+		return math.Hypot(point.X()-a.X(), point.Y()-a.Y())
+	}
+
+	t := math.Max(0, math.Min(1, ((point.X()-a.X())*abX+(point.Y()-a.Y())*abY)/l))
+	return math.Hypot(point.X()-(a.X()+t*abX), point.Y()-(a.Y()+t*abY))
+}
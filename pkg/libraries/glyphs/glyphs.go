@@ -0,0 +1,176 @@
+package glyphs
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protowire"
+	"slices"
+)
+
+// field numbers of the glyphs protobuf messages.
+// For reference see: https://github.com/mapbox/glyph-pbf-composite/blob/master/proto/glyphs.proto
+const (
+	glyphsStacksField   protowire.Number = 1
+	fontstackNameField  protowire.Number = 1
+	fontstackRangeField protowire.Number = 2
+	fontstackGlyphField protowire.Number = 3
+	glyphIDField        protowire.Number = 1
+)
+
+var errMalformed = errors.New("malformed glyphs pbf")
+
+// Glyph is a single encoded glyph message together with its codepoint.
+type Glyph struct {
+	ID  uint64
+	Raw []byte
+}
+
+// Fontstack is the decoded content of a glyph range pbf.
+type Fontstack struct {
+	Name   string
+	Range  string
+	Glyphs []Glyph
+}
+
+// Decode decodes the first fontstack of a glyph range pbf.
+func Decode(data []byte) (*Fontstack, error) {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, errMalformed
+		}
+		data = data[n:]
+
+		if num == glyphsStacksField && typ == protowire.BytesType {
+			stack, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, errMalformed
+			}
+			return decodeFontstack(stack)
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if n < 0 {
+			return nil, errMalformed
+		}
+		data = data[n:]
+	}
+
+	return nil, fmt.Errorf("%w: no fontstack", errMalformed)
+}
+
+func decodeFontstack(data []byte) (*Fontstack, error) {
+	out := &Fontstack{}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, errMalformed
+		}
+		data = data[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, errMalformed
+			}
+			data = data[n:]
+			continue
+		}
+
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return nil, errMalformed
+		}
+		data = data[n:]
+
+		switch num {
+		case fontstackNameField:
+			out.Name = string(value)
+		case fontstackRangeField:
+			out.Range = string(value)
+		case fontstackGlyphField:
+			id, err := decodeGlyphID(value)
+			if err != nil {
+				return nil, err
+			}
+			out.Glyphs = append(out.Glyphs, Glyph{ID: id, Raw: value})
+		}
+	}
+
+	return out, nil
+}
+
+func decodeGlyphID(data []byte) (uint64, error) {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return 0, errMalformed
+		}
+		data = data[n:]
+
+		if num == glyphIDField && typ == protowire.VarintType {
+			id, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return 0, errMalformed
+			}
+			return id, nil
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if n < 0 {
+			return 0, errMalformed
+		}
+		data = data[n:]
+	}
+
+	return 0, fmt.Errorf("%w: glyph without id", errMalformed)
+}
+
+// Encode encodes the fontstack as a glyph range pbf.
+func Encode(stack *Fontstack) []byte {
+	var inner []byte
+	inner = protowire.AppendTag(inner, fontstackNameField, protowire.BytesType)
+	inner = protowire.AppendString(inner, stack.Name)
+	inner = protowire.AppendTag(inner, fontstackRangeField, protowire.BytesType)
+	inner = protowire.AppendString(inner, stack.Range)
+	for _, glyph := range stack.Glyphs {
+		inner = protowire.AppendTag(inner, fontstackGlyphField, protowire.BytesType)
+		inner = protowire.AppendBytes(inner, glyph.Raw)
+	}
+
+	var out []byte
+	out = protowire.AppendTag(out, glyphsStacksField, protowire.BytesType)
+	out = protowire.AppendBytes(out, inner)
+	return out
+}
+
+// Combine merges the fontstacks into a single one named name.
+// Glyphs of earlier stacks take precedence, later stacks only fill in missing codepoints.
+func Combine(name string, stacks ...*Fontstack) *Fontstack {
+	out := &Fontstack{
+		Name: name,
+	}
+
+	seen := make(map[uint64]struct{})
+	for _, stack := range stacks {
+		if out.Range == "" {
+			out.Range = stack.Range
+		}
+
+		for _, glyph := range stack.Glyphs {
+			if _, ok := seen[glyph.ID]; ok {
+				continue
+			}
+			seen[glyph.ID] = struct{}{}
+			out.Glyphs = append(out.Glyphs, glyph)
+		}
+	}
+
+	slices.SortFunc(out.Glyphs, func(a, b Glyph) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return out
+}
@@ -0,0 +1,43 @@
+package glyphs_test
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/glyphs"
+	"google.golang.org/protobuf/encoding/protowire"
+	"testing"
+)
+
+func glyph(id uint64, advance uint64) glyphs.Glyph {
+	var raw []byte
+	raw = protowire.AppendTag(raw, 1, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, id)
+	raw = protowire.AppendTag(raw, 7, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, advance)
+	return glyphs.Glyph{ID: id, Raw: raw}
+}
+
+func TestCombine(t *testing.T) {
+	primary := &glyphs.Fontstack{Name: "Primary", Range: "0-255", Glyphs: []glyphs.Glyph{glyph(65, 1), glyph(66, 1)}}
+	fallback := &glyphs.Fontstack{Name: "Fallback", Range: "0-255", Glyphs: []glyphs.Glyph{glyph(64, 2), glyph(65, 2)}}
+
+	data := glyphs.Encode(glyphs.Combine("Primary,Fallback", primary, fallback))
+
+	combined, err := glyphs.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if combined.Name != "Primary,Fallback" || combined.Range != "0-255" {
+		t.Errorf("unexpected fontstack %q %q", combined.Name, combined.Range)
+	}
+
+	expected := []glyphs.Glyph{glyph(64, 2), glyph(65, 1), glyph(66, 1)}
+	if len(combined.Glyphs) != len(expected) {
+		t.Fatalf("expected %d glyphs, got %d", len(expected), len(combined.Glyphs))
+	}
+
+	for i, g := range combined.Glyphs {
+		if g.ID != expected[i].ID || string(g.Raw) != string(expected[i].Raw) {
+			t.Errorf("glyph %d: expected %+v, got %+v", i, expected[i], g)
+		}
+	}
+}
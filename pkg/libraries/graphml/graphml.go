@@ -0,0 +1,164 @@
+package graphml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const namespace = "http://graphml.graphdrawing.org/xmlns"
+
+// Key declares an attribute of nodes or edges.
+type Key struct {
+	ID string
+	// Name is the name of the attribute, the id is used if it is empty. Keys for nodes and edges need distinct ids
+	// but may share their name.
+	Name string
+	// For is either node or edge
+	For string
+	// Type is one of boolean, int, long, float, double or string
+	Type string
+}
+
+// Node is a vertex, its data is keyed by the ids of the keys.
+type Node struct {
+	ID   string
+	Data map[string]any
+}
+
+// Edge connects the nodes Source and Target, its data is keyed by the ids of the keys.
+type Edge struct {
+	Source string
+	Target string
+	Data   map[string]any
+}
+
+// Graph is a graph in the GraphML format, as read by e.g. NetworkX, Gephi or yEd.
+// For reference see: http://graphml.graphdrawing.org/specification.html
+type Graph struct {
+	Directed bool
+	Keys     []Key
+	Nodes    []Node
+	Edges    []Edge
+}
+
+type xmlDocument struct {
+	XMLName xml.Name `xml:"graphml"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Keys    []xmlKey `xml:"key"`
+	Graph   xmlGraph `xml:"graph"`
+}
+
+type xmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type xmlGraph struct {
+	ID          string    `xml:"id,attr"`
+	EdgeDefault string    `xml:"edgedefault,attr"`
+	Nodes       []xmlNode `xml:"node"`
+	Edges       []xmlEdge `xml:"edge"`
+}
+
+type xmlNode struct {
+	ID   string    `xml:"id,attr"`
+	Data []xmlData `xml:"data"`
+}
+
+type xmlEdge struct {
+	Source string    `xml:"source,attr"`
+	Target string    `xml:"target,attr"`
+	Data   []xmlData `xml:"data"`
+}
+
+type xmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Write writes the graph as GraphML document. Data is written in the order of the keys, nil values are omitted.
+func Write(w io.Writer, graph Graph) error {
+	doc := xmlDocument{
+		Xmlns: namespace,
+		Graph: xmlGraph{ID: "G", EdgeDefault: "undirected"},
+	}
+	if graph.Directed {
+		doc.Graph.EdgeDefault = "directed"
+	}
+
+	nodeKeys, edgeKeys := make([]string, 0), make([]string, 0)
+	for _, key := range graph.Keys {
+		name := key.Name
+		if name == "" {
+			name = key.ID
+		}
+		doc.Keys = append(doc.Keys, xmlKey{ID: key.ID, For: key.For, Name: name, Type: key.Type})
+		if key.For == "edge" {
+			edgeKeys = append(edgeKeys, key.ID)
+		} else {
+			nodeKeys = append(nodeKeys, key.ID)
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		data, err := formatData(nodeKeys, node.Data)
+		if err != nil {
+			return fmt.Errorf("invalid data of node %s: %w", node.ID, err)
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, xmlNode{ID: node.ID, Data: data})
+	}
+
+	for _, edge := range graph.Edges {
+		data, err := formatData(edgeKeys, edge.Data)
+		if err != nil {
+			return fmt.Errorf("invalid data of edge %s-%s: %w", edge.Source, edge.Target, err)
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, xmlEdge{Source: edge.Source, Target: edge.Target, Data: data})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	return nil
+}
+
+func formatData(keys []string, data map[string]any) ([]xmlData, error) {
+	out := make([]xmlData, 0, len(data))
+	for _, key := range keys {
+		value, ok := data[key]
+		if !ok || value == nil {
+			continue
+		}
+
+		var str string
+		switch value := value.(type) {
+		case string:
+			str = value
+		case bool:
+			str = strconv.FormatBool(value)
+		case int:
+			str = strconv.Itoa(value)
+		case int64:
+			str = strconv.FormatInt(value, 10)
+		case float64:
+			str = strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("unsupported type %T of %s", value, key)
+		}
+
+		out = append(out, xmlData{Key: key, Value: str})
+	}
+
+	return out, nil
+}
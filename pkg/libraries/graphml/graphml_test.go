@@ -0,0 +1,54 @@
+package graphml_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/graphml"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	graph := graphml.Graph{
+		Keys: []graphml.Key{
+			{ID: "name", For: "node", Type: "string"},
+			{ID: "level", For: "node", Type: "double"},
+			{ID: "length", For: "edge", Type: "double"},
+		},
+		Nodes: []graphml.Node{
+			{ID: "a", Data: map[string]any{"name": "Room <A>", "level": 1.5}},
+			{ID: "b", Data: map[string]any{"level": -1.0}},
+		},
+		Edges: []graphml.Edge{
+			{Source: "a", Target: "b", Data: map[string]any{"length": 12.25}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := graphml.Write(&buf, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, expected := range []string{
+		`<graph id="G" edgedefault="undirected">`,
+		`<key id="level" for="node" attr.name="level" attr.type="double"></key>`,
+		`<data key="name">Room &lt;A&gt;</data>`,
+		`<data key="level">1.5</data>`,
+		`<edge source="a" target="b">`,
+		`<data key="length">12.25</data>`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %s, got:\n%s", expected, out)
+		}
+	}
+
+	if err := xml.Unmarshal(buf.Bytes(), new(any)); err != nil {
+		t.Errorf("output is not valid XML: %v", err)
+	}
+
+	graph.Nodes[0].Data["name"] = []string{"invalid"}
+	if err := graphml.Write(&bytes.Buffer{}, graph); err == nil {
+		t.Error("expected an error for unsupported data")
+	}
+}
@@ -0,0 +1,196 @@
+package idset
+
+import (
+	"github.com/paulmach/osm"
+	"math/bits"
+	"slices"
+)
+
+const (
+	// containerBits is the number of low bits of an id stored within a container
+	containerBits = 16
+	// bitmapWords is the number of words of a bitmap container, one bit per low value
+	bitmapWords = 1 << containerBits / 64
+	// maxArrayLen is the number of values above which a container switches from a sorted array to a bitmap,
+	// from there on the bitmap is smaller
+	maxArrayLen = bitmapWords * 64 / 16
+	// containerOverhead approximates the bytes of a container besides its values, including its map entry
+	containerOverhead = 64
+)
+
+// Set is a compact set of osm feature ids. Ids are kept per element type in containers of 2^16 consecutive refs,
+// which are sorted arrays while sparse and bitmaps while dense. Ids in osm files are mostly dense, so a kept id
+// takes about 2 bytes instead of the 40 of a map entry.
+// The zero value is not usable, use New.
+type Set struct {
+	types map[osm.Type]*refSet
+	n     int
+}
+
+func New() *Set {
+	return &Set{types: make(map[osm.Type]*refSet)}
+}
+
+// Add adds the id and reports whether it was not in the set before.
+func (s *Set) Add(id osm.FeatureID) bool {
+	refs, ok := s.types[id.Type()]
+	if !ok {
+		refs = &refSet{containers: make(map[uint64]*container)}
+		s.types[id.Type()] = refs
+	}
+
+	if !refs.add(uint64(id.Ref())) {
+		return false
+	}
+	s.n++
+	return true
+}
+
+func (s *Set) Contains(id osm.FeatureID) bool {
+	refs, ok := s.types[id.Type()]
+	return ok && refs.contains(uint64(id.Ref()))
+}
+
+// Remove removes the id and reports whether it was in the set.
+func (s *Set) Remove(id osm.FeatureID) bool {
+	refs, ok := s.types[id.Type()]
+	if !ok || !refs.remove(uint64(id.Ref())) {
+		return false
+	}
+	s.n--
+	return true
+}
+
+// Len returns the number of ids in the set.
+func (s *Set) Len() int {
+	return s.n
+}
+
+// Bytes approximates the memory used by the set.
+func (s *Set) Bytes() int {
+	out := 0
+	for _, refs := range s.types {
+		for _, c := range refs.containers {
+			out += containerOverhead + cap(c.array)*2 + len(c.bitmap)*8
+		}
+	}
+	return out
+}
+
+type refSet struct {
+	containers map[uint64]*container
+}
+
+func (r *refSet) add(ref uint64) bool {
+	key, low := ref>>containerBits, uint16(ref)
+	c, ok := r.containers[key]
+	if !ok {
+		c = &container{}
+		r.containers[key] = c
+	}
+	return c.add(low)
+}
+
+func (r *refSet) contains(ref uint64) bool {
+	c, ok := r.containers[ref>>containerBits]
+	return ok && c.contains(uint16(ref))
+}
+
+func (r *refSet) remove(ref uint64) bool {
+	key := ref >> containerBits
+	c, ok := r.containers[key]
+	if !ok || !c.remove(uint16(ref)) {
+		return false
+	}
+
+	if c.n == 0 {
+		delete(r.containers, key)
+	}
+	return true
+}
+
+// container holds the low bits of the refs sharing their high bits, either in array or, once dense, in bitmap
+type container struct {
+	array  []uint16
+	bitmap []uint64
+	n      int
+}
+
+func (c *container) add(low uint16) bool {
+	if c.bitmap != nil {
+		word, bit := low/64, uint64(1)<<(low%64)
+		if c.bitmap[word]&bit != 0 {
+			return false
+		}
+		c.bitmap[word] |= bit
+		c.n++
+		return true
+	}
+
+	// ids are mostly added in ascending order, which makes this an append
+	i, found := slices.BinarySearch(c.array, low)
+	if found {
+		return false
+	}
+	c.array = slices.Insert(c.array, i, low)
+	c.n++
+
+	if len(c.array) > maxArrayLen {
+		c.toBitmap()
+	}
+	return true
+}
+
+func (c *container) contains(low uint16) bool {
+	if c.bitmap != nil {
+		return c.bitmap[low/64]&(uint64(1)<<(low%64)) != 0
+	}
+
+	_, found := slices.BinarySearch(c.array, low)
+	return found
+}
+
+func (c *container) remove(low uint16) bool {
+	if c.bitmap != nil {
+		word, bit := low/64, uint64(1)<<(low%64)
+		if c.bitmap[word]&bit == 0 {
+			return false
+		}
+		c.bitmap[word] &^= bit
+		c.n--
+
+		if c.n <= maxArrayLen/2 {
+			c.toArray()
+		}
+		return true
+	}
+
+	i, found := slices.BinarySearch(c.array, low)
+	if !found {
+		return false
+	}
+	c.array = slices.Delete(c.array, i, i+1)
+	c.n--
+	return true
+}
+
+func (c *container) toBitmap() {
+	c.bitmap = make([]uint64, bitmapWords)
+	for _, low := range c.array {
+		c.bitmap[low/64] |= uint64(1) << (low % 64)
+	}
+	c.array = nil
+}
+
+// toArray switches back to an array once the bitmap got sparse, half of maxArrayLen avoids switching back and forth
+func (c *container) toArray() {
+	c.array = make([]uint16, 0, c.n)
+	for word, value := range c.bitmap {
+		for value != 0 {
+			bit := bits.TrailingZeros64(value)
+			c.array = append(c.array, uint16(word*64+bit))
+			value &= value - 1
+		}
+	}
+	c.bitmap = nil
+}
@@ -0,0 +1,85 @@
+package idset_test
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/idset"
+	"github.com/paulmach/osm"
+	"math/rand"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	set := idset.New()
+	reference := make(map[osm.FeatureID]struct{})
+
+	rng := rand.New(rand.NewSource(1))
+	ids := make([]osm.FeatureID, 0, 30000)
+	// dense refs switch their containers to bitmaps, sparse ones stay arrays
+	for i := range 20000 {
+		ids = append(ids, osm.NodeID(1_000_000+i).FeatureID())
+	}
+	for range 10000 {
+		ref := rng.Int63n(12_000_000_000)
+		ids = append(ids, osm.NodeID(ref).FeatureID(), osm.WayID(ref).FeatureID(), osm.RelationID(ref%100).FeatureID())
+	}
+
+	for _, id := range ids {
+		_, exists := reference[id]
+		if added := set.Add(id); added == exists {
+			t.Fatalf("add %v reported %v", id, added)
+		}
+		reference[id] = struct{}{}
+	}
+
+	if set.Len() != len(reference) {
+		t.Fatalf("expected %d ids, got %d", len(reference), set.Len())
+	}
+
+	for id := range reference {
+		if !set.Contains(id) {
+			t.Fatalf("expected %v to be contained", id)
+		}
+	}
+
+	for _, id := range []osm.FeatureID{osm.NodeID(999_999).FeatureID(), osm.WayID(1_000_000).FeatureID(), osm.RelationID(100).FeatureID()} {
+		if _, ok := reference[id]; !ok && set.Contains(id) {
+			t.Errorf("expected %v not to be contained", id)
+		}
+	}
+
+	// removing most of the dense refs switches their containers back to arrays
+	removed := 0
+	for id := range reference {
+		if id.Type() == osm.TypeNode && id.Ref() >= 1_000_000 && id.Ref() < 1_019_000 {
+			if !set.Remove(id) {
+				t.Fatalf("expected %v to be removed", id)
+			}
+			delete(reference, id)
+			removed++
+		}
+	}
+
+	if set.Remove(osm.NodeID(1_000_000).FeatureID()) {
+		t.Error("expected removing a missing id to fail")
+	}
+
+	if set.Len() != len(reference) {
+		t.Fatalf("expected %d ids after removing %d, got %d", len(reference), removed, set.Len())
+	}
+	for id := range reference {
+		if !set.Contains(id) {
+			t.Fatalf("expected %v to be contained after removing", id)
+		}
+	}
+}
+
+func TestSet_Bytes(t *testing.T) {
+	set := idset.New()
+	for i := range 1_000_000 {
+		set.Add(osm.NodeID(i * 3).FeatureID())
+	}
+
+	// a third of the refs is kept, dense enough for bitmaps with 3 bits per id
+	if bytes := set.Bytes(); bytes > 1_000_000/2 {
+		t.Errorf("expected less than half a byte per id, got %d bytes", bytes)
+	}
+}
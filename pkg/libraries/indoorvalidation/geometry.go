@@ -0,0 +1,119 @@
+package indoorvalidation
+
+import (
+	"cmp"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"math"
+	"slices"
+)
+
+// tolerance is the distance in meters below which points are considered to touch
+const tolerance = 0.01
+
+// buildPolygons assigns the rings to polygons, rings within the outer ring of a larger polygon become its holes
+func buildPolygons(rings []orb.Ring) []orb.Polygon {
+	slices.SortStableFunc(rings, func(a, b orb.Ring) int {
+		return cmp.Compare(math.Abs(planar.Area(b)), math.Abs(planar.Area(a)))
+	})
+
+	var out []orb.Polygon
+	for _, ring := range rings {
+		// the smallest polygon containing the ring, rings in holes are islands and become polygons of their own
+		parent := -1
+		for i := len(out) - 1; i >= 0; i-- {
+			if planar.RingContains(out[i][0], ring[0]) {
+				parent = i
+				break
+			}
+		}
+
+		if parent >= 0 && !slices.ContainsFunc(out[parent][1:], func(hole orb.Ring) bool { return planar.RingContains(hole, ring[0]) }) {
+			out[parent] = append(out[parent], ring)
+			continue
+		}
+
+		out = append(out, orb.Polygon{ring})
+	}
+
+	return out
+}
+
+// selfIntersection returns where the closed ring crosses or touches itself
+func selfIntersection(ring orb.Ring) (orb.Point, bool) {
+	proj := geoutil.NewProjection(ring.Bound().Center())
+	xy := proj.GeometryToXY(ring).(orb.Ring)
+	segments := len(xy) - 1
+
+	for i := 0; i < segments; i++ {
+		for j := i + 1; j < segments; j++ {
+			adjacent := j == i+1 || (i == 0 && j == segments-1)
+			if adjacent {
+				continue
+			}
+
+			if point, ok := geoutil.Crossing(xy[i], xy[i+1], xy[j], xy[j+1], tolerance); ok {
+				return proj.FromXY(point), true
+			}
+
+			// a vertex on a non-adjacent segment touches it
+			for _, vertex := range []orb.Point{xy[j], xy[j+1]} {
+				if geoutil.SegmentDistance(vertex, xy[i], xy[i+1]) < tolerance {
+					return proj.FromXY(vertex), true
+				}
+			}
+		}
+	}
+
+	return orb.Point{}, false
+}
+
+// overlap returns a point where the interiors of the polygons overlap. Polygons sharing a boundary do not overlap.
+func overlap(a, b orb.Polygon) (orb.Point, bool) {
+	proj := geoutil.NewProjection(a.Bound().Center())
+	ax, bx := proj.GeometryToXY(a).(orb.Polygon), proj.GeometryToXY(b).(orb.Polygon)
+
+	for _, ringA := range ax {
+		for _, ringB := range bx {
+			for i := 0; i+1 < len(ringA); i++ {
+				for j := 0; j+1 < len(ringB); j++ {
+					if point, ok := geoutil.Crossing(ringA[i], ringA[i+1], ringB[j], ringB[j+1], tolerance); ok {
+						return proj.FromXY(point), true
+					}
+				}
+			}
+		}
+	}
+
+	for _, pair := range [][2]orb.Polygon{{ax, bx}, {bx, ax}} {
+		inner, outer := pair[0], pair[1]
+
+		for _, point := range inner[0] {
+			if strictlyInside(outer, point) {
+				return proj.FromXY(point), true
+			}
+		}
+
+		if center, _ := planar.CentroidArea(inner); strictlyInside(outer, center) {
+			return proj.FromXY(center), true
+		}
+	}
+
+	return orb.Point{}, false
+}
+
+func strictlyInside(polygon orb.Polygon, point orb.Point) bool {
+	if !planar.PolygonContains(polygon, point) {
+		return false
+	}
+
+	for _, ring := range polygon {
+		for i := 0; i+1 < len(ring); i++ {
+			if geoutil.SegmentDistance(point, ring[i], ring[i+1]) < tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,373 @@
+package indoorvalidation
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/osm"
+	"slices"
+)
+
+type IssueType string
+
+const (
+	// IssueUnclosedRoom is a room, area, corridor or level way which is neither closed nor part of a multipolygon
+	IssueUnclosedRoom IssueType = "unclosed_room"
+	// IssueBrokenMultipolygon is a multipolygon whose member ways do not form closed rings
+	IssueBrokenMultipolygon IssueType = "broken_multipolygon"
+	// IssueSelfIntersection is a polygon whose ring crosses or touches itself
+	IssueSelfIntersection IssueType = "self_intersection"
+	// IssueOverlappingRooms are two rooms on the same level whose interiors overlap
+	IssueOverlappingRooms IssueType = "overlapping_rooms"
+	// IssueOutsideBuilding is an indoor feature whose center is not within any building
+	IssueOutsideBuilding IssueType = "outside_building"
+	// IssueDoorNotOnBoundary is a door which is not a vertex of a room, area, corridor or building outline
+	IssueDoorNotOnBoundary IssueType = "door_not_on_boundary"
+	// IssueMissingLevel is an indoor feature or door without level or repeat_on tag
+	IssueMissingLevel IssueType = "missing_level"
+	// IssueMissingNode is a way referencing a node which is missing in the data
+	IssueMissingNode IssueType = "missing_node"
+)
+
+// Node is an osm node with its tags.
+type Node struct {
+	Point orb.Point
+	Tags  map[string]string
+}
+
+// Way is an osm way with the ids of its nodes, which may be missing in Data.Nodes.
+type Way struct {
+	Nodes []int64
+	Tags  map[string]string
+}
+
+type Member struct {
+	Type osm.Type
+	Ref  int64
+	Role string
+}
+
+// Relation is an osm relation with its members, which may be missing in Data.Ways.
+type Relation struct {
+	Members []Member
+	Tags    map[string]string
+}
+
+// Data contains the indoor features and buildings to validate, together with the nodes and member ways they use.
+type Data struct {
+	Nodes     map[int64]Node
+	Ways      map[int64]Way
+	Relations map[int64]Relation
+}
+
+// Issue is a problem of a feature, located at the problematic part of it.
+type Issue struct {
+	Type    IssueType
+	Feature osm.FeatureID
+	// Level is the level tag of the feature
+	Level    string
+	Message  string
+	Location orb.Geometry
+}
+
+// polygonFeature is a feature with a valid polygon, which is checked against other features
+type polygonFeature struct {
+	id       osm.FeatureID
+	tags     map[string]string
+	polygon  orb.Polygon
+	building bool
+}
+
+type validator struct {
+	data   Data
+	issues []Issue
+	// members are the ways used by multipolygons
+	members map[int64]bool
+	// vertices are the nodes used by indoor areas and buildings
+	vertices map[int64]bool
+	polygons []polygonFeature
+}
+
+// Validate checks the data for common mistakes of indoor mapping, the issues are sorted by type and feature.
+func Validate(data Data) []Issue {
+	v := &validator{
+		data:     data,
+		members:  make(map[int64]bool),
+		vertices: make(map[int64]bool),
+	}
+
+	for _, relation := range sortedKeys(data.Relations) {
+		v.checkRelation(relation)
+	}
+
+	for _, way := range sortedKeys(data.Ways) {
+		v.checkWay(way)
+	}
+
+	for _, node := range sortedKeys(data.Nodes) {
+		v.checkNode(node)
+	}
+
+	v.checkPolygons()
+
+	slices.SortStableFunc(v.issues, func(a, b Issue) int {
+		if c := cmp.Compare(a.Type, b.Type); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Feature, b.Feature)
+	})
+
+	return v.issues
+}
+
+func (v *validator) addIssue(typ IssueType, id osm.FeatureID, tags map[string]string, location orb.Geometry, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		Type:     typ,
+		Feature:  id,
+		Level:    tags["level"],
+		Message:  fmt.Sprintf(format, args...),
+		Location: location,
+	})
+}
+
+func (v *validator) checkRelation(relationID int64) {
+	relation := v.data.Relations[relationID]
+	indoor, building := isIndoorArea(relation.Tags), isBuilding(relation.Tags)
+	if relation.Tags["type"] != "multipolygon" || (!indoor && !building) {
+		return
+	}
+
+	id := osm.RelationID(relationID).FeatureID()
+	if indoor {
+		v.checkLevel(id, relation.Tags)
+	}
+
+	var lines []orb.LineString
+	for _, member := range relation.Members {
+		if member.Type != osm.TypeWay {
+			continue
+		}
+		v.members[member.Ref] = true
+
+		way, ok := v.data.Ways[member.Ref]
+		if !ok {
+			v.addIssue(IssueBrokenMultipolygon, id, relation.Tags, nil, "member way %d is missing", member.Ref)
+			return
+		}
+
+		v.addVertices(way)
+		line, ok := v.wayLine(way)
+		if !ok {
+			v.addIssue(IssueBrokenMultipolygon, id, relation.Tags, nil, "member way %d has missing nodes", member.Ref)
+			return
+		}
+		lines = append(lines, line)
+	}
+
+	rings, open := geoutil.AssembleRings(lines)
+	if len(open) > 0 {
+		location := make(orb.MultiLineString, 0, len(open))
+		for _, line := range open {
+			location = append(location, line)
+		}
+		v.addIssue(IssueBrokenMultipolygon, id, relation.Tags, location, "%d member ways do not form closed rings", len(open))
+		return
+	}
+
+	if len(rings) == 0 {
+		v.addIssue(IssueBrokenMultipolygon, id, relation.Tags, nil, "multipolygon has no member ways")
+		return
+	}
+
+	for _, polygon := range buildPolygons(rings) {
+		v.addPolygon(id, relation.Tags, polygon, building)
+	}
+}
+
+func (v *validator) checkWay(wayID int64) {
+	way := v.data.Ways[wayID]
+	indoor, building := isIndoorArea(way.Tags), isBuilding(way.Tags)
+	if !indoor && !building {
+		return
+	}
+
+	id := osm.WayID(wayID).FeatureID()
+	v.addVertices(way)
+
+	if indoor {
+		v.checkLevel(id, way.Tags)
+	}
+
+	line, ok := v.wayLine(way)
+	if !ok {
+		var location orb.Geometry
+		if len(line) > 0 {
+			location = line
+		}
+		for _, nodeID := range way.Nodes {
+			if _, ok := v.data.Nodes[nodeID]; !ok {
+				v.addIssue(IssueMissingNode, id, way.Tags, location, "node %d is missing", nodeID)
+			}
+		}
+		return
+	}
+
+	if len(way.Nodes) < 4 || way.Nodes[0] != way.Nodes[len(way.Nodes)-1] {
+		if indoor && !v.members[wayID] {
+			v.addIssue(IssueUnclosedRoom, id, way.Tags, line, "way is not closed")
+		}
+		return
+	}
+
+	v.addPolygon(id, way.Tags, orb.Polygon{orb.Ring(line)}, building)
+}
+
+func (v *validator) checkNode(nodeID int64) {
+	node := v.data.Nodes[nodeID]
+	if _, ok := node.Tags["door"]; !ok {
+		return
+	}
+
+	id := osm.NodeID(nodeID).FeatureID()
+	if _, ok := node.Tags["entrance"]; !ok {
+		// entrances are on the building outline and are often mapped without level
+		v.checkLevel(id, node.Tags)
+	}
+
+	if !v.vertices[nodeID] {
+		v.addIssue(IssueDoorNotOnBoundary, id, node.Tags, node.Point,
+			"door is not a vertex of a room, area, corridor or building outline")
+	}
+}
+
+func (v *validator) checkLevel(id osm.FeatureID, tags map[string]string) {
+	if tags["level"] != "" || tags["repeat_on"] != "" {
+		return
+	}
+
+	var location orb.Geometry
+	if id.Type() == osm.TypeNode {
+		location = v.data.Nodes[id.Ref()].Point
+	}
+	v.addIssue(IssueMissingLevel, id, tags, location, "feature has no level or repeat_on tag")
+}
+
+// addPolygon checks the polygon for self intersections and keeps it for the checks between features if it is valid
+func (v *validator) addPolygon(id osm.FeatureID, tags map[string]string, polygon orb.Polygon, building bool) {
+	for _, ring := range polygon {
+		if point, ok := selfIntersection(ring); ok {
+			v.addIssue(IssueSelfIntersection, id, tags, point, "ring crosses or touches itself")
+			return
+		}
+	}
+
+	v.polygons = append(v.polygons, polygonFeature{id: id, tags: tags, polygon: polygon, building: building})
+}
+
+func (v *validator) addVertices(way Way) {
+	for _, nodeID := range way.Nodes {
+		v.vertices[nodeID] = true
+	}
+}
+
+// wayLine returns the line of the way, false is returned if nodes are missing
+func (v *validator) wayLine(way Way) (orb.LineString, bool) {
+	out := make(orb.LineString, 0, len(way.Nodes))
+	complete := true
+	for _, nodeID := range way.Nodes {
+		node, ok := v.data.Nodes[nodeID]
+		if !ok {
+			complete = false
+			continue
+		}
+		out = append(out, node.Point)
+	}
+	return out, complete
+}
+
+// checkPolygons checks the rooms for overlaps on the same level and all indoor areas for lying within a building
+func (v *validator) checkPolygons() {
+	var buildings []polygonFeature
+	rooms := make(map[float64][]int)
+	for i, feature := range v.polygons {
+		if feature.building {
+			buildings = append(buildings, feature)
+		}
+
+		if feature.tags["indoor"] != "room" {
+			continue
+		}
+
+		levels, err := osmlevel.Parse(feature.tags["level"])
+		if err != nil {
+			continue
+		}
+		for _, level := range levels {
+			rooms[level] = append(rooms[level], i)
+		}
+	}
+
+	reported := make(map[[2]int]bool)
+	for _, level := range sortedKeys(rooms) {
+		indexes := rooms[level]
+		for i, a := range indexes {
+			for _, b := range indexes[i+1:] {
+				if reported[[2]int{a, b}] {
+					continue
+				}
+
+				first, second := v.polygons[a], v.polygons[b]
+				if !first.polygon.Bound().Intersects(second.polygon.Bound()) {
+					continue
+				}
+
+				if point, ok := overlap(first.polygon, second.polygon); ok {
+					reported[[2]int{a, b}] = true
+					v.addIssue(IssueOverlappingRooms, first.id, first.tags, point,
+						"room overlaps with %s on level %s", osmid.Format(second.id), osmlevel.Format(level))
+				}
+			}
+		}
+	}
+
+	for _, feature := range v.polygons {
+		if feature.building || !isIndoorArea(feature.tags) {
+			continue
+		}
+
+		center, _ := planar.CentroidArea(feature.polygon)
+		inside := slices.ContainsFunc(buildings, func(building polygonFeature) bool {
+			return building.polygon.Bound().Contains(center) && planar.PolygonContains(building.polygon, center)
+		})
+		if !inside {
+			v.addIssue(IssueOutsideBuilding, feature.id, feature.tags, center, "feature is not within a building")
+		}
+	}
+}
+
+// isIndoorArea reports whether the tags describe an areal indoor feature
+func isIndoorArea(tags map[string]string) bool {
+	switch tags["indoor"] {
+	case "room", "area", "corridor", "level":
+		return true
+	}
+	return false
+}
+
+func isBuilding(tags map[string]string) bool {
+	building, ok := tags["building"]
+	return ok && building != "no"
+}
+
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	out := make([]K, 0, len(m))
+	for key := range m {
+		out = append(out, key)
+	}
+	slices.Sort(out)
+	return out
+}
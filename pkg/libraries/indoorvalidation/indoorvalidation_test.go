@@ -0,0 +1,106 @@
+package indoorvalidation_test
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/indoorvalidation"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/osm"
+	"reflect"
+	"testing"
+)
+
+// scale turns the test coordinates into degrees, a unit is about 1.1 m at the equator
+const scale = 1e-5
+
+// testData returns a building with rooms and doors, the node ids encode their coordinates as x*100+y
+func testData() indoorvalidation.Data {
+	data := indoorvalidation.Data{
+		Nodes:     make(map[int64]indoorvalidation.Node),
+		Ways:      make(map[int64]indoorvalidation.Way),
+		Relations: make(map[int64]indoorvalidation.Relation),
+	}
+
+	way := func(id int64, tags map[string]string, coords ...[2]int64) {
+		var nodes []int64
+		for _, c := range coords {
+			nodeID := c[0]*100 + c[1]
+			if _, ok := data.Nodes[nodeID]; !ok {
+				data.Nodes[nodeID] = indoorvalidation.Node{Point: orb.Point{float64(c[0]) * scale, float64(c[1]) * scale}}
+			}
+			nodes = append(nodes, nodeID)
+		}
+		data.Ways[id] = indoorvalidation.Way{Nodes: nodes, Tags: tags}
+	}
+
+	way(1, map[string]string{"building": "yes"}, [2]int64{0, 0}, [2]int64{50, 0}, [2]int64{50, 50}, [2]int64{0, 50}, [2]int64{0, 0})
+	// two rooms sharing a wall with a door in it
+	way(2, map[string]string{"indoor": "room", "level": "0"}, [2]int64{0, 0}, [2]int64{10, 0}, [2]int64{10, 5}, [2]int64{10, 10}, [2]int64{0, 10}, [2]int64{0, 0})
+	way(3, map[string]string{"indoor": "room", "level": "0"}, [2]int64{10, 0}, [2]int64{20, 0}, [2]int64{20, 10}, [2]int64{10, 10}, [2]int64{10, 5}, [2]int64{10, 0})
+	data.Nodes[1005] = indoorvalidation.Node{Point: data.Nodes[1005].Point, Tags: map[string]string{"door": "hinged", "level": "0"}}
+	// overlaps room 3
+	way(4, map[string]string{"indoor": "room", "level": "0;1"}, [2]int64{15, 5}, [2]int64{25, 5}, [2]int64{25, 15}, [2]int64{15, 15}, [2]int64{15, 5})
+	// open and without level
+	way(5, map[string]string{"indoor": "corridor"}, [2]int64{60, 0}, [2]int64{70, 0}, [2]int64{70, 10})
+	// outside of the building
+	way(8, map[string]string{"indoor": "area", "level": "0"}, [2]int64{60, 20}, [2]int64{70, 20}, [2]int64{70, 30}, [2]int64{60, 20})
+	// bow tie
+	way(6, map[string]string{"indoor": "area", "level": "0"}, [2]int64{30, 30}, [2]int64{40, 40}, [2]int64{40, 30}, [2]int64{30, 40}, [2]int64{30, 30})
+	// references a missing node
+	data.Ways[7] = indoorvalidation.Way{Nodes: []int64{0, 1000, 999999, 0}, Tags: map[string]string{"indoor": "room", "level": "1"}}
+	// door in the middle of a room, without level
+	data.Nodes[99] = indoorvalidation.Node{Point: orb.Point{5 * scale, 5 * scale}, Tags: map[string]string{"door": "yes"}}
+
+	// multipolygon from two halves of a ring, and a broken one with a gap
+	way(10, nil, [2]int64{30, 0}, [2]int64{40, 0}, [2]int64{40, 10})
+	way(11, nil, [2]int64{40, 10}, [2]int64{30, 10}, [2]int64{30, 0})
+	way(12, nil, [2]int64{30, 15}, [2]int64{40, 15}, [2]int64{40, 20})
+	data.Relations[20] = indoorvalidation.Relation{
+		Tags:    map[string]string{"type": "multipolygon", "indoor": "room", "level": "0"},
+		Members: []indoorvalidation.Member{{Type: osm.TypeWay, Ref: 10, Role: "outer"}, {Type: osm.TypeWay, Ref: 11, Role: "outer"}},
+	}
+	data.Relations[21] = indoorvalidation.Relation{
+		Tags:    map[string]string{"type": "multipolygon", "indoor": "room", "level": "0"},
+		Members: []indoorvalidation.Member{{Type: osm.TypeWay, Ref: 12, Role: "outer"}},
+	}
+
+	return data
+}
+
+func TestValidate(t *testing.T) {
+	issues := indoorvalidation.Validate(testData())
+
+	type key struct {
+		typ     indoorvalidation.IssueType
+		feature string
+	}
+
+	var got []key
+	for _, issue := range issues {
+		got = append(got, key{issue.Type, issue.Feature.String()})
+	}
+
+	expected := []key{
+		{indoorvalidation.IssueBrokenMultipolygon, "relation/21"},
+		{indoorvalidation.IssueDoorNotOnBoundary, "node/99"},
+		{indoorvalidation.IssueMissingLevel, "node/99"},
+		{indoorvalidation.IssueMissingLevel, "way/5"},
+		{indoorvalidation.IssueMissingNode, "way/7"},
+		{indoorvalidation.IssueOutsideBuilding, "way/8"},
+		{indoorvalidation.IssueOverlappingRooms, "way/3"},
+		{indoorvalidation.IssueSelfIntersection, "way/6"},
+		{indoorvalidation.IssueUnclosedRoom, "way/5"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected issues\n%v\ngot\n%v", expected, got)
+	}
+
+	for _, issue := range issues {
+		if issue.Type == indoorvalidation.IssueSelfIntersection {
+			point, ok := issue.Location.(orb.Point)
+			if !ok || planar.Distance(point, orb.Point{35 * scale, 35 * scale}) > 1e-9 {
+				t.Errorf("expected the bow tie to cross at 35,35, got %v", issue.Location)
+			}
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package navgraph
+
+import (
+	"cmp"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/planar"
+	"math"
+	"slices"
+	"strconv"
+)
+
+const (
+	// snapDistance is the distance in meters up to which nodes next to an area are attached to it
+	snapDistance = 0.5
+	// cornerInset is the distance in meters reflex corners are moved into their area
+	cornerInset = 0.3
+	// stairsLengthPerLevel is the horizontal length in meters assumed for stairs rooms
+	stairsLengthPerLevel = 5.0
+	// areaCellSize is the size in degrees of the grid cells the areas are looked up in
+	areaCellSize = 0.0005
+)
+
+// keptTags are the tags copied onto nodes and areas
+var keptTags = []string{
+	"name", "ref", "indoor", "room", "door", "entrance", "exit", "highway", "wheelchair",
+	"stairs", "elevator", "conveying", "incline", "level:ref",
+}
+
+// WayNode is a vertex of a way.
+type WayNode struct {
+	ID    int64
+	Point orb.Point
+}
+
+type builderArea struct {
+	Area
+	projection geoutil.Projection
+	xy         orb.Geometry
+	bound      orb.Bound
+}
+
+// areaCell is a cell of the grid the areas are looked up in
+type areaCell struct {
+	level float64
+	x, y  int64
+}
+
+func newAreaCell(level float64, point orb.Point) areaCell {
+	return areaCell{
+		level: level,
+		x:     int64(math.Floor(point.Lon() / areaCellSize)),
+		y:     int64(math.Floor(point.Lat() / areaCellSize)),
+	}
+}
+
+type builderPoint struct {
+	id    string
+	point orb.Point
+	tags  map[string]string
+}
+
+type builderWay struct {
+	id    string
+	nodes []WayNode
+	tags  map[string]string
+}
+
+// Builder derives the navigation graph from walkable areas, doors, elevators and footways.
+// Nodes attached to the same area are connected if they can see each other, which creates a visibility graph
+// through every area. Reflex corners of the areas are added as nodes, so paths can go around them.
+type Builder struct {
+	graph  Graph
+	areas  []*builderArea
+	cells  map[areaCell][]*builderArea
+	points []builderPoint
+	ways   []builderWay
+	keys   map[string]int64
+	walks  map[[2]int64]bool
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		keys:  make(map[string]int64),
+		walks: make(map[[2]int64]bool),
+	}
+}
+
+// AddArea adds a walkable area, e.g. a room or corridor. Geometries other than polygons are ignored.
+func (b *Builder) AddArea(id string, tags map[string]string, geom orb.Geometry) {
+	switch geom.(type) {
+	case orb.Polygon, orb.MultiPolygon:
+	default:
+		return
+	}
+
+	proj := geoutil.NewProjection(geom.Bound().Center())
+	for _, level := range levels(tags) {
+		b.areas = append(b.areas, &builderArea{
+			Area: Area{
+				ID:       id,
+				Level:    level,
+				Tags:     filterTags(tags),
+				Geometry: geom,
+			},
+			projection: proj,
+			xy:         proj.GeometryToXY(geom),
+			bound:      geom.Bound(),
+		})
+	}
+}
+
+// AddPoint adds a door, entrance or elevator node.
+func (b *Builder) AddPoint(id string, point orb.Point, tags map[string]string) {
+	b.points = append(b.points, builderPoint{id: id, point: point, tags: tags})
+}
+
+// AddWay adds a footway, corridor or steps way. Steps spanning multiple levels connect their first and last node.
+func (b *Builder) AddWay(id string, nodes []WayNode, tags map[string]string) {
+	if len(nodes) < 2 {
+		return
+	}
+	b.ways = append(b.ways, builderWay{id: id, nodes: nodes, tags: tags})
+}
+
+// Build creates the graph, the builder must not be used afterwards.
+func (b *Builder) Build() *Graph {
+	b.indexAreas()
+
+	for _, area := range b.areas {
+		b.addAreaNodes(area)
+	}
+
+	for _, point := range b.points {
+		b.addPoint(point)
+	}
+
+	for _, way := range b.ways {
+		b.addWay(way)
+	}
+
+	for _, area := range b.areas {
+		b.meshArea(area)
+	}
+
+	b.connectVerticalAreas()
+
+	for _, area := range b.areas {
+		b.graph.Areas = append(b.graph.Areas, area.Area)
+	}
+
+	for i := range b.graph.Edges {
+		b.graph.Edges[i].Cost, _ = Walking.Cost(b.graph.Edges[i])
+	}
+
+	return &b.graph
+}
+
+// addAreaNodes adds the anchor and the reflex corners of the area
+func (b *Builder) addAreaNodes(area *builderArea) {
+	if anchor, ok := interiorPoint(area.xy); ok {
+		id := b.addNode(area.projection.FromXY(anchor), area.Level, NodeKindAnchor, area.ID, area.Tags)
+		area.Nodes = append(area.Nodes, id)
+	}
+
+	for _, corner := range reflexCorners(area.xy) {
+		id := b.addNode(area.projection.FromXY(corner), area.Level, NodeKindCorner, area.ID, nil)
+		area.Nodes = append(area.Nodes, id)
+	}
+}
+
+func (b *Builder) addPoint(point builderPoint) {
+	kind := NodeKindDoor
+	if point.tags["highway"] == "elevator" {
+		kind = NodeKindElevator
+	}
+
+	var ids []int64
+	for _, level := range levels(point.tags) {
+		id := b.keyedNode(point.id, point.point, level, kind, point.tags)
+		ids = append(ids, id)
+	}
+
+	// every pair of levels is connected, so riding an elevator waits only once
+	if kind == NodeKindElevator {
+		for i, from := range ids {
+			for _, to := range ids[i+1:] {
+				b.addEdge(from, to, EdgeKindElevator, 0, false, true, point.id)
+			}
+		}
+	}
+}
+
+func (b *Builder) addWay(way builderWay) {
+	kind := EdgeKindFootway
+	if way.tags["highway"] == "steps" {
+		kind = EdgeKindStairs
+		if conveying, ok := way.tags["conveying"]; ok && conveying != "no" {
+			kind = EdgeKindEscalator
+		}
+	}
+
+	wheelchair := kind == EdgeKindFootway && way.tags["wheelchair"] != "no"
+	nodes := way.nodes
+	switch way.tags["conveying"] {
+	case "backward":
+		nodes = slices.Clone(nodes)
+		slices.Reverse(nodes)
+	}
+	oneway := kind == EdgeKindEscalator && way.tags["conveying"] != "reversible"
+
+	wayLevels := levels(way.tags)
+	if kind != EdgeKindFootway && len(wayLevels) > 1 {
+		// steps spanning levels are climbed from their first to their last node, unless they lead down
+		bottom, top := wayLevels[0], wayLevels[len(wayLevels)-1]
+		if way.tags["incline"] == "down" || way.tags["incline"] == "-" {
+			bottom, top = top, bottom
+		}
+
+		first, last := nodes[0], nodes[len(nodes)-1]
+		from := b.keyedNode("n"+strconv.FormatInt(first.ID, 10), first.Point, bottom, NodeKindWay, nil)
+		to := b.keyedNode("n"+strconv.FormatInt(last.ID, 10), last.Point, top, NodeKindWay, nil)
+		b.addEdge(from, to, kind, geo.Length(wayLineString(nodes)), oneway, wheelchair, way.id)
+		return
+	}
+
+	for _, level := range wayLevels {
+		prev := int64(-1)
+		for _, node := range nodes {
+			id := b.keyedNode("n"+strconv.FormatInt(node.ID, 10), node.Point, level, NodeKindWay, nil)
+			if prev >= 0 && prev != id {
+				b.addEdge(prev, id, kind, geo.Distance(b.graph.Nodes[prev].Point, node.Point), oneway, wheelchair, way.id)
+			}
+			prev = id
+		}
+	}
+}
+
+// meshArea connects all nodes of the area which can see each other
+func (b *Builder) meshArea(area *builderArea) {
+	accessible := area.Tags["wheelchair"] != "no"
+	for i, from := range area.Nodes {
+		for _, to := range area.Nodes[i+1:] {
+			key := [2]int64{min(from, to), max(from, to)}
+			if b.walks[key] {
+				continue
+			}
+
+			p, q := b.graph.Nodes[from].Point, b.graph.Nodes[to].Point
+			if !visible(area.xy, area.projection.ToXY(p), area.projection.ToXY(q)) {
+				continue
+			}
+
+			b.walks[key] = true
+			b.addEdge(from, to, EdgeKindWalk, geo.Distance(p, q), false, accessible, area.ID)
+		}
+	}
+}
+
+// connectVerticalAreas connects the anchors of stairs and elevator rooms on adjacent levels
+func (b *Builder) connectVerticalAreas() {
+	anchors := make(map[string][]int64)
+	var ids []string
+	for _, node := range b.graph.Nodes {
+		if node.Kind != NodeKindAnchor || verticalKind(node.Tags) == "" {
+			continue
+		}
+		if _, ok := anchors[node.Feature]; !ok {
+			ids = append(ids, node.Feature)
+		}
+		anchors[node.Feature] = append(anchors[node.Feature], node.ID)
+	}
+
+	for _, id := range ids {
+		nodes := anchors[id]
+		slices.SortFunc(nodes, func(a, c int64) int {
+			return cmp.Compare(b.graph.Nodes[a].Level, b.graph.Nodes[c].Level)
+		})
+
+		kind := verticalKind(b.graph.Nodes[nodes[0]].Tags)
+		for i := 0; i+1 < len(nodes); i++ {
+			if kind == EdgeKindElevator {
+				for _, to := range nodes[i+1:] {
+					b.addEdge(nodes[i], to, kind, 0, false, true, id)
+				}
+				continue
+			}
+
+			levels := b.graph.Nodes[nodes[i+1]].Level - b.graph.Nodes[nodes[i]].Level
+			b.addEdge(nodes[i], nodes[i+1], kind, stairsLengthPerLevel*levels, false, false, id)
+		}
+	}
+}
+
+// indexAreas adds the areas to every grid cell their bound padded by the snapDistance overlaps, so keyedNode only
+// has to check the areas of a single cell
+func (b *Builder) indexAreas() {
+	b.cells = make(map[areaCell][]*builderArea)
+	for _, area := range b.areas {
+		bound := area.bound.Pad(area.projection.LonDegrees(snapDistance))
+		minCell := newAreaCell(area.Level, bound.Min)
+		maxCell := newAreaCell(area.Level, bound.Max)
+		for x := minCell.x; x <= maxCell.x; x++ {
+			for y := minCell.y; y <= maxCell.y; y++ {
+				cell := areaCell{level: area.Level, x: x, y: y}
+				b.cells[cell] = append(b.cells[cell], area)
+			}
+		}
+	}
+}
+
+// keyedNode returns the node of the feature on the level, the node is created and attached to the areas around it
+// if it does not exist yet
+func (b *Builder) keyedNode(feature string, point orb.Point, level float64, kind NodeKind, tags map[string]string) int64 {
+	key := feature + "@" + osmlevel.Format(level)
+	if id, ok := b.keys[key]; ok {
+		return id
+	}
+
+	id := b.addNode(point, level, kind, feature, filterTags(tags))
+	b.keys[key] = id
+
+	for _, area := range b.cells[newAreaCell(level, point)] {
+		if !area.bound.Pad(area.projection.LonDegrees(snapDistance)).Contains(point) {
+			continue
+		}
+
+		xy := area.projection.ToXY(point)
+		if geoutil.Contains(area.xy, xy) || boundaryDistance(area.xy, xy) < snapDistance {
+			area.Nodes = append(area.Nodes, id)
+		}
+	}
+
+	return id
+}
+
+func (b *Builder) addNode(point orb.Point, level float64, kind NodeKind, feature string, tags map[string]string) int64 {
+	id := int64(len(b.graph.Nodes))
+	b.graph.Nodes = append(b.graph.Nodes, Node{
+		ID:      id,
+		Point:   point,
+		Level:   level,
+		Kind:    kind,
+		Feature: feature,
+		Tags:    tags,
+	})
+	return id
+}
+
+func (b *Builder) addEdge(from, to int64, kind EdgeKind, length float64, oneway bool, wheelchair bool, feature string) {
+	fromNode, toNode := b.graph.Nodes[from], b.graph.Nodes[to]
+	b.graph.Edges = append(b.graph.Edges, Edge{
+		From:       from,
+		To:         to,
+		Kind:       kind,
+		Length:     length,
+		Levels:     toNode.Level - fromNode.Level,
+		Oneway:     oneway,
+		Wheelchair: wheelchair && fromNode.Tags["wheelchair"] != "no" && toNode.Tags["wheelchair"] != "no",
+		Feature:    feature,
+	})
+}
+
+// visible reports whether the straight line between the projected points stays within the projected area.
+// Crossings of the boundary next to the points are ignored, as doors may lie slightly outside.
+func visible(xy orb.Geometry, p, q orb.Point) bool {
+	l := length(sub(q, p))
+	for _, ring := range rings(xy) {
+		for i := 0; i+1 < len(ring); i++ {
+			t, ok := intersection(p, q, ring[i], ring[i+1])
+			if ok && t*l > snapDistance && (1-t)*l > snapDistance {
+				return false
+			}
+		}
+	}
+
+	mid := orb.Point{(p.X() + q.X()) / 2, (p.Y() + q.Y()) / 2}
+	return geoutil.Contains(xy, mid) || boundaryDistance(xy, mid) < snapDistance
+}
+
+// interiorPoint returns a point inside of the projected geometry, preferably its centroid
+func interiorPoint(xy orb.Geometry) (orb.Point, bool) {
+	centroid, _ := planar.CentroidArea(xy)
+	if geoutil.Contains(xy, centroid) {
+		return centroid, true
+	}
+
+	for _, ring := range rings(xy) {
+		for i := 0; i+2 < len(ring); i++ {
+			candidate := orb.Point{(ring[i].X() + ring[i+2].X()) / 2, (ring[i].Y() + ring[i+2].Y()) / 2}
+			if geoutil.Contains(xy, candidate) {
+				return candidate, true
+			}
+		}
+	}
+
+	return orb.Point{}, false
+}
+
+// reflexCorners returns the corners of the projected geometry pointing into it, moved into the geometry by cornerInset
+func reflexCorners(xy orb.Geometry) []orb.Point {
+	var out []orb.Point
+	for _, ring := range rings(xy) {
+		n := len(ring) - 1
+		if n < 3 {
+			continue
+		}
+
+		for i := 0; i < n; i++ {
+			prev, corner, next := ring[(i+n-1)%n], ring[i], ring[(i+1)%n]
+			toPrev, toNext := normalize(sub(prev, corner)), normalize(sub(next, corner))
+
+			// the corner is convex if the point between its edges is inside the area
+			bisector := normalize(orb.Point{toPrev.X() + toNext.X(), toPrev.Y() + toNext.Y()})
+			if bisector == (orb.Point{}) {
+				continue
+			}
+			probe := orb.Point{corner.X() + bisector.X()*0.01, corner.Y() + bisector.Y()*0.01}
+			if geoutil.Contains(xy, probe) {
+				continue
+			}
+
+			inset := orb.Point{corner.X() - bisector.X()*cornerInset, corner.Y() - bisector.Y()*cornerInset}
+			if geoutil.Contains(xy, inset) {
+				out = append(out, inset)
+			}
+		}
+	}
+	return out
+}
+
+// levels returns the levels of the tags, features without a level are on level 0
+func levels(tags map[string]string) []float64 {
+	for _, key := range []string{"level", "repeat_on"} {
+		if levels, err := osmlevel.Parse(tags[key]); err == nil {
+			return levels
+		}
+	}
+	return []float64{0}
+}
+
+// verticalKind returns the kind of edges connecting the levels of a stairs or elevator room
+func verticalKind(tags map[string]string) EdgeKind {
+	switch {
+	case tags["highway"] == "elevator" || tags["room"] == "elevator" || tags["elevator"] == "yes":
+		return EdgeKindElevator
+	case tags["room"] == "stairs" || tags["stairs"] == "yes":
+		return EdgeKindStairs
+	}
+	return ""
+}
+
+func filterTags(tags map[string]string) map[string]string {
+	out := make(map[string]string)
+	for _, key := range keptTags {
+		if value, ok := tags[key]; ok {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+func wayLineString(nodes []WayNode) orb.LineString {
+	out := make(orb.LineString, 0, len(nodes))
+	for _, node := range nodes {
+		out = append(out, node.Point)
+	}
+	return out
+}
@@ -0,0 +1,159 @@
+package navgraph_test
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulmach/orb"
+	"testing"
+)
+
+// scale turns the test coordinates into degrees, a unit is about 1.1 m at the equator
+const scale = 1e-5
+
+func polygon(points ...[2]float64) orb.Polygon {
+	ring := make(orb.Ring, 0, len(points)+1)
+	for _, point := range points {
+		ring = append(ring, pt(point[0], point[1]))
+	}
+	return orb.Polygon{append(ring, ring[0])}
+}
+
+func pt(x, y float64) orb.Point {
+	return orb.Point{x * scale, y * scale}
+}
+
+// testGraph is an L shaped corridor with a room at its inner corner, the room has a door into the corridor.
+// The corridor has an entrance at its end and an elevator serving levels 0 and 1.
+func testGraph() *navgraph.Graph {
+	builder := navgraph.NewBuilder()
+	builder.AddArea("w1", map[string]string{"indoor": "corridor", "level": "0"},
+		polygon([2]float64{0, 0}, [2]float64{20, 0}, [2]float64{20, 2}, [2]float64{2, 2}, [2]float64{2, 20}, [2]float64{0, 20}))
+	builder.AddArea("w2", map[string]string{"indoor": "room", "level": "0", "name": "Office"},
+		polygon([2]float64{2, 2}, [2]float64{10, 2}, [2]float64{10, 10}, [2]float64{2, 10}))
+	builder.AddPoint("n10", pt(5, 2), map[string]string{"door": "hinged", "level": "0"})
+	builder.AddPoint("n11", pt(1, 20), map[string]string{"entrance": "main"})
+	builder.AddPoint("n12", pt(19, 1), map[string]string{"highway": "elevator", "level": "0;1"})
+	return builder.Build()
+}
+
+func findNode(t *testing.T, graph *navgraph.Graph, kind navgraph.NodeKind, feature string) navgraph.Node {
+	t.Helper()
+
+	for _, node := range graph.Nodes {
+		if node.Kind == kind && node.Feature == feature {
+			return node
+		}
+	}
+
+	t.Fatalf("node %s %s not found", kind, feature)
+	return navgraph.Node{}
+}
+
+func findEdge(graph *navgraph.Graph, from, to int64) *navgraph.Edge {
+	for i, edge := range graph.Edges {
+		if (edge.From == from && edge.To == to) || (edge.From == to && edge.To == from) {
+			return &graph.Edges[i]
+		}
+	}
+	return nil
+}
+
+func TestBuilder_Build(t *testing.T) {
+	graph := testGraph()
+
+	door := findNode(t, graph, navgraph.NodeKindDoor, "n10")
+	entrance := findNode(t, graph, navgraph.NodeKindDoor, "n11")
+	corner := findNode(t, graph, navgraph.NodeKindCorner, "w1")
+	office := findNode(t, graph, navgraph.NodeKindAnchor, "w2")
+
+	if findEdge(graph, door.ID, entrance.ID) != nil {
+		t.Errorf("expected no edge from the door to the entrance, it would cut through the office")
+	}
+
+	for _, pair := range [][2]navgraph.Node{{door, corner}, {corner, entrance}, {door, office}} {
+		edge := findEdge(graph, pair[0].ID, pair[1].ID)
+		if edge == nil {
+			t.Errorf("expected an edge from %s %s to %s %s", pair[0].Kind, pair[0].Feature, pair[1].Kind, pair[1].Feature)
+			continue
+		}
+
+		if edge.Kind != navgraph.EdgeKindWalk || edge.Length <= 0 || edge.Cost <= 0 {
+			t.Errorf("unexpected edge %+v", edge)
+		}
+	}
+
+	if entrance.Level != 0 {
+		t.Errorf("expected the entrance without level to be on level 0, got %v", entrance.Level)
+	}
+
+	var elevators []navgraph.Node
+	for _, node := range graph.Nodes {
+		if node.Kind == navgraph.NodeKindElevator {
+			elevators = append(elevators, node)
+		}
+	}
+
+	if len(elevators) != 2 {
+		t.Fatalf("expected an elevator node per level, got %d", len(elevators))
+	}
+
+	edge := findEdge(graph, elevators[0].ID, elevators[1].ID)
+	if edge == nil || edge.Kind != navgraph.EdgeKindElevator || edge.Levels != 1 || !edge.Wheelchair {
+		t.Errorf("unexpected elevator edge %+v", edge)
+	}
+}
+
+func TestBuilder_BuildSteps(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	builder.AddWay("w1", []navgraph.WayNode{{ID: 1, Point: pt(0, 0)}, {ID: 2, Point: pt(5, 0)}, {ID: 3, Point: pt(10, 0)}},
+		map[string]string{"highway": "steps", "level": "0;1", "incline": "down"})
+	builder.AddWay("w2", []navgraph.WayNode{{ID: 4, Point: pt(0, 5)}, {ID: 5, Point: pt(10, 5)}},
+		map[string]string{"highway": "steps", "level": "1-2", "conveying": "backward"})
+	graph := builder.Build()
+
+	if len(graph.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(graph.Edges))
+	}
+
+	stairs, escalator := graph.Edges[0], graph.Edges[1]
+	if stairs.Kind != navgraph.EdgeKindStairs || stairs.Levels != -1 || stairs.Oneway || stairs.Wheelchair {
+		t.Errorf("unexpected stairs %+v", stairs)
+	}
+
+	if escalator.Kind != navgraph.EdgeKindEscalator || !escalator.Oneway || escalator.Levels != 1 {
+		t.Errorf("unexpected escalator %+v", escalator)
+	}
+
+	if graph.Nodes[escalator.From].Feature != "n5" {
+		t.Errorf("expected the backward escalator to start at n5, got %s", graph.Nodes[escalator.From].Feature)
+	}
+}
+
+func TestBuilder_BuildAttachesNodesAcrossCells(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	builder.AddArea("w1", map[string]string{"indoor": "corridor", "level": "0"},
+		polygon([2]float64{0, 0}, [2]float64{200, 0}, [2]float64{200, 2}, [2]float64{0, 2}))
+	builder.AddArea("w2", map[string]string{"indoor": "corridor", "level": "1"},
+		polygon([2]float64{0, 0}, [2]float64{200, 0}, [2]float64{200, 2}, [2]float64{0, 2}))
+	builder.AddPoint("n10", pt(-0.2, 1), map[string]string{"door": "hinged", "level": "0"})
+	builder.AddPoint("n11", pt(120, 1), map[string]string{"door": "hinged", "level": "0"})
+	builder.AddPoint("n12", pt(199, 2.2), map[string]string{"door": "hinged", "level": "0"})
+	builder.AddPoint("n13", pt(120, 3), map[string]string{"door": "hinged", "level": "0"})
+	graph := builder.Build()
+
+	attached := make(map[string][]string)
+	for _, area := range graph.Areas {
+		for _, id := range area.Nodes {
+			if node := graph.Nodes[id]; node.Kind == navgraph.NodeKindDoor {
+				attached[area.ID] = append(attached[area.ID], node.Feature)
+			}
+		}
+	}
+
+	if got := attached["w1"]; len(got) != 3 || got[0] != "n10" || got[1] != "n11" || got[2] != "n12" {
+		t.Errorf("expected n10, n11 and n12 to be attached to w1, got %v", got)
+	}
+
+	if got := attached["w2"]; len(got) != 0 {
+		t.Errorf("expected no doors attached to the corridor on level 1, got %v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package navgraph
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/graphml"
+	"io"
+	"strconv"
+)
+
+// csvHeader are the columns of the edge list written by WriteCSV
+var csvHeader = []string{
+	"from", "to", "kind", "length", "levels", "cost", "oneway", "wheelchair", "feature",
+	"from_level", "from_lon", "from_lat", "to_level", "to_lon", "to_lat",
+}
+
+// GraphML returns the graph for tools like NetworkX or Gephi. The graph is directed, edges which are not oneway are
+// written in both directions, so the levels and cost of stairs and escalators match the direction.
+func (g *Graph) GraphML() graphml.Graph {
+	out := graphml.Graph{
+		Directed: true,
+		Keys: []graphml.Key{
+			{ID: "kind", For: "node", Type: "string"},
+			{ID: "level", For: "node", Type: "double"},
+			{ID: "lon", For: "node", Type: "double"},
+			{ID: "lat", For: "node", Type: "double"},
+			{ID: "feature", For: "node", Type: "string"},
+			{ID: "name", For: "node", Type: "string"},
+			{ID: "edge_kind", Name: "kind", For: "edge", Type: "string"},
+			{ID: "length", For: "edge", Type: "double"},
+			{ID: "levels", For: "edge", Type: "double"},
+			{ID: "cost", For: "edge", Type: "double"},
+			{ID: "oneway", For: "edge", Type: "boolean"},
+			{ID: "wheelchair", For: "edge", Type: "boolean"},
+			{ID: "edge_feature", Name: "feature", For: "edge", Type: "string"},
+		},
+		Nodes: make([]graphml.Node, 0, len(g.Nodes)),
+		Edges: make([]graphml.Edge, 0, len(g.Edges)),
+	}
+
+	for _, node := range g.Nodes {
+		data := map[string]any{
+			"kind":    string(node.Kind),
+			"level":   node.Level,
+			"lon":     node.Point.Lon(),
+			"lat":     node.Point.Lat(),
+			"feature": node.Feature,
+		}
+		if name, ok := node.Tags["name"]; ok {
+			data["name"] = name
+		}
+		out.Nodes = append(out.Nodes, graphml.Node{ID: strconv.FormatInt(node.ID, 10), Data: data})
+	}
+
+	for _, edge := range g.Edges {
+		out.Edges = append(out.Edges, graphMLEdge(edge))
+		if !edge.Oneway {
+			reverse := edge
+			reverse.From, reverse.To, reverse.Levels = edge.To, edge.From, -edge.Levels
+			reverse.Cost, _ = Walking.Cost(reverse)
+			out.Edges = append(out.Edges, graphMLEdge(reverse))
+		}
+	}
+
+	return out
+}
+
+func graphMLEdge(edge Edge) graphml.Edge {
+	return graphml.Edge{
+		Source: strconv.FormatInt(edge.From, 10),
+		Target: strconv.FormatInt(edge.To, 10),
+		Data: map[string]any{
+			"edge_kind":    string(edge.Kind),
+			"length":       edge.Length,
+			"levels":       edge.Levels,
+			"cost":         edge.Cost,
+			"oneway":       edge.Oneway,
+			"wheelchair":   edge.Wheelchair,
+			"edge_feature": edge.Feature,
+		},
+	}
+}
+
+// WriteCSV writes the edges as CSV edge list with a header, every row contains the levels and coordinates of
+// both nodes of the edge.
+func (g *Graph) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	for _, edge := range g.Edges {
+		from, to := g.Nodes[edge.From], g.Nodes[edge.To]
+		err := writer.Write([]string{
+			strconv.FormatInt(edge.From, 10), strconv.FormatInt(edge.To, 10), string(edge.Kind),
+			format(edge.Length), format(edge.Levels), format(edge.Cost),
+			strconv.FormatBool(edge.Oneway), strconv.FormatBool(edge.Wheelchair), edge.Feature,
+			format(from.Level), format(from.Point.Lon()), format(from.Point.Lat()),
+			format(to.Level), format(to.Point.Lon()), format(to.Point.Lat()),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write edge: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush: %w", err)
+	}
+
+	return nil
+}
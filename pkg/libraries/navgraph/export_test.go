@@ -0,0 +1,75 @@
+package navgraph_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/graphml"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"testing"
+)
+
+func TestGraph_WriteCSV(t *testing.T) {
+	graph := testGraph()
+
+	var buf bytes.Buffer
+	if err := graph.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != len(graph.Edges)+1 {
+		t.Fatalf("expected a header and %d edges, got %d rows", len(graph.Edges), len(records))
+	}
+
+	if records[0][0] != "from" || len(records[1]) != len(records[0]) {
+		t.Errorf("unexpected header %v or row %v", records[0], records[1])
+	}
+}
+
+func TestGraph_GraphML(t *testing.T) {
+	graph := testGraph()
+	doc := graph.GraphML()
+
+	edges := 0
+	for _, edge := range graph.Edges {
+		edges += 2
+		if edge.Oneway {
+			edges--
+		}
+	}
+
+	if !doc.Directed || len(doc.Nodes) != len(graph.Nodes) || len(doc.Edges) != edges {
+		t.Errorf("expected %d nodes and %d directed edges, got %d and %d", len(graph.Nodes), edges, len(doc.Nodes), len(doc.Edges))
+	}
+
+	if err := graphml.Write(&bytes.Buffer{}, doc); err != nil {
+		t.Errorf("failed to write graph: %v", err)
+	}
+}
+
+func TestGraph_GraphMLOneway(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	builder.AddWay("w1", []navgraph.WayNode{{ID: 1, Point: pt(0, 0)}, {ID: 2, Point: pt(10, 0)}},
+		map[string]string{"highway": "steps", "level": "0;1", "conveying": "forward"})
+	builder.AddWay("w2", []navgraph.WayNode{{ID: 3, Point: pt(0, 5)}, {ID: 4, Point: pt(10, 5)}},
+		map[string]string{"highway": "steps", "level": "0;1"})
+	graph := builder.Build()
+	doc := graph.GraphML()
+
+	if len(doc.Edges) != 3 {
+		t.Fatalf("expected the escalator once and the stairs in both directions, got %+v", doc.Edges)
+	}
+
+	escalator, up, down := doc.Edges[0], doc.Edges[1], doc.Edges[2]
+	if escalator.Data["edge_kind"] != string(navgraph.EdgeKindEscalator) || escalator.Data["levels"] != 1.0 {
+		t.Errorf("unexpected escalator %+v", escalator)
+	}
+
+	if up.Source != down.Target || up.Target != down.Source || up.Data["levels"] != -down.Data["levels"].(float64) {
+		t.Errorf("expected the stairs in both directions, got %+v and %+v", up, down)
+	}
+}
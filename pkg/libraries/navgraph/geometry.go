@@ -0,0 +1,71 @@
+package navgraph
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulmach/orb"
+	"math"
+)
+
+// rings returns the rings of a polygon or multipolygon
+func rings(geom orb.Geometry) []orb.Ring {
+	switch g := geom.(type) {
+	case orb.Polygon:
+		return g
+	case orb.MultiPolygon:
+		var out []orb.Ring
+		for _, polygon := range g {
+			out = append(out, polygon...)
+		}
+		return out
+	}
+	return nil
+}
+
+func sub(a, b orb.Point) orb.Point {
+	return orb.Point{a.X() - b.X(), a.Y() - b.Y()}
+}
+
+func cross(a, b orb.Point) float64 {
+	return a.X()*b.Y() - a.Y()*b.X()
+}
+
+func length(a orb.Point) float64 {
+	return math.Hypot(a.X(), a.Y())
+}
+
+func normalize(a orb.Point) orb.Point {
+	l := length(a)
+	if l == 0 {
+		return a
+	}
+	return orb.Point{a.X() / l, a.Y() / l}
+}
+
+// intersection returns the position of the intersection of the segments pq and cd along pq in [0, 1].
+// Parallel segments never intersect.
+func intersection(p, q, c, d orb.Point) (float64, bool) {
+	r, s := sub(q, p), sub(d, c)
+	denom := cross(r, s)
+	if math.Abs(denom) < 1e-12 {
+		return 0, false
+	}
+
+	t := cross(sub(c, p), s) / denom
+	u := cross(sub(c, p), r) / denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+
+	return t, true
+}
+
+// boundaryDistance returns the distance of the point to the nearest ring of the geometry
+func boundaryDistance(geom orb.Geometry, point orb.Point) float64 {
+	out := math.Inf(1)
+	for _, ring := range rings(geom) {
+		for i := 0; i+1 < len(ring); i++ {
+			out = math.Min(out, geoutil.SegmentDistance(point, ring[i], ring[i+1]))
+		}
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package navgraph
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"sync"
+)
+
+type NodeKind string
+
+const (
+	// NodeKindDoor is a node tagged with door=* or entrance=*
+	NodeKindDoor NodeKind = "door"
+	// NodeKindElevator is a highway=elevator node, there is one node per level it serves
+	NodeKindElevator NodeKind = "elevator"
+	// NodeKindWay is a vertex of a footway, corridor or steps way
+	NodeKindWay NodeKind = "way"
+	// NodeKindCorner is a reflex corner of a walkable area, moved slightly into the area
+	NodeKindCorner NodeKind = "corner"
+	// NodeKindAnchor is a point inside of a walkable area, used to route to the area itself
+	NodeKindAnchor NodeKind = "anchor"
+)
+
+type EdgeKind string
+
+const (
+	// EdgeKindWalk is a straight line through a walkable area
+	EdgeKindWalk      EdgeKind = "walk"
+	EdgeKindFootway   EdgeKind = "footway"
+	EdgeKindStairs    EdgeKind = "stairs"
+	EdgeKindEscalator EdgeKind = "escalator"
+	EdgeKindElevator  EdgeKind = "elevator"
+)
+
+// Node is a vertex of the navigation graph, its ID is its index in Graph.Nodes.
+type Node struct {
+	ID    int64
+	Point orb.Point
+	Level float64
+	Kind  NodeKind
+	// Feature is the short id of the osm feature the node is derived from, e.g. n123 for a door or w42 for an area
+	Feature string
+	// Tags are the tags of the feature relevant for navigation, e.g. name, ref, door or wheelchair
+	Tags map[string]string
+}
+
+// Edge connects two nodes, it can be traversed in both directions unless it is Oneway.
+type Edge struct {
+	From int64
+	To   int64
+	Kind EdgeKind
+	// Length is the horizontal length in meters
+	Length float64
+	// Levels is the number of levels climbed from From to To, negative when going down
+	Levels     float64
+	Oneway     bool
+	Wheelchair bool
+	// Feature is the short id of the area a walk crosses, of the way a footway, stairs or escalator follows
+	// or of the elevator or stairs room connecting the levels
+	Feature string
+	// Cost is the time in seconds needed with the Walking profile
+	Cost float64
+}
+
+// Area is a walkable area on a single level. Areas spanning multiple levels are added once per level.
+type Area struct {
+	ID       string
+	Level    float64
+	Tags     map[string]string
+	Geometry orb.Geometry
+	// Nodes are the nodes inside of or on the boundary of the area
+	Nodes []int64
+}
+
+// Graph is the indoor navigation graph. It must not be modified once it is searched.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+	Areas []Area
+
+	adjacencyOnce sync.Once
+	adjacencyList [][]int
+}
+
+// GeoJSON returns the nodes and edges as features, edges are included if one of their nodes is included.
+// A nil include function includes all nodes.
+func (g *Graph) GeoJSON(include func(node Node) bool) *geojson.FeatureCollection {
+	if include == nil {
+		include = func(Node) bool { return true }
+	}
+
+	out := geojson.NewFeatureCollection()
+	for _, node := range g.Nodes {
+		if !include(node) {
+			continue
+		}
+
+		feat := geojson.NewFeature(node.Point)
+		feat.Properties["type"] = "node"
+		feat.Properties["id"] = node.ID
+		feat.Properties["kind"] = node.Kind
+		feat.Properties["level"] = node.Level
+		feat.Properties["feature"] = node.Feature
+		for key, value := range node.Tags {
+			feat.Properties[key] = value
+		}
+		out.Append(feat)
+	}
+
+	for _, edge := range g.Edges {
+		from, to := g.Nodes[edge.From], g.Nodes[edge.To]
+		if !include(from) && !include(to) {
+			continue
+		}
+
+		feat := geojson.NewFeature(orb.LineString{from.Point, to.Point})
+		feat.Properties["type"] = "edge"
+		feat.Properties["from"] = edge.From
+		feat.Properties["to"] = edge.To
+		feat.Properties["kind"] = edge.Kind
+		feat.Properties["length"] = edge.Length
+		feat.Properties["levels"] = edge.Levels
+		feat.Properties["from_level"] = from.Level
+		feat.Properties["to_level"] = to.Level
+		feat.Properties["oneway"] = edge.Oneway
+		feat.Properties["wheelchair"] = edge.Wheelchair
+		feat.Properties["cost"] = edge.Cost
+		if edge.Feature != "" {
+			feat.Properties["feature"] = edge.Feature
+		}
+		out.Append(feat)
+	}
+
+	return out
+}
@@ -0,0 +1,131 @@
+package navgraph
+
+import (
+	"container/heap"
+	"math"
+)
+
+// TargetTree contains the fastest path from every node to its nearest target, e.g. to the nearest emergency exit.
+type TargetTree struct {
+	graph   *Graph
+	profile Profile
+	costs   []float64
+	targets []bool
+	// next is the first leg of the path from the node to its target
+	next []Leg
+}
+
+// NearestTargets searches the fastest paths from all nodes to the nearest node matching the target function.
+// The search runs backwards from all targets at once, so oneway edges are only traversed in their direction.
+func (g *Graph) NearestTargets(target func(node Node) bool, profile Profile) *TargetTree {
+	// incoming contains the edges which can be traversed to reach the node
+	incoming := make([][]int, len(g.Nodes))
+	for i, edge := range g.Edges {
+		incoming[edge.To] = append(incoming[edge.To], i)
+		if !edge.Oneway {
+			incoming[edge.From] = append(incoming[edge.From], i)
+		}
+	}
+
+	tree := &TargetTree{
+		graph:   g,
+		profile: profile,
+		costs:   make([]float64, len(g.Nodes)),
+		targets: make([]bool, len(g.Nodes)),
+		next:    make([]Leg, len(g.Nodes)),
+	}
+
+	queue := &searchQueue{}
+	for i, node := range g.Nodes {
+		tree.costs[i] = math.Inf(1)
+		if target(node) {
+			tree.costs[i], tree.targets[i] = 0, true
+			heap.Push(queue, &searchItem{node: node.ID})
+		}
+	}
+
+	visited := make([]bool, len(g.Nodes))
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(*searchItem).node
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		for _, i := range incoming[current] {
+			edge := g.Edges[i]
+			if edge.To != current {
+				edge.From, edge.To, edge.Levels = edge.To, edge.From, -edge.Levels
+			}
+
+			cost, ok := profile.Cost(edge)
+			if !ok {
+				continue
+			}
+			edge.Cost = cost
+
+			total := tree.costs[current] + cost
+			if total >= tree.costs[edge.From] {
+				continue
+			}
+
+			tree.costs[edge.From] = total
+			tree.next[edge.From] = Leg{From: g.Nodes[edge.From], To: g.Nodes[current], Edge: edge}
+			heap.Push(queue, &searchItem{node: edge.From, priority: total})
+		}
+	}
+
+	return tree
+}
+
+// Cost returns the time in seconds needed from the node to its nearest target, false is returned if there is none.
+func (t *TargetTree) Cost(node int64) (float64, bool) {
+	cost := t.costs[node]
+	return cost, !math.IsInf(cost, 1)
+}
+
+// PathFrom returns the fastest path from the endpoint to its nearest target, false is returned if there is none.
+func (t *TargetTree) PathFrom(from Endpoint) (Path, bool) {
+	start := Node{ID: startNode, Point: from.Point, Level: from.Level, Kind: NodeKindEndpoint}
+
+	best, bestCost := Leg{}, math.Inf(1)
+	for _, link := range from.Links {
+		if link.Length == 0 && link.Area == "" {
+			// the endpoint is the node itself
+			if cost, ok := t.Cost(link.Node); ok && cost < bestCost {
+				best, bestCost = Leg{To: t.graph.Nodes[link.Node]}, cost
+			}
+			continue
+		}
+
+		leg := linkLeg(start, t.graph.Nodes[link.Node], link)
+		cost, ok := t.profile.Cost(leg.Edge)
+		if !ok || math.IsInf(t.costs[link.Node], 1) {
+			continue
+		}
+		leg.Edge.Cost = cost
+
+		if total := cost + t.costs[link.Node]; total < bestCost {
+			best, bestCost = leg, total
+		}
+	}
+
+	if math.IsInf(bestCost, 1) {
+		return Path{}, false
+	}
+
+	out := Path{Cost: bestCost}
+	if best.From.Kind == NodeKindEndpoint {
+		out.Legs = append(out.Legs, best)
+		out.Length += best.Edge.Length
+	}
+
+	for id := best.To.ID; !t.targets[id]; {
+		leg := t.next[id]
+		out.Legs = append(out.Legs, leg)
+		out.Length += leg.Edge.Length
+		id = leg.To.ID
+	}
+
+	return out, true
+}
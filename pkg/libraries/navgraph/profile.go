@@ -0,0 +1,71 @@
+package navgraph
+
+import "math"
+
+// Profile defines how fast the edges of the graph can be traversed.
+type Profile struct {
+	Name string
+	// Speed is the speed on level ground in m/s
+	Speed float64
+	// StairsSpeed is the horizontal speed on stairs in m/s, stairs are not used if it is 0
+	StairsSpeed float64
+	// EscalatorSpeed is the horizontal speed on escalators in m/s, escalators are not used if it is 0
+	EscalatorSpeed float64
+	// LevelTime is the time in seconds needed to climb a level on stairs
+	LevelTime float64
+	// ElevatorWait is the time in seconds waiting for an elevator
+	ElevatorWait float64
+	// ElevatorLevelTime is the time in seconds an elevator needs per level
+	ElevatorLevelTime float64
+	// Wheelchair only allows edges accessible by wheelchair
+	Wheelchair bool
+	// NoElevators does not use elevators, e.g. when evacuating
+	NoElevators bool
+}
+
+var Walking = Profile{
+	Name:              "walking",
+	Speed:             1.3,
+	StairsSpeed:       0.6,
+	EscalatorSpeed:    0.8,
+	LevelTime:         8,
+	ElevatorWait:      30,
+	ElevatorLevelTime: 4,
+}
+
+// Evacuation walks to the exits without using elevators, escalators are climbed like stairs.
+var Evacuation = Profile{
+	Name:           "evacuation",
+	Speed:          1.3,
+	StairsSpeed:    0.6,
+	EscalatorSpeed: 0.6,
+	LevelTime:      8,
+	NoElevators:    true,
+}
+
+// Cost returns the time in seconds needed to traverse the edge, false is returned if it can not be traversed.
+func (p Profile) Cost(edge Edge) (float64, bool) {
+	if p.Wheelchair && !edge.Wheelchair {
+		return 0, false
+	}
+
+	switch edge.Kind {
+	case EdgeKindStairs:
+		if p.StairsSpeed <= 0 {
+			return 0, false
+		}
+		return edge.Length/p.StairsSpeed + math.Abs(edge.Levels)*p.LevelTime, true
+	case EdgeKindEscalator:
+		if p.EscalatorSpeed <= 0 {
+			return 0, false
+		}
+		return edge.Length / p.EscalatorSpeed, true
+	case EdgeKindElevator:
+		if p.NoElevators {
+			return 0, false
+		}
+		return p.ElevatorWait + math.Abs(edge.Levels)*p.ElevatorLevelTime, true
+	default:
+		return edge.Length / p.Speed, true
+	}
+}
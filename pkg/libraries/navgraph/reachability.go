@@ -0,0 +1,320 @@
+package navgraph
+
+import (
+	"cmp"
+	"container/heap"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"math"
+	"slices"
+)
+
+// Isochrone is the part of the walkable areas on a level reachable within a cost.
+type Isochrone struct {
+	Level float64
+	// Cost is the time in seconds
+	Cost     float64
+	Geometry orb.MultiPolygon
+}
+
+// Reachable returns the time in seconds needed from the endpoint to all nodes reachable within maxCost.
+func (g *Graph) Reachable(from Endpoint, profile Profile, maxCost float64) map[int64]float64 {
+	adjacency := g.adjacency()
+	start := Node{ID: startNode, Point: from.Point, Level: from.Level, Kind: NodeKindEndpoint}
+
+	costs := make(map[int64]float64)
+	queue := &searchQueue{}
+	relax := func(leg Leg, base float64) {
+		cost, ok := profile.Cost(leg.Edge)
+		if !ok {
+			return
+		}
+
+		total := base + cost
+		if current, ok := costs[leg.To.ID]; total > maxCost || (ok && current <= total) {
+			return
+		}
+
+		costs[leg.To.ID] = total
+		heap.Push(queue, &searchItem{node: leg.To.ID, priority: total})
+	}
+
+	for _, link := range from.Links {
+		relax(linkLeg(start, g.Nodes[link.Node], link), 0)
+	}
+
+	visited := make(map[int64]bool)
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(*searchItem).node
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		for _, i := range adjacency[current] {
+			edge := g.Edges[i]
+			if edge.From != current {
+				edge.From, edge.To, edge.Levels = edge.To, edge.From, -edge.Levels
+			}
+			relax(Leg{From: g.Nodes[current], To: g.Nodes[edge.To], Edge: edge}, costs[current])
+		}
+	}
+
+	return costs
+}
+
+// maxIsochroneSamples is the maximal number of distances computed by Isochrones, cells times the sources of their area
+const maxIsochroneSamples = 1 << 24
+
+// Isochrones returns the parts of the walkable areas reachable from the endpoint within the costs, per level and cost.
+// The areas are sampled with square cells of cellSize meters on a grid shared by all areas, a cell is reached if the
+// straight walk from a reached node of its area fits into the remaining time. Walls within an area are not considered,
+// which overestimates the reach around corners slightly. The reached cells are dissolved into polygons.
+// It returns false if more than maxIsochroneSamples samples would be needed, the cell size has to be increased then.
+func (g *Graph) Isochrones(from Endpoint, profile Profile, costs []float64, cellSize float64) ([]Isochrone, bool) {
+	if len(costs) == 0 {
+		return nil, true
+	}
+
+	costs = slices.Clone(costs)
+	slices.Sort(costs)
+	maxCost := costs[len(costs)-1]
+	reached := g.Reachable(from, profile, maxCost)
+	proj := geoutil.NewProjection(from.Point)
+
+	type source struct {
+		point orb.Point
+		cost  float64
+	}
+
+	type sampledArea struct {
+		level            float64
+		xy               orb.Geometry
+		sources          []source
+		minCell, maxCell [2]int
+	}
+
+	// only the parts of the areas within the walking distance left at their sources are sampled
+	var areas []sampledArea
+	samples := 0
+	for i, area := range g.Areas {
+		var sources []source
+		for _, id := range area.Nodes {
+			if cost, ok := reached[id]; ok {
+				sources = append(sources, source{point: proj.ToXY(g.Nodes[id].Point), cost: cost})
+			}
+		}
+		if slices.Contains(from.areas, i) {
+			sources = append(sources, source{point: proj.ToXY(from.Point)})
+		}
+		if len(sources) == 0 {
+			continue
+		}
+
+		var bound orb.Bound
+		for j, src := range sources {
+			distance := (maxCost - src.cost) * profile.Speed
+			reach := orb.Bound{
+				Min: orb.Point{src.point.X() - distance, src.point.Y() - distance},
+				Max: orb.Point{src.point.X() + distance, src.point.Y() + distance},
+			}
+			if j == 0 {
+				bound = reach
+			} else {
+				bound = bound.Union(reach)
+			}
+		}
+
+		xy := proj.GeometryToXY(area.Geometry)
+		areaBound := xy.Bound()
+		if !bound.Intersects(areaBound) {
+			continue
+		}
+		bound = orb.Bound{
+			Min: orb.Point{max(bound.Min.X(), areaBound.Min.X()), max(bound.Min.Y(), areaBound.Min.Y())},
+			Max: orb.Point{min(bound.Max.X(), areaBound.Max.X()), min(bound.Max.Y(), areaBound.Max.Y())},
+		}
+
+		sampled := sampledArea{
+			level:   area.Level,
+			xy:      xy,
+			sources: sources,
+			minCell: [2]int{int(math.Floor(bound.Min.X() / cellSize)), int(math.Floor(bound.Min.Y() / cellSize))},
+			maxCell: [2]int{int(math.Floor(bound.Max.X() / cellSize)), int(math.Floor(bound.Max.Y() / cellSize))},
+		}
+
+		samples += (sampled.maxCell[0] - sampled.minCell[0] + 1) * (sampled.maxCell[1] - sampled.minCell[1] + 1) * len(sources)
+		if samples > maxIsochroneSamples {
+			return nil, false
+		}
+		areas = append(areas, sampled)
+	}
+
+	// cells holds the lowest cost of the reached cells per level, overlapping areas share their cells
+	cells := make(map[float64]map[[2]int]float64)
+	for _, area := range areas {
+		levelCells := cells[area.level]
+		if levelCells == nil {
+			levelCells = make(map[[2]int]float64)
+			cells[area.level] = levelCells
+		}
+
+		for x := area.minCell[0]; x <= area.maxCell[0]; x++ {
+			for y := area.minCell[1]; y <= area.maxCell[1]; y++ {
+				center := orb.Point{(float64(x) + 0.5) * cellSize, (float64(y) + 0.5) * cellSize}
+				if !geoutil.Contains(area.xy, center) {
+					continue
+				}
+
+				cellCost := math.Inf(1)
+				for _, src := range area.sources {
+					distance := math.Hypot(center.X()-src.point.X(), center.Y()-src.point.Y())
+					cellCost = min(cellCost, src.cost+distance/profile.Speed)
+				}
+
+				cell := [2]int{x, y}
+				if current, ok := levelCells[cell]; cellCost <= maxCost && (!ok || cellCost < current) {
+					levelCells[cell] = cellCost
+				}
+			}
+		}
+	}
+
+	out := make([]Isochrone, 0, len(cells)*len(costs))
+	for level, levelCells := range cells {
+		for _, cost := range costs {
+			filled := make(map[[2]int]bool)
+			for cell, cellCost := range levelCells {
+				if cellCost <= cost {
+					filled[cell] = true
+				}
+			}
+			if len(filled) == 0 {
+				continue
+			}
+
+			geom := dissolveCells(filled)
+			for _, polygon := range geom {
+				for _, ring := range polygon {
+					for i, point := range ring {
+						ring[i] = proj.FromXY(orb.Point{point.X() * cellSize, point.Y() * cellSize})
+					}
+				}
+			}
+			out = append(out, Isochrone{Level: level, Cost: cost, Geometry: geom})
+		}
+	}
+
+	slices.SortFunc(out, func(a, b Isochrone) int {
+		if a.Level != b.Level {
+			return cmp.Compare(a.Level, b.Level)
+		}
+		return cmp.Compare(b.Cost, a.Cost)
+	})
+
+	return out, true
+}
+
+// dissolveCells traces the outlines of the cells, given by their lower left corner, into polygons in grid units.
+// Outer rings are counterclockwise and holes clockwise. Cells touching only at a corner end up in separate polygons.
+func dissolveCells(cells map[[2]int]bool) orb.MultiPolygon {
+	// next holds the boundary edges per start vertex, the filled cell is on the left of every edge
+	next := make(map[[2]int][][2]int)
+	addEdge := func(from, to [2]int) {
+		next[from] = append(next[from], to)
+	}
+	for cell := range cells {
+		x, y := cell[0], cell[1]
+		if !cells[[2]int{x, y - 1}] {
+			addEdge([2]int{x, y}, [2]int{x + 1, y})
+		}
+		if !cells[[2]int{x + 1, y}] {
+			addEdge([2]int{x + 1, y}, [2]int{x + 1, y + 1})
+		}
+		if !cells[[2]int{x, y + 1}] {
+			addEdge([2]int{x + 1, y + 1}, [2]int{x, y + 1})
+		}
+		if !cells[[2]int{x - 1, y}] {
+			addEdge([2]int{x, y + 1}, [2]int{x, y})
+		}
+	}
+
+	starts := make([][2]int, 0, len(next))
+	for vertex := range next {
+		starts = append(starts, vertex)
+	}
+	slices.SortFunc(starts, func(a, b [2]int) int {
+		if a[1] != b[1] {
+			return cmp.Compare(a[1], b[1])
+		}
+		return cmp.Compare(a[0], b[0])
+	})
+
+	var outers, holes []orb.Ring
+	for _, start := range starts {
+		for len(next[start]) > 0 {
+			ring := orb.Ring{{float64(start[0]), float64(start[1])}}
+			var direction [2]int
+			for at := start; ; {
+				outs := next[at]
+
+				// at a vertex shared by two diagonal cells the left turn keeps following the current cell
+				index := 0
+				for i, to := range outs {
+					turn := [2]int{to[0] - at[0], to[1] - at[1]}
+					if direction[0]*turn[1]-direction[1]*turn[0] > 0 {
+						index = i
+					}
+				}
+
+				to := outs[index]
+				next[at] = slices.Delete(outs, index, index+1)
+
+				turn := [2]int{to[0] - at[0], to[1] - at[1]}
+				point := orb.Point{float64(to[0]), float64(to[1])}
+				if turn == direction && len(ring) > 1 {
+					ring[len(ring)-1] = point
+				} else {
+					ring = append(ring, point)
+				}
+				direction, at = turn, to
+
+				if at == start {
+					break
+				}
+			}
+
+			if ring.Orientation() == orb.CCW {
+				outers = append(outers, ring)
+			} else {
+				holes = append(holes, ring)
+			}
+		}
+	}
+
+	out := make(orb.MultiPolygon, 0, len(outers))
+	for _, outer := range outers {
+		out = append(out, orb.Polygon{outer})
+	}
+
+	// a hole belongs to the smallest outer ring containing the empty cell on the right of its first edge
+	for _, hole := range holes {
+		dx, dy := hole[1].X()-hole[0].X(), hole[1].Y()-hole[0].Y()
+		length := math.Hypot(dx, dy)
+		dx, dy = dx/length, dy/length
+		inside := orb.Point{hole[0].X() + 0.5*dx + 0.5*dy, hole[0].Y() + 0.5*dy - 0.5*dx}
+
+		best, bestArea := -1, math.Inf(1)
+		for i, polygon := range out {
+			if area := planar.Area(polygon[0]); area < bestArea && planar.RingContains(polygon[0], inside) {
+				best, bestArea = i, area
+			}
+		}
+		if best >= 0 {
+			out[best] = append(out[best], hole)
+		}
+	}
+
+	return out
+}
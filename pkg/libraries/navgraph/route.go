@@ -0,0 +1,418 @@
+package navgraph
+
+import (
+	"container/heap"
+	"context"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"slices"
+)
+
+const (
+	// NodeKindEndpoint is the kind of the virtual nodes where a path starts and ends
+	NodeKindEndpoint NodeKind = "endpoint"
+	// maxEndpointSnapDistance is the distance in meters up to which points outside of areas are snapped to the nearest node
+	maxEndpointSnapDistance = 20.0
+	// searchContextInterval is the number of nodes settled between two checks of the context of a search
+	searchContextInterval = 1024
+)
+
+const (
+	startNode int64 = -1
+	endNode   int64 = -2
+)
+
+// Link connects an endpoint to a node of the graph with a straight walk.
+type Link struct {
+	Node   int64
+	Length float64
+	// Area is the short id of the area the walk crosses
+	Area string
+}
+
+// Endpoint is where a path starts or ends.
+type Endpoint struct {
+	Point orb.Point
+	Level float64
+	Links []Link
+	// areas are the indexes of the areas containing the endpoint
+	areas []int
+}
+
+// Leg is a traversed edge of a path. The edge is oriented from From to To and its cost is the cost of the profile.
+type Leg struct {
+	From Node
+	To   Node
+	Edge Edge
+}
+
+// PathCost is the cost and length of the fastest path to a target of ShortestPathCosts.
+type PathCost struct {
+	// Cost is the time needed in seconds
+	Cost   float64
+	Length float64
+	// Found is false if there is no path to the target
+	Found bool
+}
+
+// Path is the result of a search.
+type Path struct {
+	Legs []Leg
+	// Cost is the time needed in seconds
+	Cost   float64
+	Length float64
+}
+
+var Wheelchair = Profile{
+	Name:              "wheelchair",
+	Speed:             0.9,
+	ElevatorWait:      30,
+	ElevatorLevelTime: 4,
+	Wheelchair:        true,
+}
+
+// Profiles are the available profiles by name.
+var Profiles = map[string]Profile{
+	Walking.Name:    Walking,
+	Wheelchair.Name: Wheelchair,
+}
+
+// NodeEndpoint returns an endpoint at the node.
+func (g *Graph) NodeEndpoint(id int64) Endpoint {
+	node := g.Nodes[id]
+	return Endpoint{
+		Point: node.Point,
+		Level: node.Level,
+		Links: []Link{{Node: id}},
+	}
+}
+
+// FeatureEndpoint returns an endpoint at the anchor of the area or at the node derived from the feature,
+// e.g. a door. The lowest level is used if the feature spans multiple levels.
+func (g *Graph) FeatureEndpoint(feature string) (Endpoint, bool) {
+	best := int64(-1)
+	for _, node := range g.Nodes {
+		if node.Feature != feature || (node.Kind != NodeKindAnchor && node.Kind != NodeKindDoor && node.Kind != NodeKindElevator) {
+			continue
+		}
+
+		if best < 0 || node.Level < g.Nodes[best].Level {
+			best = node.ID
+		}
+	}
+
+	if best < 0 {
+		return Endpoint{}, false
+	}
+
+	return g.NodeEndpoint(best), true
+}
+
+// PointEndpoint returns an endpoint at the point, linked to all nodes of the areas containing it which it can see.
+// Points outside of all areas are linked to the nearest node on the level.
+func (g *Graph) PointEndpoint(point orb.Point, level float64) (Endpoint, bool) {
+	out := Endpoint{Point: point, Level: level}
+
+	for i, area := range g.Areas {
+		if area.Level != level || !area.Geometry.Bound().Contains(point) || !geoutil.Contains(area.Geometry, point) {
+			continue
+		}
+
+		out.areas = append(out.areas, i)
+		proj := geoutil.NewProjection(area.Geometry.Bound().Center())
+		xy := proj.GeometryToXY(area.Geometry)
+		for _, id := range area.Nodes {
+			node := g.Nodes[id]
+			if visible(xy, proj.ToXY(point), proj.ToXY(node.Point)) {
+				out.Links = append(out.Links, Link{Node: id, Length: geo.Distance(point, node.Point), Area: area.ID})
+			}
+		}
+	}
+
+	if len(out.Links) > 0 {
+		return out, true
+	}
+
+	nearest, distance := int64(-1), maxEndpointSnapDistance
+	for _, node := range g.Nodes {
+		if node.Level != level {
+			continue
+		}
+
+		if d := geo.Distance(point, node.Point); d <= distance {
+			nearest, distance = node.ID, d
+		}
+	}
+
+	if nearest < 0 {
+		return Endpoint{}, false
+	}
+
+	out.Links = append(out.Links, Link{Node: nearest, Length: distance})
+	return out, true
+}
+
+type searchItem struct {
+	node     int64
+	priority float64
+	index    int
+}
+
+type searchQueue []*searchItem
+
+func (q searchQueue) Len() int           { return len(q) }
+func (q searchQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q searchQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+func (q *searchQueue) Push(x any) {
+	item := x.(*searchItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+func (q *searchQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// ShortestPath searches the fastest path between the endpoints with A*, false is returned if there is none.
+func (g *Graph) ShortestPath(from, to Endpoint, profile Profile) (Path, bool) {
+	adjacency := g.adjacency()
+
+	targets := make(map[int64]Link, len(to.Links))
+	for _, link := range to.Links {
+		targets[link.Node] = link
+	}
+
+	// the heuristic is the straight distance at the fastest speed of the profile, which never overestimates
+	speed := max(profile.Speed, profile.StairsSpeed, profile.EscalatorSpeed)
+	heuristic := func(point orb.Point) float64 {
+		return geo.Distance(point, to.Point) / speed
+	}
+
+	costs := map[int64]float64{startNode: 0}
+	previous := make(map[int64]Leg)
+	queue := &searchQueue{}
+	heap.Push(queue, &searchItem{node: startNode, priority: heuristic(from.Point)})
+
+	startLeg := Node{ID: startNode, Point: from.Point, Level: from.Level, Kind: NodeKindEndpoint}
+	endLeg := Node{ID: endNode, Point: to.Point, Level: to.Level, Kind: NodeKindEndpoint}
+
+	relax := func(leg Leg) {
+		cost, ok := profile.Cost(leg.Edge)
+		if !ok {
+			return
+		}
+		leg.Edge.Cost = cost
+
+		total := costs[leg.From.ID] + cost
+		if current, ok := costs[leg.To.ID]; ok && current <= total {
+			return
+		}
+
+		costs[leg.To.ID] = total
+		previous[leg.To.ID] = leg
+		heap.Push(queue, &searchItem{node: leg.To.ID, priority: total + heuristic(leg.To.Point)})
+	}
+
+	visited := make(map[int64]bool)
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(*searchItem).node
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		if current == endNode {
+			return g.buildPath(previous, costs[endNode]), true
+		}
+
+		if current == startNode {
+			for _, link := range from.Links {
+				relax(linkLeg(startLeg, g.Nodes[link.Node], link))
+			}
+			if g.directlyVisible(from, to) {
+				relax(linkLeg(startLeg, endLeg, Link{Length: geo.Distance(from.Point, to.Point)}))
+			}
+			continue
+		}
+
+		node := g.Nodes[current]
+		if link, ok := targets[current]; ok {
+			relax(linkLeg(node, endLeg, link))
+		}
+
+		for _, i := range adjacency[current] {
+			edge := g.Edges[i]
+			next := edge.To
+			if edge.From != current {
+				edge.From, edge.To, edge.Levels = edge.To, edge.From, -edge.Levels
+				next = edge.To
+			}
+			relax(Leg{From: node, To: g.Nodes[next], Edge: edge})
+		}
+	}
+
+	return Path{}, false
+}
+
+// ShortestPathCosts searches the fastest paths from the endpoint to all targets with a single Dijkstra search,
+// which stops once every target is reached. The context is checked while searching.
+func (g *Graph) ShortestPathCosts(ctx context.Context, from Endpoint, to []Endpoint, profile Profile) ([]PathCost, error) {
+	adjacency := g.adjacency()
+
+	// the targets are virtual nodes below endNode, linked from the nodes of their links
+	type targetLink struct {
+		node Node
+		link Link
+	}
+	targetNodes := make([]Node, len(to))
+	targets := make(map[int64][]targetLink)
+	for i, endpoint := range to {
+		targetNodes[i] = Node{ID: endNode - int64(i), Point: endpoint.Point, Level: endpoint.Level, Kind: NodeKindEndpoint}
+		for _, link := range endpoint.Links {
+			targets[link.Node] = append(targets[link.Node], targetLink{node: targetNodes[i], link: link})
+		}
+	}
+
+	costs := map[int64]float64{startNode: 0}
+	lengths := map[int64]float64{startNode: 0}
+	queue := &searchQueue{}
+	heap.Push(queue, &searchItem{node: startNode})
+
+	startLeg := Node{ID: startNode, Point: from.Point, Level: from.Level, Kind: NodeKindEndpoint}
+
+	relax := func(leg Leg) {
+		cost, ok := profile.Cost(leg.Edge)
+		if !ok {
+			return
+		}
+
+		total := costs[leg.From.ID] + cost
+		if current, ok := costs[leg.To.ID]; ok && current <= total {
+			return
+		}
+
+		costs[leg.To.ID] = total
+		lengths[leg.To.ID] = lengths[leg.From.ID] + leg.Edge.Length
+		heap.Push(queue, &searchItem{node: leg.To.ID, priority: total})
+	}
+
+	out := make([]PathCost, len(to))
+	remaining := len(to)
+	visited := make(map[int64]bool)
+	for settled := 0; queue.Len() > 0 && remaining > 0; settled++ {
+		if settled%searchContextInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		current := heap.Pop(queue).(*searchItem).node
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		if current <= endNode {
+			out[endNode-current] = PathCost{Cost: costs[current], Length: lengths[current], Found: true}
+			remaining--
+			continue
+		}
+
+		if current == startNode {
+			for _, link := range from.Links {
+				relax(linkLeg(startLeg, g.Nodes[link.Node], link))
+			}
+			for i, endpoint := range to {
+				if g.directlyVisible(from, endpoint) {
+					relax(linkLeg(startLeg, targetNodes[i], Link{Length: geo.Distance(from.Point, endpoint.Point)}))
+				}
+			}
+			continue
+		}
+
+		node := g.Nodes[current]
+		for _, target := range targets[current] {
+			relax(linkLeg(node, target.node, target.link))
+		}
+
+		for _, i := range adjacency[current] {
+			edge := g.Edges[i]
+			if edge.From != current {
+				edge.From, edge.To, edge.Levels = edge.To, edge.From, -edge.Levels
+			}
+			relax(Leg{From: node, To: g.Nodes[edge.To], Edge: edge})
+		}
+	}
+
+	return out, nil
+}
+
+func (g *Graph) buildPath(previous map[int64]Leg, cost float64) Path {
+	out := Path{Cost: cost}
+	for id := endNode; id != startNode; {
+		leg := previous[id]
+		out.Legs = append(out.Legs, leg)
+		out.Length += leg.Edge.Length
+		id = leg.From.ID
+	}
+
+	slices.Reverse(out.Legs)
+	return out
+}
+
+// directlyVisible reports whether the endpoints share an area in which they can see each other
+func (g *Graph) directlyVisible(from, to Endpoint) bool {
+	if from.Level != to.Level {
+		return false
+	}
+
+	for _, i := range from.areas {
+		if !slices.Contains(to.areas, i) {
+			continue
+		}
+
+		area := g.Areas[i]
+		proj := geoutil.NewProjection(area.Geometry.Bound().Center())
+		if visible(proj.GeometryToXY(area.Geometry), proj.ToXY(from.Point), proj.ToXY(to.Point)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func linkLeg(from, to Node, link Link) Leg {
+	return Leg{
+		From: from,
+		To:   to,
+		Edge: Edge{
+			From:       from.ID,
+			To:         to.ID,
+			Kind:       EdgeKindWalk,
+			Length:     link.Length,
+			Wheelchair: true,
+			Feature:    link.Area,
+		},
+	}
+}
+
+// adjacency returns the indexes of the edges leaving every node, oneway edges are only added to their From node
+func (g *Graph) adjacency() [][]int {
+	g.adjacencyOnce.Do(func() {
+		g.adjacencyList = make([][]int, len(g.Nodes))
+		for i, edge := range g.Edges {
+			g.adjacencyList[edge.From] = append(g.adjacencyList[edge.From], i)
+			if !edge.Oneway {
+				g.adjacencyList[edge.To] = append(g.adjacencyList[edge.To], i)
+			}
+		}
+	})
+
+	return g.adjacencyList
+}
@@ -0,0 +1,249 @@
+package navgraph_test
+
+import (
+	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"math"
+	"testing"
+)
+
+func TestGraph_ShortestPath(t *testing.T) {
+	graph := testGraph()
+
+	from, ok := graph.PointEndpoint(pt(6, 6), 0)
+	if !ok {
+		t.Fatal("expected an endpoint in the office")
+	}
+
+	to, ok := graph.PointEndpoint(pt(1, 18), 0)
+	if !ok {
+		t.Fatal("expected an endpoint in the corridor")
+	}
+
+	path, ok := graph.ShortestPath(from, to, navgraph.Walking)
+	if !ok {
+		t.Fatal("expected a path")
+	}
+
+	var features []string
+	for _, leg := range path.Legs[1:] {
+		features = append(features, leg.From.Feature)
+	}
+
+	// the path leaves the office through its door and walks around the corner of the corridor
+	if len(features) != 2 || features[0] != "n10" || features[1] != "w1" {
+		t.Errorf("expected a path through n10 and w1, got %v", features)
+	}
+
+	if path.Length < 20 || path.Length > 30 || path.Cost <= 0 {
+		t.Errorf("unexpected length %v and cost %v", path.Length, path.Cost)
+	}
+}
+
+func TestGraph_ShortestPathCosts(t *testing.T) {
+	graph := testGraph()
+
+	from, _ := graph.PointEndpoint(pt(6, 6), 0)
+	var to []navgraph.Endpoint
+	for _, point := range []orb.Point{pt(1, 18), pt(7, 7), pt(18, 1)} {
+		endpoint, ok := graph.PointEndpoint(point, 0)
+		if !ok {
+			t.Fatalf("expected an endpoint at %v", point)
+		}
+		to = append(to, endpoint)
+	}
+	for _, node := range graph.Nodes {
+		if node.Kind == navgraph.NodeKindElevator && node.Level == 1 {
+			to = append(to, graph.NodeEndpoint(node.ID))
+		}
+	}
+
+	costs, err := graph.ShortestPathCosts(context.Background(), from, to, navgraph.Wheelchair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, endpoint := range to {
+		path, ok := graph.ShortestPath(from, endpoint, navgraph.Wheelchair)
+		if costs[i].Found != ok || math.Abs(costs[i].Cost-path.Cost) > 1e-9 || math.Abs(costs[i].Length-path.Length) > 1e-9 {
+			t.Errorf("expected the costs of target %d to match the shortest path %v, %v, got %+v", i, path.Cost, path.Length, costs[i])
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := graph.ShortestPathCosts(ctx, from, to, navgraph.Wheelchair); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGraph_ShortestPathProfiles(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	for _, level := range []string{"0", "1"} {
+		builder.AddArea("w"+level, map[string]string{"indoor": "corridor", "level": level},
+			polygon([2]float64{0, 0}, [2]float64{100, 0}, [2]float64{100, 10}, [2]float64{0, 10}))
+	}
+	builder.AddWay("w3", []navgraph.WayNode{{ID: 1, Point: pt(1, 5)}, {ID: 2, Point: pt(5, 5)}},
+		map[string]string{"highway": "steps", "level": "0;1"})
+	builder.AddPoint("n4", pt(99, 5), map[string]string{"highway": "elevator", "level": "0;1"})
+	graph := builder.Build()
+
+	from, _ := graph.PointEndpoint(pt(2, 2), 0)
+	to, _ := graph.PointEndpoint(pt(2, 8), 1)
+
+	tests := map[string]navgraph.EdgeKind{
+		navgraph.Walking.Name:    navgraph.EdgeKindStairs,
+		navgraph.Wheelchair.Name: navgraph.EdgeKindElevator,
+	}
+
+	for profile, expected := range tests {
+		path, ok := graph.ShortestPath(from, to, navgraph.Profiles[profile])
+		if !ok {
+			t.Errorf("expected a path for %s", profile)
+			continue
+		}
+
+		var kinds []navgraph.EdgeKind
+		for _, leg := range path.Legs {
+			if leg.Edge.Levels != 0 {
+				kinds = append(kinds, leg.Edge.Kind)
+			}
+		}
+
+		if len(kinds) != 1 || kinds[0] != expected {
+			t.Errorf("expected %s to change levels by %s, got %v", profile, expected, kinds)
+		}
+	}
+}
+
+func TestGraph_NearestTargets(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	for _, level := range []string{"0", "1"} {
+		builder.AddArea("w"+level, map[string]string{"indoor": "corridor", "level": level},
+			polygon([2]float64{0, 0}, [2]float64{100, 0}, [2]float64{100, 10}, [2]float64{0, 10}))
+	}
+	builder.AddWay("w3", []navgraph.WayNode{{ID: 1, Point: pt(1, 5)}, {ID: 2, Point: pt(5, 5)}},
+		map[string]string{"highway": "steps", "level": "0;1"})
+	builder.AddPoint("n4", pt(99, 5), map[string]string{"highway": "elevator", "level": "0;1"})
+	builder.AddPoint("n5", pt(100, 8), map[string]string{"entrance": "emergency", "level": "0"})
+	graph := builder.Build()
+
+	tree := graph.NearestTargets(func(node navgraph.Node) bool {
+		return node.Tags["entrance"] == "emergency"
+	}, navgraph.Evacuation)
+
+	from, _ := graph.PointEndpoint(pt(98, 2), 1)
+	path, ok := tree.PathFrom(from)
+	if !ok {
+		t.Fatal("expected a path to the exit")
+	}
+
+	var kinds []navgraph.EdgeKind
+	for _, leg := range path.Legs {
+		if leg.Edge.Levels != 0 {
+			kinds = append(kinds, leg.Edge.Kind)
+		}
+	}
+
+	if len(kinds) != 1 || kinds[0] != navgraph.EdgeKindStairs {
+		t.Errorf("expected to take the stairs instead of the elevator, got %v", kinds)
+	}
+
+	if last := path.Legs[len(path.Legs)-1]; last.To.Feature != "n5" || path.Length < 190 {
+		t.Errorf("expected a path of at least 190 m ending at n5, got %v m ending at %s", path.Length, last.To.Feature)
+	}
+
+	exit := findNode(t, graph, navgraph.NodeKindDoor, "n5")
+	if cost, ok := tree.Cost(exit.ID); !ok || cost != 0 {
+		t.Errorf("expected the exit to have no cost, got %v", cost)
+	}
+
+	path, ok = tree.PathFrom(graph.NodeEndpoint(exit.ID))
+	if !ok || len(path.Legs) != 0 {
+		t.Errorf("expected an empty path from the exit, got %+v", path)
+	}
+}
+
+func TestGraph_Isochrones(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	for _, level := range []string{"0", "1"} {
+		builder.AddArea("w"+level, map[string]string{"indoor": "corridor", "level": level},
+			polygon([2]float64{0, 0}, [2]float64{100, 0}, [2]float64{100, 10}, [2]float64{0, 10}))
+	}
+	builder.AddWay("w3", []navgraph.WayNode{{ID: 1, Point: pt(1, 5)}, {ID: 2, Point: pt(5, 5)}},
+		map[string]string{"highway": "steps", "level": "0;1"})
+	graph := builder.Build()
+
+	from, _ := graph.PointEndpoint(pt(2, 2), 0)
+	isochrones, ok := graph.Isochrones(from, navgraph.Walking, []float64{60, 30}, 1)
+	if !ok {
+		t.Fatal("expected the isochrones to be sampled")
+	}
+
+	area := make(map[[2]float64]float64)
+	for _, isochrone := range isochrones {
+		area[[2]float64{isochrone.Level, isochrone.Cost}] = geo.Area(isochrone.Geometry)
+	}
+
+	// the corridor is 11 m wide, within 30 s about 41 m of it are reached, within 60 s about 80 m
+	if a := area[[2]float64{0, 30}]; a < 420 || a > 480 {
+		t.Errorf("expected about 450 m² within 30 s on level 0, got %v", a)
+	}
+	if a := area[[2]float64{0, 60}]; a < 840 || a > 920 {
+		t.Errorf("expected about 880 m² within 60 s on level 0, got %v", a)
+	}
+
+	// the stairs need about 14 s, the upper level is reached within 30 s too
+	if area[[2]float64{1, 30}] == 0 || area[[2]float64{1, 60}] <= area[[2]float64{1, 30}] {
+		t.Errorf("expected the upper level to be reached, got %v", area)
+	}
+
+	if len(isochrones) != 4 || isochrones[0].Level != 0 || isochrones[0].Cost != 60 {
+		t.Errorf("expected isochrones sorted by level and descending cost, got %d", len(isochrones))
+	}
+}
+
+func TestGraph_IsochronesDissolvesAreas(t *testing.T) {
+	corridor := polygon([2]float64{0, 0}, [2]float64{40, 0}, [2]float64{40, 40}, [2]float64{0, 40})
+	corridor = append(corridor, polygon([2]float64{10, 10}, [2]float64{10, 30}, [2]float64{30, 30}, [2]float64{30, 10})[0])
+
+	builder := navgraph.NewBuilder()
+	builder.AddArea("w1", map[string]string{"indoor": "corridor", "level": "0"}, corridor)
+	builder.AddArea("w2", map[string]string{"indoor": "room", "level": "0"},
+		polygon([2]float64{2, 2}, [2]float64{8, 2}, [2]float64{8, 8}, [2]float64{2, 8}))
+	graph := builder.Build()
+
+	from, _ := graph.PointEndpoint(pt(5, 5), 0)
+	isochrones, ok := graph.Isochrones(from, navgraph.Walking, []float64{600}, 1)
+	if !ok {
+		t.Fatal("expected the isochrones to be sampled")
+	}
+
+	if len(isochrones) != 1 || len(isochrones[0].Geometry) != 1 {
+		t.Fatalf("expected the nested room and the corridor to be dissolved into one polygon, got %+v", isochrones)
+	}
+
+	polygon := isochrones[0].Geometry[0]
+	if len(polygon) != 2 || polygon[0].Orientation() != orb.CCW || polygon[1].Orientation() != orb.CW {
+		t.Errorf("expected an outer ring with the courtyard as hole, got %d rings", len(polygon))
+	}
+
+	if a, expected := geo.Area(polygon), geo.Area(corridor); math.Abs(a-expected) > 0.1*expected {
+		t.Errorf("expected about %v m² reached, got %v", expected, a)
+	}
+}
+
+func TestGraph_IsochronesLimitsSamples(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	builder.AddArea("w1", map[string]string{"indoor": "area", "level": "0"},
+		polygon([2]float64{0, 0}, [2]float64{10000, 0}, [2]float64{10000, 10000}, [2]float64{0, 10000}))
+	graph := builder.Build()
+
+	from, _ := graph.PointEndpoint(pt(5000, 5000), 0)
+	if _, ok := graph.Isochrones(from, navgraph.Walking, []float64{3600}, 0.25); ok {
+		t.Errorf("expected the isochrones of a large area at a fine resolution to be refused")
+	}
+}
@@ -0,0 +1,43 @@
+package osmid
+
+import (
+	"fmt"
+	"github.com/paulmach/osm"
+	"strconv"
+	"strings"
+)
+
+// Format formats the feature id in its short form, e.g. w123 for way/123
+func Format(id osm.FeatureID) string {
+	return string(id.Type()[0]) + strconv.FormatInt(id.Ref(), 10)
+}
+
+// Parse parses the short form of a feature id, e.g. n1, w123 or r42. The long form way/123 is accepted as well.
+func Parse(s string) (osm.FeatureID, error) {
+	if strings.Contains(s, "/") {
+		return osm.ParseFeatureID(s)
+	}
+
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid feature id: %q", s)
+	}
+
+	ref, err := strconv.ParseInt(s[1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid feature id: %q: %w", s, err)
+	}
+
+	var typ osm.Type
+	switch s[0] {
+	case 'n':
+		typ = osm.TypeNode
+	case 'w':
+		typ = osm.TypeWay
+	case 'r':
+		typ = osm.TypeRelation
+	default:
+		return 0, fmt.Errorf("invalid feature id: %q: unknown type", s)
+	}
+
+	return typ.FeatureID(ref)
+}
@@ -0,0 +1,100 @@
+package osmlevel
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxRange is the largest difference between the levels of a range, larger ranges are invalid
+// as they are mapping mistakes and would expand into huge lists.
+const MaxRange = 200
+
+// Parse parses the value of a level tag into the levels it covers.
+// Values may be lists separated by semicolons and ranges of whole levels, e.g. "0", "-1;0", "0-2", "-2--1" or "1.5".
+// For reference see: https://wiki.openstreetmap.org/wiki/Key:level
+func Parse(value string) ([]float64, error) {
+	var out []float64
+
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		from, to, err := parseRange(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level %q: %w", value, err)
+		}
+
+		if math.IsNaN(from) || math.IsInf(from, 0) || math.IsNaN(to) || math.IsInf(to, 0) {
+			return nil, fmt.Errorf("invalid level %q: not finite", value)
+		}
+
+		if to-from > MaxRange {
+			return nil, fmt.Errorf("invalid level %q: range exceeds %d levels", value, MaxRange)
+		}
+
+		out = append(out, from)
+		if from == math.Trunc(from) && to == math.Trunc(to) {
+			for level := from + 1; level < to; level++ {
+				out = append(out, level)
+			}
+		}
+		if to != from {
+			out = append(out, to)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("invalid level %q: empty", value)
+	}
+
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// Contains reports whether the level tag value covers level, invalid values never contain a level.
+func Contains(value string, level float64) bool {
+	levels, err := Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(levels, level)
+}
+
+// Format formats a level like it is written in level tags, e.g. 1 or 1.5
+func Format(level float64) string {
+	return strconv.FormatFloat(level, 'f', -1, 64)
+}
+
+// parseRange parses a single level or a range of levels, the separator of a range is the first '-' after a number
+func parseRange(part string) (float64, float64, error) {
+	for i := 1; i < len(part); i++ {
+		if part[i] != '-' || part[i-1] == '-' {
+			continue
+		}
+
+		from, err := strconv.ParseFloat(strings.TrimSpace(part[:i]), 64)
+		if err != nil {
+			continue
+		}
+
+		to, err := strconv.ParseFloat(strings.TrimSpace(part[i+1:]), 64)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return min(from, to), max(from, to), nil
+	}
+
+	level, err := strconv.ParseFloat(part, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return level, level, nil
+}
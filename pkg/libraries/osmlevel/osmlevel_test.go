@@ -0,0 +1,47 @@
+package osmlevel_test
+
+import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string][]float64{
+		"0":      {0},
+		"-1":     {-1},
+		"1.5":    {1.5},
+		"-1;0":   {-1, 0},
+		"0-2":    {0, 1, 2},
+		"-2--1":  {-2, -1},
+		"-1-1":   {-1, 0, 1},
+		"2;0-1":  {0, 1, 2},
+		"0.5-1":  {0.5, 1},
+		" 3 ; 3": {3},
+		"-100-100": func() []float64 {
+			var out []float64
+			for level := -100; level <= 100; level++ {
+				out = append(out, float64(level))
+			}
+			return out
+		}(),
+	}
+
+	for value, expected := range tests {
+		levels, err := osmlevel.Parse(value)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", value, err)
+			continue
+		}
+
+		if !slices.Equal(levels, expected) {
+			t.Errorf("Parse(%q) = %v, expected %v", value, levels, expected)
+		}
+	}
+
+	for _, value := range []string{"", "ground", "1-", ";", "0-1000000000", "-101-100", "NaN", "0-Inf"} {
+		if _, err := osmlevel.Parse(value); err == nil {
+			t.Errorf("Parse(%q) should fail", value)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package osmpoly
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPoly = errors.New("invalid poly file")
+
+// Parse reads a polygon in the Osmosis polygon filter file format, see
+// https://wiki.openstreetmap.org/wiki/Osmosis/Polygon_Filter_File_Format.
+// Sections starting with ! are holes and are cut out of the polygon containing them.
+func Parse(r io.Reader) (orb.MultiPolygon, error) {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	next := func() (string, bool) {
+		for scanner.Scan() {
+			line++
+			if text := strings.TrimSpace(scanner.Text()); text != "" {
+				return text, true
+			}
+		}
+		return "", false
+	}
+
+	// the first line is the name of the polygon
+	if _, ok := next(); !ok {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidPoly)
+	}
+
+	var out orb.MultiPolygon
+	var holes []orb.Ring
+	for {
+		section, ok := next()
+		if !ok {
+			return nil, fmt.Errorf("%w: missing END of file", ErrInvalidPoly)
+		}
+		if section == "END" {
+			break
+		}
+
+		ring, err := parseRing(next)
+		if err != nil {
+			return nil, fmt.Errorf("%w: section %s at line %d: %w", ErrInvalidPoly, section, line, err)
+		}
+
+		if strings.HasPrefix(section, "!") {
+			holes = append(holes, ring)
+		} else {
+			out = append(out, orb.Polygon{ring})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read poly file: %w", err)
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: no polygon", ErrInvalidPoly)
+	}
+
+	for _, hole := range holes {
+		found := false
+		for i, polygon := range out {
+			if planar.RingContains(polygon[0], hole[0]) {
+				out[i] = append(out[i], hole)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: hole at %v is not within a polygon", ErrInvalidPoly, hole[0])
+		}
+	}
+
+	return out, nil
+}
+
+// parseRing reads the coordinates of a section up to its END, the ring is closed if it is not
+func parseRing(next func() (string, bool)) (orb.Ring, error) {
+	var ring orb.Ring
+	for {
+		text, ok := next()
+		if !ok {
+			return nil, errors.New("missing END of section")
+		}
+		if text == "END" {
+			break
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected lon lat, got %q", text)
+		}
+
+		lon, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lon %q: %w", fields[0], err)
+		}
+
+		lat, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lat %q: %w", fields[1], err)
+		}
+
+		// comparisons with NaN are false, so they fail the range check as well
+		if !(lon >= -180 && lon <= 180 && lat >= -90 && lat <= 90) {
+			return nil, fmt.Errorf("coordinate %q out of range", text)
+		}
+
+		ring = append(ring, orb.Point{lon, lat})
+	}
+
+	if len(ring) < 3 {
+		return nil, fmt.Errorf("expected at least 3 points, got %d", len(ring))
+	}
+
+	if !ring.Closed() {
+		ring = append(ring, ring[0])
+	}
+
+	return ring, nil
+}
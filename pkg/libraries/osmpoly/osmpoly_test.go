@@ -0,0 +1,77 @@
+package osmpoly_test
+
+import (
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmpoly"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"strings"
+	"testing"
+)
+
+const testPoly = `campus
+1
+   11.0   48.0
+   11.1   48.0
+   11.1   48.1
+   11.0   48.1
+END
+!1
+   11.04  48.04
+   11.06  48.04
+   11.06  48.06
+   11.04  48.06
+END
+2
+   12.0E+00  48.0
+   12.1      48.0
+   12.1      48.1
+END
+END
+`
+
+func TestParse(t *testing.T) {
+	polygons, err := osmpoly.Parse(strings.NewReader(testPoly))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(polygons) != 2 || len(polygons[0]) != 2 || len(polygons[1]) != 1 {
+		t.Fatalf("expected a polygon with a hole and a triangle, got %v", polygons)
+	}
+
+	for _, ring := range []orb.Ring{polygons[0][0], polygons[0][1], polygons[1][0]} {
+		if !ring.Closed() {
+			t.Errorf("ring %v is not closed", ring)
+		}
+	}
+
+	tests := map[orb.Point]bool{
+		{11.01, 48.01}: true,
+		{11.05, 48.05}: false,
+		{12.09, 48.01}: true,
+		{11.5, 48.05}:  false,
+	}
+	for point, want := range tests {
+		if got := planar.MultiPolygonContains(polygons, point); got != want {
+			t.Errorf("expected contains %v to be %v", point, want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for name, poly := range map[string]string{
+		"empty":        "",
+		"missing end":  "campus\n1\n 11.0 48.0\n 11.1 48.0\n 11.1 48.1\nEND\n",
+		"invalid lon":  "campus\n1\n x 48.0\n 11.1 48.0\n 11.1 48.1\nEND\nEND\n",
+		"nan lat":      "campus\n1\n 11.0 NaN\n 11.1 48.0\n 11.1 48.1\nEND\nEND\n",
+		"out of range": "campus\n1\n 11.0 48.0\n 191.1 48.0\n 11.1 48.1\nEND\nEND\n",
+		"only hole":    "campus\n!1\n 11.0 48.0\n 11.1 48.0\n 11.1 48.1\nEND\nEND\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := osmpoly.Parse(strings.NewReader(poly)); !errors.Is(err, osmpoly.ErrInvalidPoly) {
+				t.Errorf("expected ErrInvalidPoly, got %v", err)
+			}
+		})
+	}
+}
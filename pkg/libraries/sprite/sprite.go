@@ -0,0 +1,149 @@
+package sprite
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"math"
+	"path"
+	"slices"
+	"strings"
+)
+
+// padding between the icons of a sheet in pixels, prevents bleeding when sampling the sheet
+const padding = 2
+
+// IndexEntry describes the position of a single icon in a sprite sheet.
+// For reference see: https://maplibre.org/maplibre-style-spec/sprite/#index-file
+type IndexEntry struct {
+	Width      int `json:"width"`
+	Height     int `json:"height"`
+	X          int `json:"x"`
+	Y          int `json:"y"`
+	PixelRatio int `json:"pixelRatio"`
+}
+
+// Sheet is a packed sprite sheet and the index describing it.
+type Sheet struct {
+	Index map[string]IndexEntry
+	Image *image.RGBA
+}
+
+// PNG encodes the sheet image.
+func (s *Sheet) PNG() ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, s.Image); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Build rasterizes every *.svg file in fsys at the given pixel ratio and packs them into a single sheet.
+// The icons are named after their file name without extension, the viewBox of an icon is its size at pixel ratio 1.
+func Build(fsys fs.FS, pixelRatio int) (*Sheet, error) {
+	paths, err := fs.Glob(fsys, "*.svg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list icons: %w", err)
+	}
+
+	icons := make(map[string]image.Image, len(paths))
+	for _, iconPath := range paths {
+		img, err := rasterize(fsys, iconPath, pixelRatio)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rasterize icon %s: %w", iconPath, err)
+		}
+
+		icons[strings.TrimSuffix(path.Base(iconPath), ".svg")] = img
+	}
+
+	return Pack(icons, pixelRatio), nil
+}
+
+func rasterize(fsys fs.FS, iconPath string, pixelRatio int) (image.Image, error) {
+	file, err := fsys.Open(iconPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	icon, err := oksvg.ReadIconStream(file)
+	if err != nil {
+		return nil, err
+	}
+
+	width := int(math.Ceil(icon.ViewBox.W)) * pixelRatio
+	height := int(math.Ceil(icon.ViewBox.H)) * pixelRatio
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("icon has an empty viewBox")
+	}
+
+	icon.SetTarget(0, 0, float64(width), float64(height))
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1.0)
+
+	return img, nil
+}
+
+// Pack places the icons on a sheet using a shelf packing algorithm.
+// Icons are sorted by height, so every shelf is filled with icons of similar height.
+func Pack(icons map[string]image.Image, pixelRatio int) *Sheet {
+	names := make([]string, 0, len(icons))
+	area, maxWidth := 0, 0
+	for name, icon := range icons {
+		names = append(names, name)
+		size := icon.Bounds().Size()
+		area += (size.X + padding) * (size.Y + padding)
+		maxWidth = max(maxWidth, size.X+padding)
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		if diff := icons[b].Bounds().Dy() - icons[a].Bounds().Dy(); diff != 0 {
+			return diff
+		}
+		return strings.Compare(a, b)
+	})
+
+	sheetWidth := max(maxWidth, int(math.Ceil(math.Sqrt(float64(area)))))
+
+	index := make(map[string]IndexEntry, len(names))
+	x, y, shelfHeight, sheetHeight := 0, 0, 0, 0
+	for _, name := range names {
+		size := icons[name].Bounds().Size()
+
+		if x+size.X+padding > sheetWidth {
+			x = 0
+			y += shelfHeight
+			shelfHeight = 0
+		}
+
+		index[name] = IndexEntry{
+			Width:      size.X,
+			Height:     size.Y,
+			X:          x,
+			Y:          y,
+			PixelRatio: pixelRatio,
+		}
+
+		x += size.X + padding
+		shelfHeight = max(shelfHeight, size.Y+padding)
+		sheetHeight = max(sheetHeight, y+shelfHeight)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, max(sheetWidth, 1), max(sheetHeight, 1)))
+	for name, entry := range index {
+		icon := icons[name]
+		draw.Draw(img, image.Rect(entry.X, entry.Y, entry.X+entry.Width, entry.Y+entry.Height), icon, icon.Bounds().Min, draw.Src)
+	}
+
+	return &Sheet{
+		Index: index,
+		Image: img,
+	}
+}
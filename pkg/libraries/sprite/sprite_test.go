@@ -0,0 +1,61 @@
+package sprite_test
+
+import (
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/sprite"
+	"image"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPack(t *testing.T) {
+	icons := make(map[string]image.Image)
+	for i := 1; i <= 20; i++ {
+		icons[fmt.Sprintf("icon-%d", i)] = image.NewRGBA(image.Rect(0, 0, 4+i%7, 4+i%5))
+	}
+
+	sheet := sprite.Pack(icons, 1)
+
+	if len(sheet.Index) != len(icons) {
+		t.Fatalf("expected %d entries, got %d", len(icons), len(sheet.Index))
+	}
+
+	rects := make(map[string]image.Rectangle)
+	for name, entry := range sheet.Index {
+		rect := image.Rect(entry.X, entry.Y, entry.X+entry.Width, entry.Y+entry.Height)
+		if !rect.In(sheet.Image.Bounds()) {
+			t.Errorf("icon %s at %v is outside of the sheet %v", name, rect, sheet.Image.Bounds())
+		}
+
+		for other, otherRect := range rects {
+			if rect.Overlaps(otherRect) {
+				t.Errorf("icon %s overlaps %s", name, other)
+			}
+		}
+		rects[name] = rect
+	}
+}
+
+func TestBuild(t *testing.T) {
+	fsys := fstest.MapFS{
+		"square.svg": {Data: []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 12 10"><rect width="12" height="10" fill="#000"/></svg>`)},
+	}
+
+	sheet, err := sprite.Build(fsys, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := sheet.Index["square"]
+	if !ok {
+		t.Fatal("icon square is missing")
+	}
+
+	if entry.Width != 24 || entry.Height != 20 || entry.PixelRatio != 2 {
+		t.Errorf("unexpected index entry %+v", entry)
+	}
+
+	if _, _, _, a := sheet.Image.At(entry.X+12, entry.Y+10).RGBA(); a == 0 {
+		t.Error("icon was not drawn onto the sheet")
+	}
+}
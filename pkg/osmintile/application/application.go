@@ -2,37 +2,207 @@ package application
 
 import (
 	"context"
+	"errors"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
 	"github.com/paulmach/orb/maptile"
+	"github.com/paulmach/osm"
 )
 
+var ErrDevModeDisabled = errors.New("dev mode is disabled")
+
 type Application interface {
-	GetMapStyle(ctx context.Context) (entities.MapStyle, error)
-	GetTile(ctx context.Context, level int, x, y, z uint32, acceptGzip bool) ([]byte, error)
+	GetMapStyle(ctx context.Context, params entities.MapStyleParams) (entities.MapStyle, error)
+	GetTile(ctx context.Context, level int, building osm.FeatureID, x, y, z uint32, acceptGzip bool) ([]byte, error)
+	GetTileJSON(ctx context.Context, level int) (entities.TileJSON, error)
+	GetSprite(ctx context.Context, pixelRatio int) (entities.Sprite, error)
+	GetGlyphs(ctx context.Context, fontstack string, glyphRange string) ([]byte, error)
+	GetBuildings(ctx context.Context, bound orb.Bound) ([]entities.Building, error)
+	GetBuilding(ctx context.Context, id osm.FeatureID) (entities.Building, error)
+	GetLevels(ctx context.Context, bound orb.Bound) ([]entities.Level, error)
+	GetBuildingLevels(ctx context.Context, buildingID osm.FeatureID) ([]entities.Level, error)
+	GetFeature(ctx context.Context, id osm.FeatureID) (*geojson.Feature, error)
+	Locate(ctx context.Context, point orb.Point, level *float64, radius float64) (entities.Location, error)
+	Search(ctx context.Context, query string, bound orb.Bound, level *float64, limit int) (*geojson.FeatureCollection, error)
+	Route(ctx context.Context, from, to entities.Waypoint, profile string, language string) (entities.Route, error)
+	GetRoutingGraph(ctx context.Context, bound orb.Bound, level *float64) (*geojson.FeatureCollection, error)
+	GetRoomGraph(ctx context.Context, buildingID osm.FeatureID, level *float64) (entities.RoomGraph, error)
+	EvacuationRoute(ctx context.Context, from entities.Waypoint, language string) (entities.Route, error)
+	GetEvacuationPlan(ctx context.Context, level float64, bound orb.Bound) (*geojson.FeatureCollection, error)
+	Reachability(ctx context.Context, from entities.Waypoint, minutes []float64, profile string, resolution float64) (*geojson.FeatureCollection, error)
+	Matrix(ctx context.Context, origins, destinations []entities.Waypoint, profile string) (entities.Matrix, error)
+	Validate(ctx context.Context) (entities.ValidationReport, error)
+	GetValidationGeoJSON(ctx context.Context) (*geojson.FeatureCollection, error)
+	GetValidationTile(ctx context.Context, x, y, z uint32, acceptGzip bool) ([]byte, error)
+	GetGeometryRepairs(ctx context.Context) ([]entities.GeometryRepair, error)
+	GetImportStatus() entities.ImportStatus
+	SubscribeDevReload(ctx context.Context) (<-chan struct{}, error)
 }
 
 type application struct {
-	styleService service.MapStyleService
-	tilesService service.MapTilesService
+	styleService      service.MapStyleService
+	tilesService      service.MapTilesService
+	tileJSONService   service.TileJSONService
+	spriteService     service.SpriteService
+	glyphService      service.GlyphService
+	buildingService   service.BuildingService
+	levelService      service.LevelService
+	featureService    service.FeatureService
+	locationService   service.LocationService
+	searchService     service.SearchService
+	routingService    service.RoutingService
+	validationService service.ValidationService
+	importService     service.ImportService
+	devReloadService  service.DevReloadService
 }
 
-func New(styleService service.MapStyleService, tilesService service.MapTilesService) Application {
+// New creates the Application, devReloadService may be nil if dev mode is disabled.
+func New(
+	styleService service.MapStyleService,
+	tilesService service.MapTilesService,
+	tileJSONService service.TileJSONService,
+	spriteService service.SpriteService,
+	glyphService service.GlyphService,
+	buildingService service.BuildingService,
+	levelService service.LevelService,
+	featureService service.FeatureService,
+	locationService service.LocationService,
+	searchService service.SearchService,
+	routingService service.RoutingService,
+	validationService service.ValidationService,
+	importService service.ImportService,
+	devReloadService service.DevReloadService,
+) Application {
 	return &application{
-		styleService: styleService,
-		tilesService: tilesService,
+		styleService:      styleService,
+		tilesService:      tilesService,
+		tileJSONService:   tileJSONService,
+		spriteService:     spriteService,
+		glyphService:      glyphService,
+		buildingService:   buildingService,
+		levelService:      levelService,
+		featureService:    featureService,
+		locationService:   locationService,
+		searchService:     searchService,
+		routingService:    routingService,
+		validationService: validationService,
+		importService:     importService,
+		devReloadService:  devReloadService,
+	}
+}
+
+func (app *application) GetMapStyle(ctx context.Context, params entities.MapStyleParams) (entities.MapStyle, error) {
+	return app.styleService.GetMapStyle(ctx, params)
+}
+
+func (app *application) GetTile(ctx context.Context, level int, building osm.FeatureID, x, y, z uint32, acceptGzip bool) ([]byte, error) {
+	tile := maptile.Tile{
+		X: x,
+		Y: y,
+		Z: maptile.Zoom(z),
 	}
+	return app.tilesService.GetMapTile(ctx, level, building, tile, acceptGzip)
+}
+
+func (app *application) GetTileJSON(ctx context.Context, level int) (entities.TileJSON, error) {
+	return app.tileJSONService.GetTileJSON(ctx, level)
+}
+
+func (app *application) GetSprite(ctx context.Context, pixelRatio int) (entities.Sprite, error) {
+	return app.spriteService.GetSprite(ctx, pixelRatio)
+}
+
+func (app *application) GetGlyphs(ctx context.Context, fontstack string, glyphRange string) ([]byte, error) {
+	return app.glyphService.GetGlyphs(ctx, fontstack, glyphRange)
+}
+
+func (app *application) GetBuildings(ctx context.Context, bound orb.Bound) ([]entities.Building, error) {
+	return app.buildingService.GetBuildings(ctx, bound)
+}
+
+func (app *application) GetBuilding(ctx context.Context, id osm.FeatureID) (entities.Building, error) {
+	return app.buildingService.GetBuilding(ctx, id)
+}
+
+func (app *application) GetLevels(ctx context.Context, bound orb.Bound) ([]entities.Level, error) {
+	return app.levelService.GetLevels(ctx, bound)
+}
+
+func (app *application) GetBuildingLevels(ctx context.Context, buildingID osm.FeatureID) ([]entities.Level, error) {
+	return app.levelService.GetBuildingLevels(ctx, buildingID)
+}
+
+func (app *application) GetFeature(ctx context.Context, id osm.FeatureID) (*geojson.Feature, error) {
+	return app.featureService.GetFeature(ctx, id)
+}
+
+func (app *application) Locate(ctx context.Context, point orb.Point, level *float64, radius float64) (entities.Location, error) {
+	return app.locationService.Locate(ctx, point, level, radius)
+}
+
+func (app *application) Search(ctx context.Context, query string, bound orb.Bound, level *float64, limit int) (*geojson.FeatureCollection, error) {
+	return app.searchService.Search(ctx, query, bound, level, limit)
+}
+
+func (app *application) Route(ctx context.Context, from, to entities.Waypoint, profile string, language string) (entities.Route, error) {
+	return app.routingService.Route(ctx, from, to, profile, language)
+}
+
+func (app *application) GetRoutingGraph(ctx context.Context, bound orb.Bound, level *float64) (*geojson.FeatureCollection, error) {
+	return app.routingService.GetGraph(ctx, bound, level)
 }
 
-func (app *application) GetMapStyle(ctx context.Context) (entities.MapStyle, error) {
-	return app.styleService.GetMapStyle(ctx)
+func (app *application) GetRoomGraph(ctx context.Context, buildingID osm.FeatureID, level *float64) (entities.RoomGraph, error) {
+	return app.routingService.GetRoomGraph(ctx, buildingID, level)
 }
 
-func (app *application) GetTile(ctx context.Context, level int, x, y, z uint32, acceptGzip bool) ([]byte, error) {
+func (app *application) EvacuationRoute(ctx context.Context, from entities.Waypoint, language string) (entities.Route, error) {
+	return app.routingService.EvacuationRoute(ctx, from, language)
+}
+
+func (app *application) GetEvacuationPlan(ctx context.Context, level float64, bound orb.Bound) (*geojson.FeatureCollection, error) {
+	return app.routingService.GetEvacuationPlan(ctx, level, bound)
+}
+
+func (app *application) Reachability(ctx context.Context, from entities.Waypoint, minutes []float64, profile string, resolution float64) (*geojson.FeatureCollection, error) {
+	return app.routingService.Reachability(ctx, from, minutes, profile, resolution)
+}
+
+func (app *application) Matrix(ctx context.Context, origins, destinations []entities.Waypoint, profile string) (entities.Matrix, error) {
+	return app.routingService.Matrix(ctx, origins, destinations, profile)
+}
+
+func (app *application) Validate(ctx context.Context) (entities.ValidationReport, error) {
+	return app.validationService.Validate(ctx)
+}
+
+func (app *application) GetValidationGeoJSON(ctx context.Context) (*geojson.FeatureCollection, error) {
+	return app.validationService.GetValidationGeoJSON(ctx)
+}
+
+func (app *application) GetValidationTile(ctx context.Context, x, y, z uint32, acceptGzip bool) ([]byte, error) {
 	tile := maptile.Tile{
 		X: x,
 		Y: y,
 		Z: maptile.Zoom(z),
 	}
-	return app.tilesService.GetMapTile(ctx, level, tile, acceptGzip)
+	return app.validationService.GetValidationTile(ctx, tile, acceptGzip)
+}
+
+func (app *application) GetGeometryRepairs(ctx context.Context) ([]entities.GeometryRepair, error) {
+	return app.validationService.GetGeometryRepairs(ctx)
+}
+
+func (app *application) GetImportStatus() entities.ImportStatus {
+	return app.importService.GetStatus()
+}
+
+func (app *application) SubscribeDevReload(ctx context.Context) (<-chan struct{}, error) {
+	if app.devReloadService == nil {
+		return nil, ErrDevModeDisabled
+	}
+
+	return app.devReloadService.Subscribe(ctx), nil
 }
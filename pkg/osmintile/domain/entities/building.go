@@ -0,0 +1,14 @@
+package entities
+
+import "github.com/paulmach/orb/geojson"
+
+// Building is a building=* outline together with the levels of the indoor features inside it.
+type Building struct {
+	// ID is the short form of the id of the outline, e.g. w123
+	ID     string     `json:"id"`
+	Name   string     `json:"name,omitempty"`
+	Levels []float64  `json:"levels"`
+	Bounds [4]float64 `json:"bounds"`
+	// Outline is only set when querying a single building
+	Outline *geojson.Geometry `json:"outline,omitempty"`
+}
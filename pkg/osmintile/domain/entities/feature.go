@@ -0,0 +1,27 @@
+package entities
+
+import "github.com/paulmach/orb/geojson"
+
+// RelationMember is a member of a relation as stored in the database.
+type RelationMember struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+	Role string `json:"role"`
+}
+
+// FeatureRef references a feature containing another one, e.g. its building or room.
+type FeatureRef struct {
+	// ID is the short form of the id, e.g. w123
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Ref  string `json:"ref,omitempty"`
+}
+
+// Location describes what can be found at a point.
+type Location struct {
+	Building *FeatureRef `json:"building"`
+	// Areas are the rooms, areas and corridors containing the point, innermost first
+	Areas *geojson.FeatureCollection `json:"areas"`
+	// Pois are the pois around the point, nearest first
+	Pois *geojson.FeatureCollection `json:"pois"`
+}
@@ -0,0 +1,37 @@
+package entities
+
+import "time"
+
+const (
+	ImportStateIdle    = "idle"
+	ImportStateRunning = "running"
+	ImportStateDone    = "done"
+	ImportStateFailed  = "failed"
+)
+
+// ImportStatus is the state of the import of the osm file, Progress is the last progress of a started import.
+type ImportStatus struct {
+	// State is one of idle, running, done or failed
+	State      string          `json:"state"`
+	File       string          `json:"file,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Progress   *ImportProgress `json:"progress,omitempty"`
+}
+
+// ImportProgress is the progress of an import, the passes scan the osm file except for the final index pass.
+type ImportProgress struct {
+	Pass string `json:"pass"`
+	// PassIndex is the 1-based index of the pass out of PassCount
+	PassIndex      int   `json:"pass_index"`
+	PassCount      int   `json:"pass_count"`
+	BytesRead      int64 `json:"bytes_read"`
+	FileSize       int64 `json:"file_size"`
+	ObjectsScanned int   `json:"objects_scanned"`
+	// ObjectsKept is the number of objects selected by the filter passes, or inserted by the insert pass
+	ObjectsKept    int     `json:"objects_kept"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	// ETASeconds estimates the remaining time of the scanning passes from the bytes read, it is omitted while unknown
+	ETASeconds *float64 `json:"eta_seconds,omitempty"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import "github.com/paulmach/orb"
+
+// LevelTag is the level tag of a single imported feature.
+type LevelTag struct {
+	// Level is the raw value of the level tag, e.g. 0;1
+	Level string
+	// LevelRef is the raw value of the level:ref tag
+	LevelRef string
+	Bound    orb.Bound
+}
+
+// Level summarizes the features present on a single level.
+type Level struct {
+	Level        float64    `json:"level"`
+	Ref          string     `json:"ref,omitempty"`
+	FeatureCount int        `json:"feature_count"`
+	Bounds       [4]float64 `json:"bounds"`
+}
@@ -1,6 +1,34 @@
 package entities
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"github.com/paulmach/osm"
+)
 
 // MapStyle for reference see: https://docs.mapbox.com/style-spec/reference/root
 type MapStyle json.RawMessage
+
+// MapStyleParams parametrize the rendering of a MapStyle.
+type MapStyleParams struct {
+	// Level is the building level the tiles are requested for
+	Level int
+	// Theme is the name of the color palette in styles/themes
+	Theme string
+	// Language is preferred for labels via the name:<Language> tag, empty uses the name tag only
+	Language string
+	// Building restricts the tiles to the features of a single building, the zero id shows all buildings
+	Building osm.FeatureID
+}
+
+const (
+	DefaultMapStyleLevel = -1
+	DefaultMapStyleTheme = "default"
+)
+
+// DefaultMapStyleParams returns the params used if the client does not request anything else.
+func DefaultMapStyleParams() MapStyleParams {
+	return MapStyleParams{
+		Level: DefaultMapStyleLevel,
+		Theme: DefaultMapStyleTheme,
+	}
+}
@@ -0,0 +1,36 @@
+package entities
+
+// RoomGraph is the adjacency graph of the walkable areas of a building, connected by shared doors and openings.
+type RoomGraph struct {
+	Building    string           `json:"building"`
+	Level       *float64         `json:"level,omitempty"`
+	Rooms       []RoomGraphRoom  `json:"rooms"`
+	Connections []RoomConnection `json:"connections"`
+}
+
+// RoomGraphRoom is a room, area or corridor on a single level.
+type RoomGraphRoom struct {
+	// ID identifies the room on its level, e.g. w123@1
+	ID string `json:"id"`
+	// Feature is the short id of the room, e.g. w123
+	Feature string  `json:"feature"`
+	Level   float64 `json:"level"`
+	Indoor  string  `json:"indoor"`
+	Name    string  `json:"name,omitempty"`
+	Ref     string  `json:"ref,omitempty"`
+	Doors   int     `json:"doors"`
+	// NoDoor flags rooms which are not connected to any other room by a door
+	NoDoor bool `json:"no_door"`
+}
+
+// RoomConnection connects two rooms through a door or an opening, e.g. a footway crossing their shared wall.
+type RoomConnection struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Kind is either door or opening
+	Kind string `json:"kind"`
+	// Via is the short id of the door or of the feature the opening is derived from
+	Via   string     `json:"via"`
+	Name  string     `json:"name,omitempty"`
+	Point [2]float64 `json:"point"`
+}
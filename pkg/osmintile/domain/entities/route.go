@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/osm"
+)
+
+// Waypoint is where a route starts or ends, either a point on a level or a feature like a room or door.
+type Waypoint struct {
+	Point orb.Point
+	Level float64
+	// Feature is used instead of Point and Level if it is not zero
+	Feature osm.FeatureID
+}
+
+// Route is the fastest path between two waypoints.
+type Route struct {
+	Profile string `json:"profile"`
+	// Distance is the walked distance in meters
+	Distance float64 `json:"distance"`
+	// Duration is the estimated time in seconds
+	Duration float64 `json:"duration"`
+	// Segments are the parts of the route on a single level as line strings, in the order they are walked
+	Segments     *geojson.FeatureCollection `json:"segments"`
+	LevelChanges []LevelChange              `json:"level_changes"`
+	Instructions []Instruction              `json:"instructions"`
+}
+
+// LevelChange is a step of a route using stairs, an escalator or an elevator.
+type LevelChange struct {
+	Kind      string     `json:"kind"`
+	FromLevel float64    `json:"from_level"`
+	ToLevel   float64    `json:"to_level"`
+	Point     [2]float64 `json:"point"`
+	// Feature is the short id of the elevator, stairs or escalator
+	Feature string `json:"feature,omitempty"`
+	// Segment is the index of the segment the level change follows, 0 if the route starts with the level change
+	Segment  int     `json:"segment"`
+	Duration float64 `json:"duration"`
+}
+
+// Instruction is a step of the turn-by-turn directions of a route.
+type Instruction struct {
+	// Type is one of depart, turn, continue, door, level_change or arrive
+	Type  string     `json:"type"`
+	Text  string     `json:"text"`
+	Point [2]float64 `json:"point"`
+	Level float64    `json:"level"`
+	// Distance is the distance in meters walked until the next instruction
+	Distance float64 `json:"distance"`
+}
+
+// Matrix contains the walking distances and durations from every origin to every destination.
+// Entries are nil if there is no route, rows are the origins and columns the destinations.
+type Matrix struct {
+	Profile string `json:"profile"`
+	// Distances are in meters
+	Distances [][]*float64 `json:"distances"`
+	// Durations are in seconds
+	Durations [][]*float64 `json:"durations"`
+}
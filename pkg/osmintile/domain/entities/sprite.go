@@ -0,0 +1,9 @@
+package entities
+
+import "encoding/json"
+
+// Sprite for reference see: https://maplibre.org/maplibre-style-spec/sprite/
+type Sprite struct {
+	Index json.RawMessage
+	Image []byte
+}
@@ -0,0 +1,24 @@
+package entities
+
+// TileJSON for reference see: https://github.com/mapbox/tilejson-spec/tree/master/3.0.0
+type TileJSON struct {
+	TileJSON     string                `json:"tilejson"`
+	Name         string                `json:"name,omitempty"`
+	Description  string                `json:"description,omitempty"`
+	Attribution  string                `json:"attribution,omitempty"`
+	Scheme       string                `json:"scheme"`
+	Tiles        []string              `json:"tiles"`
+	MinZoom      int                   `json:"minzoom"`
+	MaxZoom      int                   `json:"maxzoom"`
+	Bounds       [4]float64            `json:"bounds"`
+	Center       [3]float64            `json:"center"`
+	VectorLayers []TileJSONVectorLayer `json:"vector_layers"`
+}
+
+type TileJSONVectorLayer struct {
+	ID          string            `json:"id"`
+	Fields      map[string]string `json:"fields"`
+	Description string            `json:"description,omitempty"`
+	MinZoom     int               `json:"minzoom"`
+	MaxZoom     int               `json:"maxzoom"`
+}
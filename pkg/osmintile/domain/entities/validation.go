@@ -0,0 +1,28 @@
+package entities
+
+import "github.com/paulmach/orb/geojson"
+
+// ValidationReport lists the problems found in the indoor data.
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+	// Counts is the number of issues per type
+	Counts map[string]int `json:"counts"`
+}
+
+// ValidationIssue is a problem of a feature, e.g. an unclosed room or a door not on a room boundary.
+type ValidationIssue struct {
+	Type string `json:"type"`
+	// Feature is the short id of the feature, e.g. w123
+	Feature string `json:"feature"`
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message"`
+	// Location is where the problem is, it is omitted if it can not be located, e.g. for missing member ways
+	Location *geojson.Geometry `json:"location,omitempty"`
+}
+
+// GeometryRepair lists the repairs applied at import to the polygons of a feature, e.g. closed_ring or spike.
+type GeometryRepair struct {
+	// Feature is the short id of the feature, e.g. w123
+	Feature string   `json:"feature"`
+	Repairs []string `json:"repairs"`
+}
@@ -0,0 +1,30 @@
+package entities
+
+import "strings"
+
+// VectorLayer describes a layer of the vector tiles and the attributes its features carry.
+type VectorLayer struct {
+	ID          string
+	Description string
+	MinZoom     int
+	MaxZoom     int
+	// Fields maps attribute names to their type, e.g. String or Number
+	Fields map[string]string
+	// FieldPrefixes are prefixes of attributes with dynamic names, e.g. name: for name:de
+	FieldPrefixes []string
+}
+
+// HasField reports whether features of the layer may carry the attribute.
+func (v VectorLayer) HasField(name string) bool {
+	if _, ok := v.Fields[name]; ok {
+		return true
+	}
+
+	for _, prefix := range v.FieldPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,13 +2,57 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/indoorvalidation"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/osm"
 )
 
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrSearchDisabled is returned by Search if the database does not support full text search
+	ErrSearchDisabled = errors.New("search is disabled")
+)
+
+// ImportOptions configures an import, the zero value imports all matching features of the file.
+type ImportOptions struct {
+	// Boundary keeps only the features intersecting it, together with the complete ways and relations they belong to
+	Boundary orb.MultiPolygon
+	// Progress is called when a pass starts or finishes and about once per second in between, it may be nil
+	Progress func(progress entities.ImportProgress)
+}
+
+// OsmDataRepository gives access to the imported osm data.
+// Queries taking a building only return features inside of it, the zero FeatureID disables the filter.
 type OsmDataRepository interface {
-	Import(ctx context.Context, path string) error
-	GetBase(ctx context.Context, level int, bound orb.Bound) (*geojson.FeatureCollection, error)
+	Import(ctx context.Context, path string, options ImportOptions) error
+	GetBase(ctx context.Context, level int, building osm.FeatureID, bound orb.Bound) (*geojson.FeatureCollection, error)
+	GetPois(ctx context.Context, level int, building osm.FeatureID, bound orb.Bound) (*geojson.FeatureCollection, error)
+	// GetPoisWithin returns the poi nodes within the bound on any level, the properties of the features are their tags.
+	GetPoisWithin(ctx context.Context, bound orb.Bound) ([]*geojson.Feature, error)
 	GetMapBounds(ctx context.Context) (orb.Bound, error)
 	GetMapCenter(ctx context.Context) (orb.Point, error)
+	GetLevelTags(ctx context.Context, bound orb.Bound) ([]entities.LevelTag, error)
+	GetFeatureGeometry(ctx context.Context, id osm.FeatureID) (orb.Geometry, error)
+	GetFeatureTags(ctx context.Context, id osm.FeatureID) (map[string]string, error)
+	GetRelationMembers(ctx context.Context, id osm.RelationID) ([]entities.RelationMember, error)
+	// GetIndoorAreasAt returns the indoor=room|area|corridor features containing the point on any level,
+	// the properties of the features are their tags.
+	GetIndoorAreasAt(ctx context.Context, point orb.Point) ([]*geojson.Feature, error)
+	// Search returns the features whose names, ref, shop or amenity match the query with prefixes, best match first.
+	// The properties of the features are name, ref, category, level and rank.
+	// Without a level, all levels are searched.
+	Search(ctx context.Context, query string, bound orb.Bound, level *float64, limit int) ([]*geojson.Feature, error)
+	GetBuildings(ctx context.Context, bound orb.Bound) ([]entities.Building, error)
+	GetBuilding(ctx context.Context, id osm.FeatureID) (entities.Building, error)
+	GetBuildingsAt(ctx context.Context, point orb.Point) ([]entities.Building, error)
+	GetNavGraph(ctx context.Context) (*navgraph.Graph, error)
+	// GetValidationData returns the indoor features and buildings with the nodes and member ways they reference,
+	// together with all doors.
+	GetValidationData(ctx context.Context) (indoorvalidation.Data, error)
+	// GetGeometryRepairs returns the features whose polygons were repaired at import, ordered by id.
+	GetGeometryRepairs(ctx context.Context) ([]entities.GeometryRepair, error)
 }
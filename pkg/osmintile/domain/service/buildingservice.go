@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+)
+
+var ErrBuildingNotFound = errors.New("building not found")
+
+type BuildingService interface {
+	GetBuildings(ctx context.Context, bound orb.Bound) ([]entities.Building, error)
+	GetBuilding(ctx context.Context, id osm.FeatureID) (entities.Building, error)
+}
+
+type buildingService struct {
+	dataRepository repository.OsmDataRepository
+}
+
+func NewBuildingService(dataRepository repository.OsmDataRepository) BuildingService {
+	return &buildingService{
+		dataRepository: dataRepository,
+	}
+}
+
+func (b *buildingService) GetBuildings(ctx context.Context, bound orb.Bound) ([]entities.Building, error) {
+	buildings, err := b.dataRepository.GetBuildings(ctx, bound)
+	if err != nil {
+		return nil, fmt.Errorf("error getting buildings: %w", err)
+	}
+
+	return buildings, nil
+}
+
+func (b *buildingService) GetBuilding(ctx context.Context, id osm.FeatureID) (entities.Building, error) {
+	building, err := b.dataRepository.GetBuilding(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return entities.Building{}, fmt.Errorf("%w: %s", ErrBuildingNotFound, id)
+	}
+	if err != nil {
+		return entities.Building{}, fmt.Errorf("error getting building: %w", err)
+	}
+
+	return building, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/broadcast"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/fswatch"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+)
+
+// DevReloadService watches the on-disk styles and static directories during development.
+type DevReloadService interface {
+	Watch(ctx context.Context) error
+	Subscribe(ctx context.Context) <-chan struct{}
+}
+
+type devReloadService struct {
+	styleService MapStyleService
+	stylesFS     fs.FS
+	staticFS     fs.FS
+	interval     time.Duration
+	broadcaster  *broadcast.Broadcaster
+}
+
+// NewDevReloadService creates a DevReloadService, stylesFS and staticFS may be nil if they should not be watched.
+func NewDevReloadService(styleService MapStyleService, stylesFS fs.FS, staticFS fs.FS, interval time.Duration) DevReloadService {
+	return &devReloadService{
+		styleService: styleService,
+		stylesFS:     stylesFS,
+		staticFS:     staticFS,
+		interval:     interval,
+		broadcaster:  broadcast.New(),
+	}
+}
+
+// Watch polls the watched directories until ctx is cancelled.
+// Style changes reload the style templates, every change notifies the subscribers.
+func (d *devReloadService) Watch(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+
+	if d.stylesFS != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[0] = fswatch.Poll(ctx, d.stylesFS, d.interval, func() {
+				log.Println("styles changed, reloading templates")
+				if err := d.styleService.ReloadTemplates(); err != nil {
+					log.Printf("reloaded templates have errors: %v", err)
+				}
+				d.broadcaster.Notify()
+			})
+		}()
+	}
+
+	if d.staticFS != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[1] = fswatch.Poll(ctx, d.staticFS, d.interval, func() {
+				log.Println("static files changed")
+				d.broadcaster.Notify()
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if errors.Is(err, context.Canceled) {
+			errs[i] = nil
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *devReloadService) Subscribe(ctx context.Context) <-chan struct{} {
+	return d.broadcaster.Subscribe(ctx)
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/geojson"
+)
+
+// minArrowLength is the length in meters a leg needs to get an evacuation arrow
+const minArrowLength = 2.0
+
+// EvacuationRoute returns the fastest route from the waypoint to the nearest emergency exit without using elevators
+func (r *routingService) EvacuationRoute(ctx context.Context, from entities.Waypoint, language string) (entities.Route, error) {
+	if !languageRegex.MatchString(language) {
+		return entities.Route{}, fmt.Errorf("%w: %q", ErrInvalidLanguage, language)
+	}
+
+	graph, tree, err := r.getEvacuationTree(ctx)
+	if err != nil {
+		return entities.Route{}, err
+	}
+
+	endpoint, err := waypointEndpoint(graph, from)
+	if err != nil {
+		return entities.Route{}, err
+	}
+
+	path, ok := tree.PathFrom(endpoint)
+	if !ok {
+		return entities.Route{}, fmt.Errorf("%w: no emergency exit can be reached from the waypoint", ErrRouteNotFound)
+	}
+
+	route := buildRoute(path, navgraph.Evacuation)
+	route.Instructions = buildInstructions(graph, path, language)
+
+	return route, nil
+}
+
+// GetEvacuationPlan returns the evacuation paths of all rooms on the level within the bound.
+// Every room is a point feature of type room with its exit, distance and duration, rooms from which no exit can be
+// reached are flagged as not reachable. The legs of the paths on the level are line features of type path and, if
+// they are long enough, get an arrow at their middle with the bearing towards the exit. Stairs and escalators
+// leaving the level are point features of type level_change.
+func (r *routingService) GetEvacuationPlan(ctx context.Context, level float64, bound orb.Bound) (*geojson.FeatureCollection, error) {
+	graph, tree, err := r.getEvacuationTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := geojson.NewFeatureCollection()
+	legs := make(map[[2]int64]bool)
+
+	addLeg := func(leg navgraph.Leg) {
+		key := [2]int64{leg.From.ID, leg.To.ID}
+		if leg.From.Kind == navgraph.NodeKindEndpoint || legs[key] {
+			return
+		}
+		legs[key] = true
+
+		if leg.From.Level != leg.To.Level {
+			feat := geojson.NewFeature(leg.From.Point)
+			feat.Properties["type"] = "level_change"
+			feat.Properties["kind"] = leg.Edge.Kind
+			feat.Properties["level"] = leg.From.Level
+			feat.Properties["to_level"] = leg.To.Level
+			feat.Properties["feature"] = leg.Edge.Feature
+			out.Append(feat)
+			return
+		}
+
+		remaining, _ := tree.Cost(leg.To.ID)
+
+		feat := geojson.NewFeature(orb.LineString{leg.From.Point, leg.To.Point})
+		feat.Properties["type"] = "path"
+		feat.Properties["level"] = leg.From.Level
+		feat.Properties["duration"] = remaining + leg.Edge.Cost
+		out.Append(feat)
+
+		if leg.Edge.Length >= minArrowLength {
+			arrow := geojson.NewFeature(geo.PointAtBearingAndDistance(leg.From.Point, geo.Bearing(leg.From.Point, leg.To.Point), leg.Edge.Length/2))
+			arrow.Properties["type"] = "arrow"
+			arrow.Properties["level"] = leg.From.Level
+			arrow.Properties["bearing"] = geo.Bearing(leg.From.Point, leg.To.Point)
+			out.Append(arrow)
+		}
+	}
+
+	for _, area := range graph.Areas {
+		if area.Level != level || area.Tags["indoor"] == "corridor" || !bound.Intersects(area.Geometry.Bound()) {
+			continue
+		}
+
+		anchor, ok := areaAnchor(graph, area)
+		if !ok {
+			continue
+		}
+
+		feat := geojson.NewFeature(anchor.Point)
+		feat.Properties["type"] = "room"
+		feat.Properties["feature"] = area.ID
+		feat.Properties["level"] = area.Level
+		if name := area.Tags["name"]; name != "" {
+			feat.Properties["name"] = name
+		}
+		if ref := area.Tags["ref"]; ref != "" {
+			feat.Properties["ref"] = ref
+		}
+
+		path, ok := tree.PathFrom(graph.NodeEndpoint(anchor.ID))
+		feat.Properties["reachable"] = ok
+		if ok && len(path.Legs) > 0 {
+			feat.Properties["exit"] = path.Legs[len(path.Legs)-1].To.Feature
+			feat.Properties["distance"] = path.Length
+			feat.Properties["duration"] = path.Cost
+		}
+		out.Append(feat)
+
+		for _, leg := range path.Legs {
+			if leg.From.Level == level {
+				addLeg(leg)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// getEvacuationTree returns the graph with the paths of all nodes to their nearest emergency exit
+func (r *routingService) getEvacuationTree(ctx context.Context) (*navgraph.Graph, *navgraph.TargetTree, error) {
+	graph, err := r.getGraph(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.graphMu.Lock()
+	defer r.graphMu.Unlock()
+
+	if r.evacuationTree != nil && r.graph == graph {
+		return graph, r.evacuationTree, nil
+	}
+
+	tree := graph.NearestTargets(isEmergencyExit, navgraph.Evacuation)
+	// the graph may have been invalidated in between, the paths of an outdated graph are not cached
+	if r.graph == graph {
+		r.evacuationTree = tree
+	}
+
+	return graph, tree, nil
+}
+
+// isEmergencyExit reports whether the node is a door tagged with entrance=emergency|exit or exit=*
+func isEmergencyExit(node navgraph.Node) bool {
+	if node.Kind != navgraph.NodeKindDoor {
+		return false
+	}
+
+	if exit, ok := node.Tags["exit"]; ok && exit != "no" {
+		return true
+	}
+
+	return node.Tags["entrance"] == "emergency" || node.Tags["entrance"] == "exit"
+}
+
+// areaAnchor returns the anchor node of the area on its level
+func areaAnchor(graph *navgraph.Graph, area navgraph.Area) (navgraph.Node, bool) {
+	for _, id := range area.Nodes {
+		node := graph.Nodes[id]
+		if node.Kind == navgraph.NodeKindAnchor && node.Feature == area.ID {
+			return node, true
+		}
+	}
+
+	return navgraph.Node{}, false
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/osm"
+	"slices"
+)
+
+var ErrFeatureNotFound = errors.New("feature not found")
+
+type FeatureService interface {
+	GetFeature(ctx context.Context, id osm.FeatureID) (*geojson.Feature, error)
+}
+
+type featureService struct {
+	dataRepository repository.OsmDataRepository
+}
+
+func NewFeatureService(dataRepository repository.OsmDataRepository) FeatureService {
+	return &featureService{
+		dataRepository: dataRepository,
+	}
+}
+
+// GetFeature returns the feature with all of its tags. The properties additionally contain the parsed levels,
+// the building and room containing the feature and, for relations, the members.
+func (f *featureService) GetFeature(ctx context.Context, id osm.FeatureID) (*geojson.Feature, error) {
+	geom, err := f.dataRepository.GetFeatureGeometry(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrFeatureNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting feature geometry: %w", err)
+	}
+
+	tags, err := f.dataRepository.GetFeatureTags(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting feature tags: %w", err)
+	}
+
+	levels, err := osmlevel.Parse(tags["level"])
+	if err != nil {
+		levels = nil
+	}
+
+	feat := geojson.NewFeature(geom)
+	feat.ID = osmid.Format(id)
+	feat.Properties["osm_type"] = id.Type()
+	feat.Properties["osm_id"] = id.Ref()
+	feat.Properties["tags"] = tags
+	feat.Properties["levels"] = levels
+
+	center, _ := planar.CentroidArea(geom)
+
+	building, err := f.getContainingBuilding(ctx, id, center)
+	if err != nil {
+		return nil, err
+	}
+	if building != nil {
+		feat.Properties["building"] = building
+	}
+
+	room, err := f.getContainingRoom(ctx, id, center, levels)
+	if err != nil {
+		return nil, err
+	}
+	if room != nil {
+		feat.Properties["room"] = room
+	}
+
+	if id.Type() == osm.TypeRelation {
+		members, err := f.dataRepository.GetRelationMembers(ctx, id.RelationID())
+		if err != nil {
+			return nil, fmt.Errorf("error getting relation members: %w", err)
+		}
+
+		feat.Properties["members"] = members
+	}
+
+	return feat, nil
+}
+
+// getContainingBuilding returns the smallest building containing the point, which is not the feature itself
+func (f *featureService) getContainingBuilding(ctx context.Context, id osm.FeatureID, point orb.Point) (*entities.FeatureRef, error) {
+	buildings, err := f.dataRepository.GetBuildingsAt(ctx, point)
+	if err != nil {
+		return nil, fmt.Errorf("error getting buildings: %w", err)
+	}
+
+	for _, building := range buildings {
+		if building.ID == osmid.Format(id) {
+			continue
+		}
+
+		return &entities.FeatureRef{ID: building.ID, Name: building.Name}, nil
+	}
+
+	return nil, nil
+}
+
+// getContainingRoom returns the innermost room containing the point on one of the levels, which is not the feature itself
+func (f *featureService) getContainingRoom(ctx context.Context, id osm.FeatureID, point orb.Point, levels []float64) (*entities.FeatureRef, error) {
+	areas, err := f.dataRepository.GetIndoorAreasAt(ctx, point)
+	if err != nil {
+		return nil, fmt.Errorf("error getting indoor areas: %w", err)
+	}
+
+	rooms := slices.DeleteFunc(areas, func(area *geojson.Feature) bool {
+		return area.ID == osmid.Format(id) || area.Properties.MustString("indoor", "") != "room" ||
+			!sharesLevel(area.Properties.MustString("level", ""), levels)
+	})
+	if len(rooms) == 0 {
+		return nil, nil
+	}
+
+	sortInnermostFirst(rooms)
+
+	return featureRef(rooms[0]), nil
+}
+
+// sharesLevel reports whether the level tag value contains one of the levels. Without levels every value matches.
+func sharesLevel(value string, levels []float64) bool {
+	if len(levels) == 0 {
+		return true
+	}
+
+	for _, level := range levels {
+		if osmlevel.Contains(value, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortInnermostFirst sorts the features containing a common point by their area, so the innermost comes first
+func sortInnermostFirst(features []*geojson.Feature) {
+	slices.SortStableFunc(features, func(a, b *geojson.Feature) int {
+		return cmp.Compare(planar.Area(a.Geometry), planar.Area(b.Geometry))
+	})
+}
+
+// featureRef references a feature as returned by the repository, where the properties are the tags
+func featureRef(feat *geojson.Feature) *entities.FeatureRef {
+	id, _ := feat.ID.(string)
+	return &entities.FeatureRef{
+		ID:   id,
+		Name: feat.Properties.MustString("name", ""),
+		Ref:  feat.Properties.MustString("ref", ""),
+	}
+}
@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/osm"
+	"reflect"
+	"testing"
+)
+
+type fakeFeatureRepository struct {
+	fakeOsmDataRepository
+	geometries map[osm.FeatureID]orb.Geometry
+	tags       map[osm.FeatureID]map[string]string
+	members    map[osm.RelationID][]entities.RelationMember
+	buildings  []entities.Building
+	areas      []*geojson.Feature
+}
+
+func (f fakeFeatureRepository) GetFeatureGeometry(_ context.Context, id osm.FeatureID) (orb.Geometry, error) {
+	geom, ok := f.geometries[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return geom, nil
+}
+
+func (f fakeFeatureRepository) GetFeatureTags(_ context.Context, id osm.FeatureID) (map[string]string, error) {
+	return f.tags[id], nil
+}
+
+func (f fakeFeatureRepository) GetRelationMembers(_ context.Context, id osm.RelationID) ([]entities.RelationMember, error) {
+	return f.members[id], nil
+}
+
+func (f fakeFeatureRepository) GetBuildingsAt(context.Context, orb.Point) ([]entities.Building, error) {
+	return f.buildings, nil
+}
+
+func (f fakeFeatureRepository) GetIndoorAreasAt(context.Context, orb.Point) ([]*geojson.Feature, error) {
+	// the service filters the areas in place, so every call gets its own slice
+	return append([]*geojson.Feature(nil), f.areas...), nil
+}
+
+func newTestRoom(id string, size float64, level string) *geojson.Feature {
+	feat := newTestFeature(id, square(size), level)
+	feat.Properties["indoor"] = "room"
+	feat.Properties["name"] = "Room " + id
+	return feat
+}
+
+func newTestFeatureRepository() fakeFeatureRepository {
+	return fakeFeatureRepository{
+		geometries: map[osm.FeatureID]orb.Geometry{
+			osm.NodeID(1).FeatureID():     orb.Point{1, 1},
+			osm.WayID(20).FeatureID():     square(4),
+			osm.RelationID(3).FeatureID(): square(10),
+		},
+		tags: map[osm.FeatureID]map[string]string{
+			osm.NodeID(1).FeatureID():     {"door": "yes", "level": "0"},
+			osm.WayID(20).FeatureID():     {"indoor": "room", "level": "0"},
+			osm.RelationID(3).FeatureID(): {"building": "university", "name": "Main"},
+		},
+		members: map[osm.RelationID][]entities.RelationMember{
+			3: {{Type: "way", ID: 10, Role: "outer"}},
+		},
+		buildings: []entities.Building{{ID: "r3", Name: "Main"}},
+		areas: []*geojson.Feature{
+			newTestRoom("w22", 8, "0"),
+			newTestRoom("w20", 4, "0"),
+			newTestRoom("w21", 2, "1"),
+		},
+	}
+}
+
+func TestFeatureService_GetFeature(t *testing.T) {
+	svc := service.NewFeatureService(newTestFeatureRepository())
+
+	feat, err := svc.GetFeature(context.Background(), osm.NodeID(1).FeatureID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if feat.ID != "n1" || feat.Properties["osm_type"] != osm.TypeNode || feat.Properties["osm_id"] != int64(1) {
+		t.Errorf("expected node n1, got %v %v", feat.ID, feat.Properties)
+	}
+
+	if levels := feat.Properties["levels"]; !reflect.DeepEqual(levels, []float64{0}) {
+		t.Errorf("expected levels [0], got %v", levels)
+	}
+
+	if building := feat.Properties["building"]; !reflect.DeepEqual(building, &entities.FeatureRef{ID: "r3", Name: "Main"}) {
+		t.Errorf("expected building r3, got %+v", building)
+	}
+
+	// the innermost room on the level of the door, the smaller room is on another level
+	if room := feat.Properties["room"]; !reflect.DeepEqual(room, &entities.FeatureRef{ID: "w20", Name: "Room w20"}) {
+		t.Errorf("expected room w20, got %+v", room)
+	}
+
+	if _, ok := feat.Properties["members"]; ok {
+		t.Error("expected no members for a node")
+	}
+}
+
+func TestFeatureService_GetFeature_ExcludesItself(t *testing.T) {
+	svc := service.NewFeatureService(newTestFeatureRepository())
+
+	room, err := svc.GetFeature(context.Background(), osm.WayID(20).FeatureID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref := room.Properties["room"]; !reflect.DeepEqual(ref, &entities.FeatureRef{ID: "w22", Name: "Room w22"}) {
+		t.Errorf("expected the room around w20, got %+v", ref)
+	}
+
+	building, err := svc.GetFeature(context.Background(), osm.RelationID(3).FeatureID())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := building.Properties["building"]; ok {
+		t.Errorf("expected the building not to contain itself, got %+v", building.Properties["building"])
+	}
+
+	if members := building.Properties["members"]; !reflect.DeepEqual(members, []entities.RelationMember{{Type: "way", ID: 10, Role: "outer"}}) {
+		t.Errorf("expected the members of r3, got %+v", members)
+	}
+}
+
+func TestFeatureService_GetFeature_NotFound(t *testing.T) {
+	svc := service.NewFeatureService(newTestFeatureRepository())
+
+	_, err := svc.GetFeature(context.Background(), osm.WayID(404).FeatureID())
+	if !errors.Is(err, service.ErrFeatureNotFound) {
+		t.Errorf("expected ErrFeatureNotFound, got %v", err)
+	}
+}
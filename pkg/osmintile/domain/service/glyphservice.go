@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/glyphs"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidGlyphRange = errors.New("invalid glyph range")
+	ErrGlyphsNotFound    = errors.New("glyphs not found")
+)
+
+// glyphRangeRegex matches the codepoint ranges MapLibre requests, e.g. 0-255
+var glyphRangeRegex = regexp.MustCompile(`^(\d+)-(\d+)$`)
+
+// maxGlyphCacheEntries bounds the combined fontstacks kept in memory, an arbitrary entry is evicted when it is full
+const maxGlyphCacheEntries = 512
+
+type GlyphService interface {
+	GetGlyphs(ctx context.Context, fontstack string, glyphRange string) ([]byte, error)
+}
+
+type glyphService struct {
+	fontFS  fs.FS
+	cacheMu sync.RWMutex
+	cache   map[string][]byte
+}
+
+// NewGlyphService serves pre-generated glyph pbfs from fontFS, laid out as <font name>/<start>-<end>.pbf
+func NewGlyphService(fontFS fs.FS) GlyphService {
+	return &glyphService{
+		fontFS: fontFS,
+		cache:  make(map[string][]byte),
+	}
+}
+
+// GetGlyphs returns the glyphs of the comma separated fontstack for the range.
+// Codepoints missing in a font are taken from the following fonts of the stack.
+func (g *glyphService) GetGlyphs(_ context.Context, fontstack string, glyphRange string) ([]byte, error) {
+	if err := validateGlyphRange(glyphRange); err != nil {
+		return nil, err
+	}
+
+	// the cache is keyed by the fonts found, so unknown or repeated fonts of the requested stack do not add entries
+	fonts := g.resolveFonts(fontstack, glyphRange)
+	if len(fonts) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrGlyphsNotFound, fontstack, glyphRange)
+	}
+
+	key := strings.Join(fonts, ",") + "/" + glyphRange
+
+	g.cacheMu.RLock()
+	data, ok := g.cache[key]
+	g.cacheMu.RUnlock()
+	if ok {
+		return data, nil
+	}
+
+	stacks := make([]*glyphs.Fontstack, 0, len(fonts))
+	for _, font := range fonts {
+		stack, err := g.loadFont(font, glyphRange)
+		if err != nil {
+			return nil, err
+		}
+
+		stacks = append(stacks, stack)
+	}
+
+	data = glyphs.Encode(glyphs.Combine(strings.Join(fonts, ","), stacks...))
+
+	g.cacheMu.Lock()
+	if len(g.cache) >= maxGlyphCacheEntries {
+		for evicted := range g.cache {
+			delete(g.cache, evicted)
+			break
+		}
+	}
+	g.cache[key] = data
+	g.cacheMu.Unlock()
+
+	return data, nil
+}
+
+// resolveFonts returns the fonts of the comma separated fontstack with a file for the range, without duplicates
+func (g *glyphService) resolveFonts(fontstack string, glyphRange string) []string {
+	fonts := make([]string, 0)
+	for _, font := range strings.Split(fontstack, ",") {
+		font = strings.TrimSpace(font)
+		if slices.Contains(fonts, font) {
+			continue
+		}
+
+		fontPath, ok := glyphFontPath(font, glyphRange)
+		if !ok {
+			continue
+		}
+
+		if _, err := fs.Stat(g.fontFS, fontPath); err != nil {
+			continue
+		}
+
+		fonts = append(fonts, font)
+	}
+	return fonts
+}
+
+// glyphFontPath returns the path of the pbf of the font for the range, fonts must not contain path separators
+func glyphFontPath(font string, glyphRange string) (string, bool) {
+	fontPath := path.Join(font, glyphRange+".pbf")
+	if font == "" || strings.ContainsAny(font, `/\`) || !fs.ValidPath(fontPath) {
+		return "", false
+	}
+	return fontPath, true
+}
+
+func (g *glyphService) loadFont(font string, glyphRange string) (*glyphs.Fontstack, error) {
+	fontPath, ok := glyphFontPath(font, glyphRange)
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+
+	data, err := fs.ReadFile(g.fontFS, fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading font %s: %w", fontPath, err)
+	}
+
+	stack, err := glyphs.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding font %s: %w", fontPath, err)
+	}
+
+	return stack, nil
+}
+
+func validateGlyphRange(glyphRange string) error {
+	match := glyphRangeRegex.FindStringSubmatch(glyphRange)
+	if match == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidGlyphRange, glyphRange)
+	}
+
+	start, err := strconv.Atoi(match[1])
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidGlyphRange, glyphRange)
+	}
+
+	end, err := strconv.Atoi(match[2])
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidGlyphRange, glyphRange)
+	}
+
+	if start%256 != 0 || end != start+255 {
+		return fmt.Errorf("%w: %q", ErrInvalidGlyphRange, glyphRange)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/graphml"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// GraphExportFormats are the formats ExportGraph can write
+var GraphExportFormats = []string{"geojson", "graphml", "csv"}
+
+// GraphExportFormat returns the export format matching the extension of the path, e.g. graph.graphml
+func GraphExportFormat(path string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case "geojson", "json":
+		return "geojson", nil
+	case "graphml", "csv":
+		return ext, nil
+	default:
+		return "", fmt.Errorf("%w: %q, expected one of %v", ErrUnknownExportFormat, ext, GraphExportFormats)
+	}
+}
+
+// ExportGraph writes the navigation graph in the format. GeoJSON contains the nodes and edges as features,
+// GraphML the nodes and edges with their attributes and CSV the edges together with the coordinates of their nodes.
+func (r *routingService) ExportGraph(ctx context.Context, w io.Writer, format string) error {
+	graph, err := r.getGraph(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "geojson":
+		err = json.NewEncoder(w).Encode(graph.GeoJSON(nil))
+	case "graphml":
+		err = graphml.Write(w, graph.GraphML())
+	case "csv":
+		err = graph.WriteCSV(w)
+	default:
+		return fmt.Errorf("%w: %q, expected one of %v", ErrUnknownExportFormat, format, GraphExportFormats)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error exporting graph: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrImportRunning = errors.New("import is already running")
+
+type ImportService interface {
+	// Import imports the osm file and records its status, options.Progress is called as well.
+	Import(ctx context.Context, path string, options repository.ImportOptions) error
+	// ImportInBackground marks the import as running before it returns and imports the osm file in a goroutine.
+	// A failed import is logged and recorded in the status.
+	ImportInBackground(ctx context.Context, path string, options repository.ImportOptions) error
+	// GetStatus returns the state and the last progress of the current or last import.
+	GetStatus() entities.ImportStatus
+	// OnImported registers a callback called after every successful import, e.g. to drop cached results.
+	OnImported(callback func())
+}
+
+type importService struct {
+	dataRepository repository.OsmDataRepository
+	statusMu       sync.Mutex
+	status         entities.ImportStatus
+	onImported     []func()
+}
+
+func NewImportService(dataRepository repository.OsmDataRepository) ImportService {
+	return &importService{
+		dataRepository: dataRepository,
+		status:         entities.ImportStatus{State: entities.ImportStateIdle},
+	}
+}
+
+func (i *importService) Import(ctx context.Context, path string, options repository.ImportOptions) error {
+	if err := i.start(path); err != nil {
+		return err
+	}
+
+	return i.run(ctx, path, options)
+}
+
+func (i *importService) ImportInBackground(ctx context.Context, path string, options repository.ImportOptions) error {
+	if err := i.start(path); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := i.run(ctx, path, options); err != nil {
+			log.Printf("import failed: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// start marks the import of the file as running, unless another import is running
+func (i *importService) start(path string) error {
+	i.statusMu.Lock()
+	defer i.statusMu.Unlock()
+
+	if i.status.State == entities.ImportStateRunning {
+		return ErrImportRunning
+	}
+
+	startedAt := time.Now()
+	i.status = entities.ImportStatus{State: entities.ImportStateRunning, File: path, StartedAt: &startedAt}
+	return nil
+}
+
+// run imports the started import and records its outcome
+func (i *importService) run(ctx context.Context, path string, options repository.ImportOptions) error {
+	callback := options.Progress
+	options.Progress = func(progress entities.ImportProgress) {
+		i.statusMu.Lock()
+		i.status.Progress = &progress
+		i.statusMu.Unlock()
+
+		if callback != nil {
+			callback(progress)
+		}
+	}
+
+	err := i.dataRepository.Import(ctx, path, options)
+
+	i.statusMu.Lock()
+	finishedAt := time.Now()
+	i.status.FinishedAt = &finishedAt
+	if err != nil {
+		i.status.State = entities.ImportStateFailed
+		i.status.Error = err.Error()
+		i.statusMu.Unlock()
+		return fmt.Errorf("error importing %s: %w", path, err)
+	}
+
+	i.status.State = entities.ImportStateDone
+	onImported := slices.Clone(i.onImported)
+	i.statusMu.Unlock()
+
+	for _, callback := range onImported {
+		callback()
+	}
+
+	return nil
+}
+
+func (i *importService) OnImported(callback func()) {
+	i.statusMu.Lock()
+	defer i.statusMu.Unlock()
+
+	i.onImported = append(i.onImported, callback)
+}
+
+func (i *importService) GetStatus() entities.ImportStatus {
+	i.statusMu.Lock()
+	defer i.statusMu.Unlock()
+
+	return i.status
+}
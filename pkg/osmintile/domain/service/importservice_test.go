@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"testing"
+)
+
+type fakeImportRepository struct {
+	fakeOsmDataRepository
+	progress []entities.ImportProgress
+	// during is called while importing, after the progress was reported
+	during func()
+	err    error
+}
+
+func (f fakeImportRepository) Import(_ context.Context, _ string, options repository.ImportOptions) error {
+	for _, progress := range f.progress {
+		options.Progress(progress)
+	}
+	if f.during != nil {
+		f.during()
+	}
+	return f.err
+}
+
+func TestImportService_Import(t *testing.T) {
+	progress := []entities.ImportProgress{
+		{Pass: "relation filter pass", PassIndex: 1, PassCount: 5, FileSize: 100},
+		{Pass: "relation filter pass", PassIndex: 1, PassCount: 5, BytesRead: 50, FileSize: 100, ObjectsKept: 3},
+	}
+
+	var svc service.ImportService
+	repo := fakeImportRepository{progress: progress}
+	repo.during = func() {
+		status := svc.GetStatus()
+		if status.State != entities.ImportStateRunning || status.File != "campus.osm.pbf" {
+			t.Errorf("expected running import of campus.osm.pbf, got %+v", status)
+		}
+		if status.Progress == nil || *status.Progress != progress[1] {
+			t.Errorf("expected last progress %+v, got %+v", progress[1], status.Progress)
+		}
+
+		if err := svc.Import(context.Background(), "other.osm", repository.ImportOptions{}); !errors.Is(err, service.ErrImportRunning) {
+			t.Errorf("expected ErrImportRunning, got %v", err)
+		}
+	}
+	svc = service.NewImportService(repo)
+
+	imported := 0
+	svc.OnImported(func() { imported++ })
+
+	if state := svc.GetStatus().State; state != entities.ImportStateIdle {
+		t.Errorf("expected idle before the import, got %s", state)
+	}
+
+	reported := 0
+	err := svc.Import(context.Background(), "campus.osm.pbf", repository.ImportOptions{
+		Progress: func(entities.ImportProgress) { reported++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reported != len(progress) {
+		t.Errorf("expected the callback to be called %d times, got %d", len(progress), reported)
+	}
+
+	status := svc.GetStatus()
+	if status.State != entities.ImportStateDone || status.StartedAt == nil || status.FinishedAt == nil {
+		t.Errorf("expected a finished import, got %+v", status)
+	}
+
+	if imported != 1 {
+		t.Errorf("expected the imported callback to be called once, got %d", imported)
+	}
+}
+
+func TestImportService_ImportFailed(t *testing.T) {
+	importErr := errors.New("broken file")
+	svc := service.NewImportService(fakeImportRepository{err: importErr})
+	svc.OnImported(func() { t.Error("expected no imported callback after a failed import") })
+
+	if err := svc.Import(context.Background(), "campus.osm", repository.ImportOptions{}); !errors.Is(err, importErr) {
+		t.Fatalf("expected import error, got %v", err)
+	}
+
+	status := svc.GetStatus()
+	if status.State != entities.ImportStateFailed || status.Error == "" {
+		t.Errorf("expected a failed import with error, got %+v", status)
+	}
+}
+
+func TestImportService_ImportInBackground(t *testing.T) {
+	release := make(chan struct{})
+	svc := service.NewImportService(fakeImportRepository{during: func() { <-release }})
+
+	imported := make(chan struct{})
+	svc.OnImported(func() { close(imported) })
+
+	if err := svc.ImportInBackground(context.Background(), "campus.osm", repository.ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the state is set before the import starts, so requests right after the start are already rejected
+	if state := svc.GetStatus().State; state != entities.ImportStateRunning {
+		t.Errorf("expected running right after the start, got %s", state)
+	}
+
+	if err := svc.ImportInBackground(context.Background(), "other.osm", repository.ImportOptions{}); !errors.Is(err, service.ErrImportRunning) {
+		t.Errorf("expected ErrImportRunning, got %v", err)
+	}
+
+	close(release)
+	<-imported
+
+	if state := svc.GetStatus().State; state != entities.ImportStateDone {
+		t.Errorf("expected done after the import, got %s", state)
+	}
+}
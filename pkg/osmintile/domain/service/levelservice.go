@@ -0,0 +1,135 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"log"
+	"slices"
+	"strings"
+)
+
+type LevelService interface {
+	GetLevels(ctx context.Context, bound orb.Bound) ([]entities.Level, error)
+	GetBuildingLevels(ctx context.Context, buildingID osm.FeatureID) ([]entities.Level, error)
+}
+
+type levelService struct {
+	dataRepository repository.OsmDataRepository
+}
+
+func NewLevelService(dataRepository repository.OsmDataRepository) LevelService {
+	return &levelService{
+		dataRepository: dataRepository,
+	}
+}
+
+func (l *levelService) GetLevels(ctx context.Context, bound orb.Bound) ([]entities.Level, error) {
+	tags, err := l.dataRepository.GetLevelTags(ctx, bound)
+	if err != nil {
+		return nil, fmt.Errorf("error getting level tags: %w", err)
+	}
+
+	return summarizeLevels(tags), nil
+}
+
+// GetBuildingLevels returns the levels of the features, whose center lies within the outline of the building.
+func (l *levelService) GetBuildingLevels(ctx context.Context, buildingID osm.FeatureID) ([]entities.Level, error) {
+	building, err := l.dataRepository.GetBuilding(ctx, buildingID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrBuildingNotFound, buildingID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting building: %w", err)
+	}
+
+	outline := building.Outline.Coordinates
+
+	tags, err := l.dataRepository.GetLevelTags(ctx, outline.Bound())
+	if err != nil {
+		return nil, fmt.Errorf("error getting level tags: %w", err)
+	}
+
+	contained := make([]entities.LevelTag, 0, len(tags))
+	for _, tag := range tags {
+		if geoutil.Contains(outline, tag.Bound.Center()) {
+			contained = append(contained, tag)
+		}
+	}
+
+	return summarizeLevels(contained), nil
+}
+
+// summarizeLevels expands the level tags into the levels they cover and aggregates them per level
+func summarizeLevels(tags []entities.LevelTag) []entities.Level {
+	levels := make(map[float64]*entities.Level)
+	refCounts := make(map[float64]map[string]int)
+
+	for _, tag := range tags {
+		values, err := osmlevel.Parse(tag.Level)
+		if err != nil {
+			log.Printf("ignoring level tag: %v", err)
+			continue
+		}
+
+		refs := strings.Split(tag.LevelRef, ";")
+
+		for i, value := range values {
+			level, ok := levels[value]
+			if !ok {
+				level = &entities.Level{Level: value}
+				levels[value] = level
+				refCounts[value] = make(map[string]int)
+			}
+
+			bound := tag.Bound
+			if level.FeatureCount > 0 {
+				bound = bound.Union(boundFromArray(level.Bounds))
+			}
+			level.Bounds = boundToArray(bound)
+			level.FeatureCount++
+
+			// level:ref lists the names in the same order as the level tag
+			if tag.LevelRef != "" && len(refs) == len(values) {
+				refCounts[value][strings.TrimSpace(refs[i])]++
+			}
+		}
+	}
+
+	out := make([]entities.Level, 0, len(levels))
+	for value, level := range levels {
+		level.Ref = mostCommon(refCounts[value])
+		out = append(out, *level)
+	}
+
+	slices.SortFunc(out, func(a, b entities.Level) int {
+		return cmp.Compare(a.Level, b.Level)
+	})
+
+	return out
+}
+
+func mostCommon(counts map[string]int) string {
+	out, outCount := "", 0
+	for value, count := range counts {
+		if count > outCount || (count == outCount && value < out) {
+			out, outCount = value, count
+		}
+	}
+	return out
+}
+
+func boundToArray(bound orb.Bound) [4]float64 {
+	return [4]float64{bound.Min.Lon(), bound.Min.Lat(), bound.Max.Lon(), bound.Max.Lat()}
+}
+
+func boundFromArray(bound [4]float64) orb.Bound {
+	return orb.Bound{Min: orb.Point{bound[0], bound[1]}, Max: orb.Point{bound[2], bound[3]}}
+}
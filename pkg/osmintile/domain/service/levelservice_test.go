@@ -0,0 +1,46 @@
+package service_test
+
+import (
+	"context"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/orb"
+	"reflect"
+	"testing"
+)
+
+type fakeLevelRepository struct {
+	fakeOsmDataRepository
+	tags []entities.LevelTag
+}
+
+func (f fakeLevelRepository) GetLevelTags(context.Context, orb.Bound) ([]entities.LevelTag, error) {
+	return f.tags, nil
+}
+
+func TestLevelService_GetLevels(t *testing.T) {
+	a := orb.Bound{Min: orb.Point{0, 0}, Max: orb.Point{1, 1}}
+	b := orb.Bound{Min: orb.Point{2, 2}, Max: orb.Point{3, 3}}
+
+	svc := service.NewLevelService(fakeLevelRepository{tags: []entities.LevelTag{
+		{Level: "0", LevelRef: "EG", Bound: a},
+		{Level: "0;1", LevelRef: "EG;OG1", Bound: b},
+		{Level: "-1", Bound: a},
+		{Level: "roof", Bound: b},
+	}})
+
+	levels, err := svc.GetLevels(context.Background(), orb.Bound{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []entities.Level{
+		{Level: -1, FeatureCount: 1, Bounds: [4]float64{0, 0, 1, 1}},
+		{Level: 0, Ref: "EG", FeatureCount: 2, Bounds: [4]float64{0, 0, 3, 3}},
+		{Level: 1, Ref: "OG1", FeatureCount: 1, Bounds: [4]float64{2, 2, 3, 3}},
+	}
+
+	if !reflect.DeepEqual(levels, expected) {
+		t.Errorf("expected %+v, got %+v", expected, levels)
+	}
+}
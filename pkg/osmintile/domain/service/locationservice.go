@@ -0,0 +1,95 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/geojson"
+	"math"
+	"slices"
+)
+
+const (
+	DefaultLocateRadius = 25.0
+	maxLocateRadius     = 500.0
+)
+
+var ErrInvalidRadius = errors.New("invalid radius")
+
+type LocationService interface {
+	// Locate returns what can be found at the point. Without a level, features on all levels are returned.
+	Locate(ctx context.Context, point orb.Point, level *float64, radius float64) (entities.Location, error)
+}
+
+type locationService struct {
+	dataRepository repository.OsmDataRepository
+}
+
+func NewLocationService(dataRepository repository.OsmDataRepository) LocationService {
+	return &locationService{
+		dataRepository: dataRepository,
+	}
+}
+
+func (l *locationService) Locate(ctx context.Context, point orb.Point, level *float64, radius float64) (entities.Location, error) {
+	if math.IsNaN(radius) || radius <= 0 || radius > maxLocateRadius {
+		return entities.Location{}, fmt.Errorf("%w: %g, must be in (0, %g]", ErrInvalidRadius, radius, maxLocateRadius)
+	}
+
+	location := entities.Location{
+		Areas: geojson.NewFeatureCollection(),
+		Pois:  geojson.NewFeatureCollection(),
+	}
+
+	buildings, err := l.dataRepository.GetBuildingsAt(ctx, point)
+	if err != nil {
+		return entities.Location{}, fmt.Errorf("error getting buildings: %w", err)
+	}
+	if len(buildings) > 0 {
+		location.Building = &entities.FeatureRef{ID: buildings[0].ID, Name: buildings[0].Name}
+	}
+
+	areas, err := l.dataRepository.GetIndoorAreasAt(ctx, point)
+	if err != nil {
+		return entities.Location{}, fmt.Errorf("error getting indoor areas: %w", err)
+	}
+
+	areas = slices.DeleteFunc(areas, func(area *geojson.Feature) bool {
+		return !onLevel(area, level)
+	})
+	sortInnermostFirst(areas)
+	location.Areas.Features = areas
+
+	pois, err := l.dataRepository.GetPoisWithin(ctx, geo.NewBoundAroundPoint(point, radius))
+	if err != nil {
+		return entities.Location{}, fmt.Errorf("error getting pois: %w", err)
+	}
+
+	pois = slices.DeleteFunc(pois, func(poi *geojson.Feature) bool {
+		return !onLevel(poi, level) || geo.Distance(point, poi.Point()) > radius
+	})
+	for _, poi := range pois {
+		poi.Properties["distance"] = geo.Distance(point, poi.Point())
+	}
+	slices.SortStableFunc(pois, func(a, b *geojson.Feature) int {
+		return cmp.Compare(a.Properties.MustFloat64("distance"), b.Properties.MustFloat64("distance"))
+	})
+	location.Pois.Features = pois
+
+	return location, nil
+}
+
+// onLevel reports whether the level tag of the feature contains the level, every feature is on the nil level
+func onLevel(feat *geojson.Feature, level *float64) bool {
+	if level == nil {
+		return true
+	}
+
+	return osmlevel.Contains(feat.Properties.MustString("level", ""), *level)
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/ptr"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"math"
+	"testing"
+)
+
+type fakeLocationRepository struct {
+	fakeOsmDataRepository
+	areas []*geojson.Feature
+	pois  []*geojson.Feature
+}
+
+func (f fakeLocationRepository) GetBuildingsAt(context.Context, orb.Point) ([]entities.Building, error) {
+	return []entities.Building{{ID: "w1", Name: "Main"}}, nil
+}
+
+func (f fakeLocationRepository) GetIndoorAreasAt(context.Context, orb.Point) ([]*geojson.Feature, error) {
+	return f.areas, nil
+}
+
+func (f fakeLocationRepository) GetPoisWithin(context.Context, orb.Bound) ([]*geojson.Feature, error) {
+	return f.pois, nil
+}
+
+func newTestFeature(id string, geom orb.Geometry, level string) *geojson.Feature {
+	feat := geojson.NewFeature(geom)
+	feat.ID = id
+	feat.Properties["level"] = level
+	return feat
+}
+
+func square(size float64) orb.Polygon {
+	return orb.Polygon{{{0, 0}, {size, 0}, {size, size}, {0, size}, {0, 0}}}
+}
+
+func TestLocationService_Locate(t *testing.T) {
+	svc := service.NewLocationService(fakeLocationRepository{
+		areas: []*geojson.Feature{
+			newTestFeature("w10", square(0.001), "0"),
+			newTestFeature("w11", square(0.0001), "0"),
+			newTestFeature("w12", square(0.0001), "1"),
+		},
+		pois: []*geojson.Feature{
+			newTestFeature("n20", orb.Point{0.0001, 0.0001}, "0"),
+			newTestFeature("n21", orb.Point{0.00001, 0.00001}, "0;1"),
+			newTestFeature("n22", orb.Point{0.00001, 0.00001}, "1"),
+			newTestFeature("n23", orb.Point{0.01, 0.01}, "0"),
+		},
+	})
+
+	location, err := svc.Locate(context.Background(), orb.Point{0.00005, 0.00005}, ptr.Ptr(0.0), 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if location.Building == nil || location.Building.ID != "w1" {
+		t.Errorf("expected building w1, got %+v", location.Building)
+	}
+
+	assertFeatureIDs(t, location.Areas, "w11", "w10")
+	assertFeatureIDs(t, location.Pois, "n21", "n20")
+
+	for _, radius := range []float64{0, math.NaN(), math.Inf(1)} {
+		_, err = svc.Locate(context.Background(), orb.Point{}, nil, radius)
+		if !errors.Is(err, service.ErrInvalidRadius) {
+			t.Errorf("expected ErrInvalidRadius for %g, got %v", radius, err)
+		}
+	}
+}
+
+func assertFeatureIDs(t *testing.T, fc *geojson.FeatureCollection, ids ...string) {
+	t.Helper()
+
+	if len(fc.Features) != len(ids) {
+		t.Fatalf("expected %d features, got %d", len(ids), len(fc.Features))
+	}
+
+	for i, id := range ids {
+		if fc.Features[i].ID != id {
+			t.Errorf("expected feature %d to be %s, got %v", i, id, fc.Features[i].ID)
+		}
+	}
+}
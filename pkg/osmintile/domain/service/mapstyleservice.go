@@ -4,20 +4,44 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
 	"github.com/paulkoehlerdev/OsmInTile/styles"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
 	"text/template"
 )
 
+var (
+	ErrUnknownTheme    = errors.New("unknown theme")
+	ErrInvalidLanguage = errors.New("invalid language")
+)
+
+// languageRegex accepts language codes as used in name:<lang> keys, e.g. de, gsw or zh-Hant
+var languageRegex = regexp.MustCompile(`^[a-z]{2,3}([-_][A-Za-z0-9]{2,8})?$`)
+
 type MapStyleService interface {
-	GetMapStyle(ctx context.Context) (entities.MapStyle, error)
+	GetMapStyle(ctx context.Context, params entities.MapStyleParams) (entities.MapStyle, error)
+	// ReloadTemplates loads the templates again, they are validated again if ValidateTemplates was called before.
+	ReloadTemplates() error
+	ValidateTemplates(vectorLayers []entities.VectorLayer) error
 }
 
 type mapStyleService struct {
-	publicUrl      string
-	templates      *template.Template
+	publicUrl    string
+	templateFS   fs.FS
+	templatesMu  sync.RWMutex
+	templates    *template.Template
+	themes       map[string]map[string]string
+	templatesErr error
+	// vectorLayers are the layers of the last validation, reloaded templates are validated against them
+	vectorLayers   []entities.VectorLayer
 	dataRepository repository.OsmDataRepository
 }
 
@@ -25,33 +49,186 @@ type mapStyleInfo struct {
 	PublicURL string
 	Bounds    string
 	Center    string
+	Level     int
+	Theme     map[string]string
+	Language  string
+	Building  string
 }
 
 func NewMapStyleService(publicUrl string, dataRepository repository.OsmDataRepository) (MapStyleService, error) {
-	templates, err := template.ParseFS(styles.FS, "*.json")
-	if err != nil {
-		return nil, fmt.Errorf("error loading template dir: %w", err)
+	service := &mapStyleService{
+		publicUrl:      publicUrl,
+		templateFS:     styles.FS,
+		dataRepository: dataRepository,
+	}
+
+	if err := service.ReloadTemplates(); err != nil {
+		return nil, err
 	}
 
-	return &mapStyleService{
+	return service, nil
+}
+
+// NewDevMapStyleService loads the templates from templateFS. Other than NewMapStyleService it does not fail
+// on broken templates, the error is reported by GetMapStyle until the templates are fixed and reloaded.
+func NewDevMapStyleService(publicUrl string, templateFS fs.FS, dataRepository repository.OsmDataRepository) MapStyleService {
+	service := &mapStyleService{
 		publicUrl:      publicUrl,
-		templates:      templates,
+		templateFS:     templateFS,
 		dataRepository: dataRepository,
-	}, nil
+	}
+
+	_ = service.ReloadTemplates()
+
+	return service
 }
 
-func (m *mapStyleService) GetMapStyle(ctx context.Context) (entities.MapStyle, error) {
-	return m.getMapStyle(ctx, "default.json")
+func (m *mapStyleService) ReloadTemplates() error {
+	templates, err := template.New("").Option("missingkey=error").ParseFS(m.templateFS, "*.json")
+	if err != nil {
+		err = fmt.Errorf("error loading template dir: %w", err)
+	}
+
+	var themes map[string]map[string]string
+	if err == nil {
+		themes, err = m.loadThemes()
+	}
+
+	m.templatesMu.Lock()
+	m.templatesErr = err
+	if err == nil {
+		m.templates = templates
+		m.themes = themes
+	}
+	vectorLayers := m.vectorLayers
+	m.templatesMu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if vectorLayers != nil {
+		return m.validateTemplates(templates, themes, vectorLayers)
+	}
+
+	return nil
 }
 
-func (m *mapStyleService) getMapStyle(ctx context.Context, name string) (entities.MapStyle, error) {
+// loadThemes loads the color palettes from themes/<name>.json
+func (m *mapStyleService) loadThemes() (map[string]map[string]string, error) {
+	paths, err := fs.Glob(m.templateFS, "themes/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("error listing themes: %w", err)
+	}
+
+	themes := make(map[string]map[string]string, len(paths))
+	for _, themePath := range paths {
+		file, err := fs.ReadFile(m.templateFS, themePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading theme %s: %w", themePath, err)
+		}
+
+		theme := make(map[string]string)
+		if err := json.Unmarshal(file, &theme); err != nil {
+			return nil, fmt.Errorf("error parsing theme %s: %w", themePath, err)
+		}
+
+		themes[strings.TrimSuffix(path.Base(themePath), ".json")] = theme
+	}
+
+	return themes, nil
+}
+
+func (m *mapStyleService) GetMapStyle(ctx context.Context, params entities.MapStyleParams) (entities.MapStyle, error) {
+	return m.getMapStyle(ctx, "default.json", params)
+}
+
+func (m *mapStyleService) getMapStyle(ctx context.Context, name string, params entities.MapStyleParams) (entities.MapStyle, error) {
+	if params.Language != "" && !languageRegex.MatchString(params.Language) {
+		return entities.MapStyle{}, fmt.Errorf("%w: %q", ErrInvalidLanguage, params.Language)
+	}
+
+	m.templatesMu.RLock()
+	templates, themes, templatesErr := m.templates, m.themes, m.templatesErr
+	m.templatesMu.RUnlock()
+
+	if templatesErr != nil {
+		return entities.MapStyle{}, templatesErr
+	}
+
+	theme, ok := themes[params.Theme]
+	if !ok {
+		return entities.MapStyle{}, fmt.Errorf("%w: %q", ErrUnknownTheme, params.Theme)
+	}
+
 	styleInfo, err := m.getMapStyleInfo(ctx)
 	if err != nil {
 		return entities.MapStyle{}, fmt.Errorf("error getting map style info: %w", err)
 	}
 
+	styleInfo.Level = params.Level
+	styleInfo.Theme = theme
+	styleInfo.Language = params.Language
+	if params.Building != 0 {
+		styleInfo.Building = osmid.Format(params.Building)
+	}
+
+	return renderMapStyle(templates, name, styleInfo)
+}
+
+// ValidateTemplates renders every style template with every theme and validates the result against the vector layers.
+// The templates are rendered with placeholder bounds, so no data has to be imported.
+func (m *mapStyleService) ValidateTemplates(vectorLayers []entities.VectorLayer) error {
+	m.templatesMu.Lock()
+	m.vectorLayers = vectorLayers
+	templates, themes, templatesErr := m.templates, m.themes, m.templatesErr
+	m.templatesMu.Unlock()
+
+	if templatesErr != nil {
+		return templatesErr
+	}
+
+	return m.validateTemplates(templates, themes, vectorLayers)
+}
+
+func (m *mapStyleService) validateTemplates(templates *template.Template, themes map[string]map[string]string, vectorLayers []entities.VectorLayer) error {
+	// the styles are only rendered with a theme, without themes nothing would be validated
+	if len(themes) == 0 {
+		return fmt.Errorf("%w: no themes/*.json to render the styles with", ErrInvalidMapStyle)
+	}
+
+	var errs []error
+	for _, tmpl := range templates.Templates() {
+		if tmpl.Name() == "" {
+			continue
+		}
+
+		for themeName, theme := range themes {
+			styleInfo := mapStyleInfo{
+				PublicURL: m.publicUrl,
+				Bounds:    "[-180, -85, 180, 85]",
+				Center:    "[0, 0]",
+				Level:     entities.DefaultMapStyleLevel,
+				Theme:     theme,
+				Language:  "en",
+			}
+
+			style, err := renderMapStyle(templates, tmpl.Name(), styleInfo)
+			if err == nil {
+				err = ValidateMapStyle(style, m.publicUrl, vectorLayers)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("style %s with theme %s: %w", tmpl.Name(), themeName, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func renderMapStyle(templates *template.Template, name string, styleInfo mapStyleInfo) (entities.MapStyle, error) {
 	writer := bytes.Buffer{}
-	err = m.templates.ExecuteTemplate(&writer, name, styleInfo)
+	err := templates.ExecuteTemplate(&writer, name, styleInfo)
 	if err != nil {
 		return entities.MapStyle{}, fmt.Errorf("error executing template: %w", err)
 	}
@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulkoehlerdev/OsmInTile/styles"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type fakeOsmDataRepository struct {
+	repository.OsmDataRepository
+}
+
+func (fakeOsmDataRepository) GetMapBounds(context.Context) (orb.Bound, error) {
+	return orb.Bound{Min: orb.Point{11.56, 48.13}, Max: orb.Point{11.57, 48.14}}, nil
+}
+
+func (fakeOsmDataRepository) GetMapCenter(context.Context) (orb.Point, error) {
+	return orb.Point{11.565, 48.135}, nil
+}
+
+func TestMapStyleService_GetMapStyle(t *testing.T) {
+	svc, err := service.NewMapStyleService("http://localhost:8080", fakeOsmDataRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, params := range []entities.MapStyleParams{
+		entities.DefaultMapStyleParams(),
+		{Level: 2, Theme: "dark", Language: "de", Building: osm.WayID(42).FeatureID()},
+	} {
+		style, err := svc.GetMapStyle(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !json.Valid(style) {
+			t.Fatalf("style for %+v is not valid json: %s", params, style)
+		}
+
+		if params.Language != "" && !strings.Contains(string(style), `"name:`+params.Language+`"`) {
+			t.Errorf("style for %+v does not prefer the requested language", params)
+		}
+
+		if params.Building != 0 && !strings.Contains(string(style), "?building=w42") {
+			t.Errorf("style for %+v does not filter the tiles by building", params)
+		}
+	}
+}
+
+func TestMapStyleService_GetMapStyle_InvalidParams(t *testing.T) {
+	svc, err := service.NewMapStyleService("http://localhost:8080", fakeOsmDataRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.GetMapStyle(context.Background(), entities.MapStyleParams{Theme: "missing"})
+	if !errors.Is(err, service.ErrUnknownTheme) {
+		t.Errorf("expected ErrUnknownTheme, got %v", err)
+	}
+
+	_, err = svc.GetMapStyle(context.Background(), entities.MapStyleParams{Theme: entities.DefaultMapStyleTheme, Language: `de"]`})
+	if !errors.Is(err, service.ErrInvalidLanguage) {
+		t.Errorf("expected ErrInvalidLanguage, got %v", err)
+	}
+}
+
+func TestMapStyleService_ValidateTemplates(t *testing.T) {
+	svc, err := service.NewMapStyleService("http://localhost:8080", fakeOsmDataRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = svc.ValidateTemplates(service.NewMapTilesService(fakeOsmDataRepository{}).GetVectorLayers())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapStyleService_ReloadTemplates_Validates(t *testing.T) {
+	templateFS := fstest.MapFS{}
+	for _, name := range []string{"default.json", "themes/default.json", "themes/dark.json"} {
+		data, err := fs.ReadFile(styles.FS, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		templateFS[name] = &fstest.MapFile{Data: data}
+	}
+
+	svc := service.NewDevMapStyleService("http://localhost:8080", templateFS, fakeOsmDataRepository{})
+
+	if err := svc.ReloadTemplates(); err != nil {
+		t.Fatalf("expected no validation before ValidateTemplates, got %v", err)
+	}
+
+	err := svc.ValidateTemplates(service.NewMapTilesService(fakeOsmDataRepository{}).GetVectorLayers())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broken := strings.Replace(string(templateFS["default.json"].Data), `"source-layer": "indoor-base"`, `"source-layer": "missing"`, 1)
+	templateFS["default.json"] = &fstest.MapFile{Data: []byte(broken)}
+
+	err = svc.ReloadTemplates()
+	if !errors.Is(err, service.ErrInvalidMapStyle) || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("expected the reloaded template to be invalid, got %v", err)
+	}
+}
+
+func TestValidateMapStyle(t *testing.T) {
+	style := entities.MapStyle(`{
+		"version": 8,
+		"sources": {"osmintile": {"type": "vector", "tiles": ["http://localhost:8080/tiles/0/{z}/{x}/{y}"]}},
+		"layers": [
+			{"id": "rooms", "type": "fill", "source": "osmintile", "source-layer": "rooms", "paint": {}},
+			{"id": "base", "type": "fill", "source": "osmintile", "source-layer": "indoor-base", "filter": ["==", ["get", "level"], "1"]},
+			{"id": "base", "type": "polygon", "source": "osmintile", "source-layer": "indoor-base"}
+		]
+	}`)
+
+	vectorLayers := service.NewMapTilesService(fakeOsmDataRepository{}).GetVectorLayers()
+
+	err := service.ValidateMapStyle(style, "http://localhost:8080", vectorLayers)
+	if !errors.Is(err, service.ErrInvalidMapStyle) {
+		t.Fatalf("expected ErrInvalidMapStyle, got %v", err)
+	}
+
+	for _, expected := range []string{`source-layer "rooms"`, `attribute "level"`, `"base" is not unique`, `unknown type "polygon"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected issue containing %s, got %v", expected, err)
+		}
+	}
+}
+
+func TestMapStyleService_ValidateTemplates_NoThemes(t *testing.T) {
+	data, err := fs.ReadFile(styles.FS, "default.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateFS := fstest.MapFS{"default.json": &fstest.MapFile{Data: data}}
+	svc := service.NewDevMapStyleService("http://localhost:8080", templateFS, fakeOsmDataRepository{})
+
+	err = svc.ValidateTemplates(service.NewMapTilesService(fakeOsmDataRepository{}).GetVectorLayers())
+	if !errors.Is(err, service.ErrInvalidMapStyle) {
+		t.Errorf("expected a styles directory without themes to be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidMapStyle = errors.New("invalid map style")
+
+// for reference see: https://maplibre.org/maplibre-style-spec/sources/ and https://maplibre.org/maplibre-style-spec/layers/
+var (
+	styleSourceTypes = []string{"vector", "raster", "raster-dem", "geojson", "image", "video"}
+	styleLayerTypes  = []string{"background", "fill", "line", "symbol", "raster", "circle", "fill-extrusion", "heatmap", "hillshade"}
+)
+
+type styleDocument struct {
+	Version *int                   `json:"version"`
+	Sources map[string]styleSource `json:"sources"`
+	Layers  []json.RawMessage      `json:"layers"`
+}
+
+type styleSource struct {
+	Type  string   `json:"type"`
+	URL   string   `json:"url"`
+	Tiles []string `json:"tiles"`
+}
+
+type styleLayer struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Source      string          `json:"source"`
+	SourceLayer string          `json:"source-layer"`
+	Filter      json.RawMessage `json:"filter"`
+	Layout      json.RawMessage `json:"layout"`
+	Paint       json.RawMessage `json:"paint"`
+}
+
+// ValidateMapStyle checks the structure of a rendered style and cross-checks the layers using sources served
+// under publicUrl against the vector layers and attributes the tiles contain.
+// All issues found are joined into the returned error.
+func ValidateMapStyle(style entities.MapStyle, publicUrl string, vectorLayers []entities.VectorLayer) error {
+	doc := styleDocument{}
+	if err := json.Unmarshal(style, &doc); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMapStyle, err)
+	}
+
+	var errs []error
+	issue := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidMapStyle}, args...)...))
+	}
+
+	if doc.Version == nil || *doc.Version != 8 {
+		issue("version must be 8")
+	}
+
+	ownSources := make(map[string]bool, len(doc.Sources))
+	for name, source := range doc.Sources {
+		if !slices.Contains(styleSourceTypes, source.Type) {
+			issue("source %q has unknown type %q", name, source.Type)
+		}
+
+		own := strings.HasPrefix(source.URL, publicUrl)
+		for _, tiles := range source.Tiles {
+			own = own || strings.HasPrefix(tiles, publicUrl)
+		}
+		ownSources[name] = own && source.Type == "vector"
+	}
+
+	layersByID := make(map[string]entities.VectorLayer, len(vectorLayers))
+	for _, layer := range vectorLayers {
+		layersByID[layer.ID] = layer
+	}
+
+	seenIDs := make(map[string]struct{}, len(doc.Layers))
+	for i, rawLayer := range doc.Layers {
+		layer := styleLayer{}
+		if err := json.Unmarshal(rawLayer, &layer); err != nil {
+			issue("layer %d: %v", i, err)
+			continue
+		}
+
+		if layer.ID == "" {
+			issue("layer %d has no id", i)
+		}
+		if _, ok := seenIDs[layer.ID]; ok {
+			issue("layer id %q is not unique", layer.ID)
+		}
+		seenIDs[layer.ID] = struct{}{}
+
+		if !slices.Contains(styleLayerTypes, layer.Type) {
+			issue("layer %q has unknown type %q", layer.ID, layer.Type)
+			continue
+		}
+
+		if layer.Type == "background" {
+			continue
+		}
+
+		own, ok := ownSources[layer.Source]
+		if !ok {
+			issue("layer %q references unknown source %q", layer.ID, layer.Source)
+			continue
+		}
+
+		if !own {
+			continue
+		}
+
+		vectorLayer, ok := layersByID[layer.SourceLayer]
+		if !ok {
+			issue("layer %q references unknown source-layer %q", layer.ID, layer.SourceLayer)
+			continue
+		}
+
+		for _, property := range styleExpressionProperties(layer.Filter, layer.Layout, layer.Paint) {
+			if !vectorLayer.HasField(property) {
+				issue("layer %q uses attribute %q, which source-layer %q does not contain", layer.ID, property, layer.SourceLayer)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// styleExpressionProperties collects the feature properties read by ["get", ...] and ["has", ...] expressions.
+func styleExpressionProperties(raws ...json.RawMessage) []string {
+	var out []string
+
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case []any:
+			if len(v) == 2 {
+				if op, ok := v[0].(string); ok && (op == "get" || op == "has") {
+					if property, ok := v[1].(string); ok {
+						out = append(out, property)
+						return
+					}
+				}
+			}
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]any:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+
+	for _, raw := range raws {
+		if len(raw) == 0 {
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+		walk(value)
+	}
+
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -3,16 +3,24 @@ package service
 import (
 	"context"
 	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/encoding/mvt"
 	"github.com/paulmach/orb/geojson"
 	"github.com/paulmach/orb/maptile"
 	"github.com/paulmach/orb/simplify"
+	"github.com/paulmach/osm"
+)
+
+const (
+	tilesMinZoom = 13
+	tilesMaxZoom = 22
 )
 
 type MapTilesService interface {
-	GetMapTile(ctx context.Context, level int, tile maptile.Tile, acceptGzip bool) ([]byte, error)
+	GetMapTile(ctx context.Context, level int, building osm.FeatureID, tile maptile.Tile, acceptGzip bool) ([]byte, error)
+	GetVectorLayers() []entities.VectorLayer
 }
 
 type mapTilesService struct {
@@ -25,9 +33,10 @@ func NewMapTilesService(dataRepository repository.OsmDataRepository) MapTilesSer
 	}
 }
 
-func (m *mapTilesService) GetMapTile(ctx context.Context, level int, tile maptile.Tile, acceptGzip bool) ([]byte, error) {
+// GetMapTile renders the tile for the level, the zero building renders the features of all buildings.
+func (m *mapTilesService) GetMapTile(ctx context.Context, level int, building osm.FeatureID, tile maptile.Tile, acceptGzip bool) ([]byte, error) {
 	bounds := tile.Bound(1)
-	collections, err := m.getFeaturesFor(ctx, level, bounds)
+	collections, err := m.getFeaturesFor(ctx, level, building, bounds)
 	if err != nil {
 		return nil, fmt.Errorf("error getting features: %w", err)
 	}
@@ -50,17 +59,58 @@ func (m *mapTilesService) GetMapTile(ctx context.Context, level int, tile maptil
 	return data, nil
 }
 
-func (m *mapTilesService) getFeaturesFor(ctx context.Context, level int, bounds orb.Bound) (map[string]*geojson.FeatureCollection, error) {
-	base, err := m.dataRepository.GetBase(ctx, level, bounds)
+func (m *mapTilesService) getFeaturesFor(ctx context.Context, level int, building osm.FeatureID, bounds orb.Bound) (map[string]*geojson.FeatureCollection, error) {
+	base, err := m.dataRepository.GetBase(ctx, level, building, bounds)
 	if err != nil {
 		return nil, fmt.Errorf("get base failed: %w", err)
 	}
 
+	pois, err := m.dataRepository.GetPois(ctx, level, building, bounds)
+	if err != nil {
+		return nil, fmt.Errorf("get pois failed: %w", err)
+	}
+
 	return map[string]*geojson.FeatureCollection{
 		"indoor-base": base,
+		"indoor-poi":  pois,
 	}, nil
 }
 
+// GetVectorLayers describes the layers of the tiles, keep in sync with the tags selected by the repository.
+func (m *mapTilesService) GetVectorLayers() []entities.VectorLayer {
+	return []entities.VectorLayer{
+		{
+			ID:          "indoor-base",
+			Description: "Rooms, areas and corridors of the level",
+			MinZoom:     tilesMinZoom,
+			MaxZoom:     tilesMaxZoom,
+			Fields: map[string]string{
+				"indoor": "String",
+				"room":   "String",
+				"name":   "String",
+				"ref":    "String",
+			},
+			FieldPrefixes: []string{"name:"},
+		},
+		{
+			ID:          "indoor-poi",
+			Description: "Points of interest, elevators and entrances of the level",
+			MinZoom:     tilesMinZoom,
+			MaxZoom:     tilesMaxZoom,
+			Fields: map[string]string{
+				"amenity":  "String",
+				"shop":     "String",
+				"highway":  "String",
+				"entrance": "String",
+				"door":     "String",
+				"name":     "String",
+				"ref":      "String",
+			},
+			FieldPrefixes: []string{"name:"},
+		},
+	}
+}
+
 func (m *mapTilesService) cleanLayers(layers mvt.Layers) mvt.Layers {
 	layers.Clip(mvt.MapboxGLDefaultExtentBound)
 	layers.Simplify(simplify.DouglasPeucker(1.0))
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/ptr"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"sync"
+)
+
+// MaxMatrixWaypoints is the maximum number of origins and of destinations of a matrix
+const MaxMatrixWaypoints = 100
+
+var ErrInvalidMatrix = errors.New("invalid matrix")
+
+// Matrix computes the fastest routes from every origin to every destination with one search per origin. The searches
+// run concurrently, but at most one per CPU across all requests.
+func (r *routingService) Matrix(ctx context.Context, origins, destinations []entities.Waypoint, profileName string) (entities.Matrix, error) {
+	if len(origins) == 0 || len(destinations) == 0 {
+		return entities.Matrix{}, fmt.Errorf("%w: expected at least one origin and destination", ErrInvalidMatrix)
+	}
+
+	if len(origins) > MaxMatrixWaypoints || len(destinations) > MaxMatrixWaypoints {
+		return entities.Matrix{}, fmt.Errorf("%w: expected at most %d origins and destinations", ErrInvalidMatrix, MaxMatrixWaypoints)
+	}
+
+	profile, ok := navgraph.Profiles[profileName]
+	if !ok {
+		return entities.Matrix{}, fmt.Errorf("%w: %q", ErrUnknownProfile, profileName)
+	}
+
+	graph, err := r.getGraph(ctx)
+	if err != nil {
+		return entities.Matrix{}, err
+	}
+
+	fromEndpoints, err := waypointEndpoints(graph, origins, "origin")
+	if err != nil {
+		return entities.Matrix{}, err
+	}
+
+	toEndpoints, err := waypointEndpoints(graph, destinations, "destination")
+	if err != nil {
+		return entities.Matrix{}, err
+	}
+
+	out := entities.Matrix{
+		Profile:   profile.Name,
+		Distances: make([][]*float64, len(origins)),
+		Durations: make([][]*float64, len(origins)),
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(origins))
+	for i, from := range fromEndpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case r.searches <- struct{}{}:
+				defer func() { <-r.searches }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+
+			costs, err := graph.ShortestPathCosts(ctx, from, toEndpoints, profile)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			out.Distances[i] = make([]*float64, len(destinations))
+			out.Durations[i] = make([]*float64, len(destinations))
+			for j, cost := range costs {
+				if cost.Found {
+					out.Distances[i][j] = ptr.Ptr(cost.Length)
+					out.Durations[i][j] = ptr.Ptr(cost.Cost)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return entities.Matrix{}, err
+		}
+	}
+
+	return out, nil
+}
+
+func waypointEndpoints(graph *navgraph.Graph, waypoints []entities.Waypoint, name string) ([]navgraph.Endpoint, error) {
+	out := make([]navgraph.Endpoint, 0, len(waypoints))
+	for i, waypoint := range waypoints {
+		endpoint, err := waypointEndpoint(graph, waypoint)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", name, i, err)
+		}
+		out = append(out, endpoint)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulmach/orb/geojson"
+	"math"
+)
+
+const (
+	DefaultReachabilityResolution = 1.0
+	minReachabilityResolution     = 0.25
+	maxReachabilityResolution     = 10.0
+	maxReachabilityMinutes        = 60.0
+	maxReachabilityTimes          = 10
+)
+
+var ErrInvalidReachability = errors.New("invalid reachability parameters")
+
+// Reachability returns the parts of the walkable areas reachable from the waypoint within the minutes, as one
+// multipolygon per level and time. Resolution is the size of the sampled cells in meters.
+func (r *routingService) Reachability(ctx context.Context, from entities.Waypoint, minutes []float64, profileName string, resolution float64) (*geojson.FeatureCollection, error) {
+	if len(minutes) == 0 || len(minutes) > maxReachabilityTimes {
+		return nil, fmt.Errorf("%w: expected 1 to %d times", ErrInvalidReachability, maxReachabilityTimes)
+	}
+
+	costs := make([]float64, 0, len(minutes))
+	for _, m := range minutes {
+		if math.IsNaN(m) || m <= 0 || m > maxReachabilityMinutes {
+			return nil, fmt.Errorf("%w: minutes must be within (0, %v], got %v", ErrInvalidReachability, maxReachabilityMinutes, m)
+		}
+		costs = append(costs, m*60)
+	}
+
+	if math.IsNaN(resolution) || resolution < minReachabilityResolution || resolution > maxReachabilityResolution {
+		return nil, fmt.Errorf("%w: resolution must be within [%v, %v], got %v",
+			ErrInvalidReachability, minReachabilityResolution, maxReachabilityResolution, resolution)
+	}
+
+	profile, ok := navgraph.Profiles[profileName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProfile, profileName)
+	}
+
+	graph, err := r.getGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := waypointEndpoint(graph, from)
+	if err != nil {
+		return nil, err
+	}
+
+	isochrones, ok := graph.Isochrones(endpoint, profile, costs, resolution)
+	if !ok {
+		return nil, fmt.Errorf("%w: the reachable areas are too large for a resolution of %v m", ErrInvalidReachability, resolution)
+	}
+
+	out := geojson.NewFeatureCollection()
+	for _, isochrone := range isochrones {
+		feat := geojson.NewFeature(isochrone.Geometry)
+		feat.Properties["level"] = isochrone.Level
+		feat.Properties["minutes"] = isochrone.Cost / 60
+		out.Append(feat)
+	}
+
+	return out, nil
+}
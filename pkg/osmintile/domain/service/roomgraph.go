@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/osm"
+	"slices"
+)
+
+// GetRoomGraph returns the rooms of the building, whose center lies within its outline, and the doors and openings
+// connecting them. Nodes of the navigation graph attached to multiple rooms connect them, doors as door and all
+// others as opening. Without a level, all levels are returned.
+func (r *routingService) GetRoomGraph(ctx context.Context, buildingID osm.FeatureID, level *float64) (entities.RoomGraph, error) {
+	building, err := r.dataRepository.GetBuilding(ctx, buildingID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return entities.RoomGraph{}, fmt.Errorf("%w: %s", ErrBuildingNotFound, buildingID)
+	}
+	if err != nil {
+		return entities.RoomGraph{}, fmt.Errorf("error getting building: %w", err)
+	}
+
+	graph, err := r.getGraph(ctx)
+	if err != nil {
+		return entities.RoomGraph{}, err
+	}
+
+	outline := building.Outline.Coordinates
+	out := entities.RoomGraph{
+		Building:    osmid.Format(buildingID),
+		Level:       level,
+		Rooms:       make([]entities.RoomGraphRoom, 0),
+		Connections: make([]entities.RoomConnection, 0),
+	}
+
+	nodeRooms := make(map[int64][]int)
+	for _, area := range graph.Areas {
+		if level != nil && area.Level != *level {
+			continue
+		}
+
+		if !outline.Bound().Intersects(area.Geometry.Bound()) || !geoutil.Contains(outline, area.Geometry.Bound().Center()) {
+			continue
+		}
+
+		for _, id := range area.Nodes {
+			nodeRooms[id] = append(nodeRooms[id], len(out.Rooms))
+		}
+
+		out.Rooms = append(out.Rooms, entities.RoomGraphRoom{
+			ID:      roomGraphID(area),
+			Feature: area.ID,
+			Level:   area.Level,
+			Indoor:  area.Tags["indoor"],
+			Name:    area.Tags["name"],
+			Ref:     area.Tags["ref"],
+		})
+	}
+
+	nodeIDs := make([]int64, 0, len(nodeRooms))
+	for id, rooms := range nodeRooms {
+		if len(rooms) > 1 {
+			nodeIDs = append(nodeIDs, id)
+		}
+	}
+	slices.Sort(nodeIDs)
+
+	for _, id := range nodeIDs {
+		node := graph.Nodes[id]
+		kind := "opening"
+		if node.Kind == navgraph.NodeKindDoor {
+			kind = "door"
+		}
+
+		rooms := nodeRooms[id]
+		for i, from := range rooms {
+			for _, to := range rooms[i+1:] {
+				out.Connections = append(out.Connections, entities.RoomConnection{
+					From:  out.Rooms[from].ID,
+					To:    out.Rooms[to].ID,
+					Kind:  kind,
+					Via:   node.Feature,
+					Name:  doorName(node.Tags),
+					Point: [2]float64{node.Point.Lon(), node.Point.Lat()},
+				})
+
+				if kind == "door" {
+					out.Rooms[from].Doors++
+					out.Rooms[to].Doors++
+				}
+			}
+		}
+	}
+
+	for i := range out.Rooms {
+		out.Rooms[i].NoDoor = out.Rooms[i].Doors == 0
+	}
+
+	return out, nil
+}
+
+func roomGraphID(area navgraph.Area) string {
+	return area.ID + "@" + osmlevel.Format(area.Level)
+}
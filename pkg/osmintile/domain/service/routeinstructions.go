@@ -0,0 +1,286 @@
+package service
+
+import (
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulmach/orb/geo"
+	"math"
+	"strings"
+)
+
+const (
+	DefaultInstructionLanguage = "en"
+	// minTurnLength is the length in meters legs must have to count as a turn, shorter ones only step around corners
+	minTurnLength = 1.0
+)
+
+// instructionCatalogs contains the instruction texts per language, values are format strings
+var instructionCatalogs = map[string]map[string]string{
+	"en": {
+		"depart":          "Start in %s",
+		"departIn":        "Start in the %s",
+		"departUnnamed":   "Start walking",
+		"turn":            "Turn %s",
+		"turnAround":      "Turn around",
+		"turnInto":        "Turn %s into %s",
+		"continueInto":    "Continue into %s",
+		"door":            "Pass through door '%s'",
+		"doorUnnamed":     "Pass through the door",
+		"elevator":        "Take the elevator to level %s",
+		"stairsUp":        "Take the stairs up to level %s",
+		"stairsDown":      "Take the stairs down to level %s",
+		"escalatorUp":     "Take the escalator up to level %s",
+		"escalatorDown":   "Take the escalator down to level %s",
+		"arrive":          "Arrive at %s",
+		"arriveUnnamed":   "You have arrived",
+		"unnamedPlace":    "the %s",
+		"left":            "left",
+		"right":           "right",
+		"slight left":     "slightly left",
+		"slight right":    "slightly right",
+		"sharp left":      "sharp left",
+		"sharp right":     "sharp right",
+		"indoor:room":     "room",
+		"indoor:corridor": "corridor",
+		"indoor:area":     "area",
+		"indoor:room:ref": "room %s",
+	},
+	"de": {
+		"depart":          "Starten Sie in %s",
+		"departIn":        "Starten Sie im %s",
+		"departUnnamed":   "Gehen Sie los",
+		"turn":            "Biegen Sie %s ab",
+		"turnAround":      "Drehen Sie um",
+		"turnInto":        "Biegen Sie %s in %s ab",
+		"continueInto":    "Gehen Sie weiter in %s",
+		"door":            "Gehen Sie durch die Tür '%s'",
+		"doorUnnamed":     "Gehen Sie durch die Tür",
+		"elevator":        "Nehmen Sie den Aufzug zur Ebene %s",
+		"stairsUp":        "Nehmen Sie die Treppe nach oben zur Ebene %s",
+		"stairsDown":      "Nehmen Sie die Treppe nach unten zur Ebene %s",
+		"escalatorUp":     "Nehmen Sie die Rolltreppe nach oben zur Ebene %s",
+		"escalatorDown":   "Nehmen Sie die Rolltreppe nach unten zur Ebene %s",
+		"arrive":          "Sie haben %s erreicht",
+		"arriveUnnamed":   "Sie haben Ihr Ziel erreicht",
+		"unnamedPlace":    "den %s",
+		"left":            "links",
+		"right":           "rechts",
+		"slight left":     "leicht links",
+		"slight right":    "leicht rechts",
+		"sharp left":      "scharf links",
+		"sharp right":     "scharf rechts",
+		"indoor:room":     "Raum",
+		"indoor:corridor": "Flur",
+		"indoor:area":     "Bereich",
+		"indoor:room:ref": "Raum %s",
+	},
+}
+
+// instructionBuilder turns the legs of a path into instructions
+type instructionBuilder struct {
+	language string
+	catalog  map[string]string
+	areas    map[string]map[string]string
+	out      []entities.Instruction
+}
+
+// buildInstructions generates the instructions for the path in the language, unknown languages fall back to english
+func buildInstructions(graph *navgraph.Graph, path navgraph.Path, language string) []entities.Instruction {
+	language, _, _ = strings.Cut(strings.ReplaceAll(language, "_", "-"), "-")
+	catalog, ok := instructionCatalogs[language]
+	if !ok {
+		language, catalog = DefaultInstructionLanguage, instructionCatalogs[DefaultInstructionLanguage]
+	}
+
+	b := &instructionBuilder{
+		language: language,
+		catalog:  catalog,
+		areas:    make(map[string]map[string]string, len(graph.Areas)),
+		out:      make([]entities.Instruction, 0),
+	}
+	for _, area := range graph.Areas {
+		b.areas[area.ID] = area.Tags
+	}
+
+	if len(path.Legs) == 0 {
+		return b.out
+	}
+
+	first, last := path.Legs[0], path.Legs[len(path.Legs)-1]
+	currentArea := first.Edge.Feature
+
+	b.add("depart", b.departText(b.endpointTags(first.From, first.Edge)), first.From)
+
+	for i, leg := range path.Legs {
+		if i > 0 {
+			b.addTransition(path.Legs[i-1], leg, &currentArea)
+		}
+		b.out[len(b.out)-1].Distance += leg.Edge.Length
+	}
+
+	if place := b.placeName(b.endpointTags(last.To, last.Edge), "unnamedPlace"); place != "" {
+		b.add("arrive", b.text("arrive", place), last.To)
+	} else {
+		b.add("arrive", b.text("arriveUnnamed"), last.To)
+	}
+
+	return b.out
+}
+
+// addTransition adds the instruction for the node between the legs, if there is anything to say
+func (b *instructionBuilder) addTransition(prev, leg navgraph.Leg, currentArea *string) {
+	node := leg.From
+	nextArea := *currentArea
+	if leg.Edge.Kind == navgraph.EdgeKindWalk && leg.Edge.Feature != "" {
+		nextArea = leg.Edge.Feature
+	}
+	defer func() { *currentArea = nextArea }()
+
+	switch {
+	case leg.From.Level != leg.To.Level:
+		b.add("level_change", b.levelChangeText(leg), node)
+	case prev.From.Level != prev.To.Level:
+		// the level change already told where to go
+	case node.Kind == navgraph.NodeKindDoor:
+		if name := doorName(node.Tags); name != "" {
+			b.add("door", b.text("door", name), node)
+		} else {
+			b.add("door", b.text("doorUnnamed"), node)
+		}
+	default:
+		direction := turnDirection(prev, leg)
+		entering := nextArea != *currentArea && *currentArea != ""
+		switch {
+		case direction == "around":
+			b.add("turn", b.text("turnAround"), node)
+		case entering && direction != "":
+			b.add("turn", b.text("turnInto", b.catalog[direction], b.placeName(b.areas[nextArea], "unnamedPlace")), node)
+		case entering:
+			b.add("continue", b.text("continueInto", b.placeName(b.areas[nextArea], "unnamedPlace")), node)
+		case direction != "":
+			b.add("turn", b.text("turn", b.catalog[direction]), node)
+		}
+	}
+}
+
+func (b *instructionBuilder) levelChangeText(leg navgraph.Leg) string {
+	level := osmlevel.Format(leg.To.Level)
+	if ref := leg.To.Tags["level:ref"]; ref != "" {
+		level = ref
+	}
+
+	direction := "Up"
+	if leg.To.Level < leg.From.Level {
+		direction = "Down"
+	}
+
+	switch leg.Edge.Kind {
+	case navgraph.EdgeKindElevator:
+		return b.text("elevator", level)
+	case navgraph.EdgeKindEscalator:
+		return b.text("escalator"+direction, level)
+	default:
+		return b.text("stairs"+direction, level)
+	}
+}
+
+func (b *instructionBuilder) add(typ string, text string, node navgraph.Node) {
+	b.out = append(b.out, entities.Instruction{
+		Type:  typ,
+		Text:  text,
+		Point: [2]float64{node.Point.Lon(), node.Point.Lat()},
+		Level: node.Level,
+	})
+}
+
+func (b *instructionBuilder) text(key string, args ...any) string {
+	return fmt.Sprintf(b.catalog[key], args...)
+}
+
+// endpointTags returns the tags describing where a path starts or ends, which is either an anchor, a door or the walked area
+func (b *instructionBuilder) endpointTags(node navgraph.Node, edge navgraph.Edge) map[string]string {
+	if node.Kind == navgraph.NodeKindAnchor || node.Kind == navgraph.NodeKindDoor {
+		return node.Tags
+	}
+	return b.areas[edge.Feature]
+}
+
+// placeName names an area by its name in the language, its name, its ref or its kind.
+// Unnamed areas are formatted with the catalog key, as some languages need a different article depending on the sentence.
+func (b *instructionBuilder) placeName(tags map[string]string, unnamedKey string) string {
+	if name := b.namedPlace(tags); name != "" {
+		return name
+	}
+
+	if kind := b.catalog["indoor:"+tags["indoor"]]; kind != "" {
+		return fmt.Sprintf(b.catalog[unnamedKey], kind)
+	}
+
+	return ""
+}
+
+// departText names the start of the path, unnamed places use departIn to contract the article with the preposition
+func (b *instructionBuilder) departText(tags map[string]string) string {
+	if name := b.namedPlace(tags); name != "" {
+		return b.text("depart", name)
+	}
+
+	if kind := b.catalog["indoor:"+tags["indoor"]]; kind != "" {
+		return b.text("departIn", kind)
+	}
+
+	return b.text("departUnnamed")
+}
+
+// namedPlace returns the name or ref of the place, or an empty string if it has neither
+func (b *instructionBuilder) namedPlace(tags map[string]string) string {
+	for _, key := range []string{"name:" + b.language, "name"} {
+		if name := tags[key]; name != "" {
+			return name
+		}
+	}
+
+	if ref := tags["ref"]; ref != "" {
+		return fmt.Sprintf(b.catalog["indoor:room:ref"], ref)
+	}
+
+	return ""
+}
+
+func doorName(tags map[string]string) string {
+	if name := tags["name"]; name != "" {
+		return name
+	}
+	return tags["ref"]
+}
+
+// turnDirection returns the catalog key of the turn between the legs, around for u-turns which have their own
+// sentence, or an empty string when going straight
+func turnDirection(prev, leg navgraph.Leg) string {
+	if prev.Edge.Length < minTurnLength || leg.Edge.Length < minTurnLength {
+		return ""
+	}
+
+	angle := geo.Bearing(leg.From.Point, leg.To.Point) - geo.Bearing(prev.From.Point, prev.To.Point)
+	angle = math.Mod(angle+540, 360) - 180
+
+	side := "right"
+	if angle < 0 {
+		side = "left"
+	}
+
+	switch abs := math.Abs(angle); {
+	case abs < 20:
+		return ""
+	case abs < 45:
+		return "slight " + side
+	case abs < 135:
+		return side
+	case abs < 170:
+		return "sharp " + side
+	default:
+		return "around"
+	}
+}
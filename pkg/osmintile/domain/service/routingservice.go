@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/osm"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const DefaultRoutingProfile = "walking"
+
+var (
+	ErrUnknownProfile   = errors.New("unknown profile")
+	ErrWaypointNotFound = errors.New("waypoint not found")
+	ErrRouteNotFound    = errors.New("route not found")
+)
+
+type RoutingService interface {
+	// Route returns the fastest route between the waypoints for the profile, e.g. walking or wheelchair.
+	// The instructions are in the language, falling back to english if it is not available.
+	Route(ctx context.Context, from, to entities.Waypoint, profile string, language string) (entities.Route, error)
+	// GetGraph returns the nodes and edges of the navigation graph within the bound for debugging.
+	// Without a level, all levels are returned.
+	GetGraph(ctx context.Context, bound orb.Bound, level *float64) (*geojson.FeatureCollection, error)
+	// GetRoomGraph returns which rooms of the building are connected by doors or openings.
+	// Without a level, all levels are returned.
+	GetRoomGraph(ctx context.Context, buildingID osm.FeatureID, level *float64) (entities.RoomGraph, error)
+	// EvacuationRoute returns the fastest route to the nearest emergency exit, elevators are not used
+	EvacuationRoute(ctx context.Context, from entities.Waypoint, language string) (entities.Route, error)
+	// GetEvacuationPlan returns the evacuation paths of all rooms on the level within the bound
+	GetEvacuationPlan(ctx context.Context, level float64, bound orb.Bound) (*geojson.FeatureCollection, error)
+	// Reachability returns per level and time the walkable areas reachable from the waypoint within the minutes
+	Reachability(ctx context.Context, from entities.Waypoint, minutes []float64, profile string, resolution float64) (*geojson.FeatureCollection, error)
+	// Matrix returns the distances and durations of the fastest routes from every origin to every destination
+	Matrix(ctx context.Context, origins, destinations []entities.Waypoint, profile string) (entities.Matrix, error)
+	// ExportGraph writes the navigation graph as geojson, graphml or csv edge list
+	ExportGraph(ctx context.Context, w io.Writer, format string) error
+	// Invalidate drops the cached graph and evacuation paths, it has to be called after the data was imported again.
+	Invalidate()
+}
+
+type routingService struct {
+	dataRepository repository.OsmDataRepository
+	graphMu        sync.Mutex
+	graph          *navgraph.Graph
+	evacuationTree *navgraph.TargetTree
+	// searches limits the concurrent searches of matrices to one per CPU
+	searches chan struct{}
+}
+
+func NewRoutingService(dataRepository repository.OsmDataRepository) RoutingService {
+	return &routingService{
+		dataRepository: dataRepository,
+		searches:       make(chan struct{}, runtime.NumCPU()),
+	}
+}
+
+func (r *routingService) GetGraph(ctx context.Context, bound orb.Bound, level *float64) (*geojson.FeatureCollection, error) {
+	graph, err := r.getGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return graph.GeoJSON(func(node navgraph.Node) bool {
+		return bound.Contains(node.Point) && (level == nil || node.Level == *level)
+	}), nil
+}
+
+func (r *routingService) Route(ctx context.Context, from, to entities.Waypoint, profileName string, language string) (entities.Route, error) {
+	if !languageRegex.MatchString(language) {
+		return entities.Route{}, fmt.Errorf("%w: %q", ErrInvalidLanguage, language)
+	}
+
+	profile, ok := navgraph.Profiles[profileName]
+	if !ok {
+		return entities.Route{}, fmt.Errorf("%w: %q", ErrUnknownProfile, profileName)
+	}
+
+	graph, err := r.getGraph(ctx)
+	if err != nil {
+		return entities.Route{}, err
+	}
+
+	fromEndpoint, err := waypointEndpoint(graph, from)
+	if err != nil {
+		return entities.Route{}, err
+	}
+
+	toEndpoint, err := waypointEndpoint(graph, to)
+	if err != nil {
+		return entities.Route{}, err
+	}
+
+	path, ok := graph.ShortestPath(fromEndpoint, toEndpoint, profile)
+	if !ok {
+		return entities.Route{}, fmt.Errorf("%w: no %s route between the waypoints", ErrRouteNotFound, profile.Name)
+	}
+
+	route := buildRoute(path, profile)
+	route.Instructions = buildInstructions(graph, path, language)
+
+	return route, nil
+}
+
+// waypointEndpoint finds the waypoint in the graph
+func waypointEndpoint(graph *navgraph.Graph, waypoint entities.Waypoint) (navgraph.Endpoint, error) {
+	if waypoint.Feature != 0 {
+		endpoint, ok := graph.FeatureEndpoint(osmid.Format(waypoint.Feature))
+		if !ok {
+			return navgraph.Endpoint{}, fmt.Errorf("%w: %s is not part of the navigation graph", ErrWaypointNotFound, waypoint.Feature)
+		}
+		return endpoint, nil
+	}
+
+	endpoint, ok := graph.PointEndpoint(waypoint.Point, waypoint.Level)
+	if !ok {
+		return navgraph.Endpoint{}, fmt.Errorf("%w: no walkable area at %v on level %s",
+			ErrWaypointNotFound, waypoint.Point, osmlevel.Format(waypoint.Level))
+	}
+	return endpoint, nil
+}
+
+// buildRoute splits the path into segments per level, which are separated by level changes
+func buildRoute(path navgraph.Path, profile navgraph.Profile) entities.Route {
+	route := entities.Route{
+		Profile:      profile.Name,
+		Distance:     path.Length,
+		Duration:     path.Cost,
+		Segments:     geojson.NewFeatureCollection(),
+		LevelChanges: make([]entities.LevelChange, 0),
+	}
+
+	var line orb.LineString
+	var distance, duration float64
+	flush := func(level float64) {
+		if len(line) < 2 {
+			line, distance, duration = nil, 0, 0
+			return
+		}
+
+		feat := geojson.NewFeature(line)
+		feat.Properties["level"] = level
+		feat.Properties["distance"] = distance
+		feat.Properties["duration"] = duration
+		route.Segments.Append(feat)
+		line, distance, duration = nil, 0, 0
+	}
+
+	for _, leg := range path.Legs {
+		if leg.From.Level != leg.To.Level {
+			flush(leg.From.Level)
+			route.LevelChanges = append(route.LevelChanges, entities.LevelChange{
+				Kind:      string(leg.Edge.Kind),
+				FromLevel: leg.From.Level,
+				ToLevel:   leg.To.Level,
+				Point:     [2]float64{leg.From.Point.Lon(), leg.From.Point.Lat()},
+				Feature:   leg.Edge.Feature,
+				Segment:   max(len(route.Segments.Features)-1, 0),
+				Duration:  leg.Edge.Cost,
+			})
+			continue
+		}
+
+		if len(line) == 0 {
+			line = append(line, leg.From.Point)
+		}
+		line = append(line, leg.To.Point)
+		distance += leg.Edge.Length
+		duration += leg.Edge.Cost
+	}
+
+	if len(path.Legs) > 0 {
+		flush(path.Legs[len(path.Legs)-1].To.Level)
+	}
+
+	return route
+}
+
+func (r *routingService) Invalidate() {
+	r.graphMu.Lock()
+	defer r.graphMu.Unlock()
+
+	r.graph = nil
+	r.evacuationTree = nil
+}
+
+// getGraph loads the graph on first use, it is cached until Invalidate is called
+func (r *routingService) getGraph(ctx context.Context) (*navgraph.Graph, error) {
+	r.graphMu.Lock()
+	defer r.graphMu.Unlock()
+
+	if r.graph != nil {
+		return r.graph, nil
+	}
+
+	graph, err := r.dataRepository.GetNavGraph(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting navigation graph: %w", err)
+	}
+
+	r.graph = graph
+	return graph, nil
+}
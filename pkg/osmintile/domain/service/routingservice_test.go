@@ -0,0 +1,402 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/osm"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type fakeRoutingRepository struct {
+	fakeOsmDataRepository
+	graph *navgraph.Graph
+}
+
+func (f fakeRoutingRepository) GetNavGraph(context.Context) (*navgraph.Graph, error) {
+	return f.graph, nil
+}
+
+func (f fakeRoutingRepository) GetBuilding(_ context.Context, id osm.FeatureID) (entities.Building, error) {
+	if id != osm.WayID(100).FeatureID() {
+		return entities.Building{}, repository.ErrNotFound
+	}
+	return entities.Building{ID: "w100", Outline: geojson.NewGeometry(square(0.001))}, nil
+}
+
+// newTestNavGraph returns two corridors on levels 0 and 1, connected by stairs at their west end
+func newTestNavGraph() *navgraph.Graph {
+	builder := navgraph.NewBuilder()
+	for _, level := range []string{"0", "1"} {
+		builder.AddArea("w"+level, map[string]string{"indoor": "corridor", "level": level}, square(0.001))
+	}
+	builder.AddWay("w3", []navgraph.WayNode{{ID: 1, Point: orb.Point{0.0001, 0.0005}}, {ID: 2, Point: orb.Point{0.0002, 0.0005}}},
+		map[string]string{"highway": "steps", "level": "0;1"})
+	return builder.Build()
+}
+
+func TestRoutingService_Route(t *testing.T) {
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: newTestNavGraph()})
+
+	from := entities.Waypoint{Point: orb.Point{0.0009, 0.0001}, Level: 0}
+	to := entities.Waypoint{Point: orb.Point{0.0009, 0.0009}, Level: 1}
+
+	route, err := svc.Route(context.Background(), from, to, service.DefaultRoutingProfile, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(route.Segments.Features) != 2 || len(route.LevelChanges) != 1 {
+		t.Fatalf("expected 2 segments and 1 level change, got %d and %d", len(route.Segments.Features), len(route.LevelChanges))
+	}
+
+	change := route.LevelChanges[0]
+	if change.Kind != "stairs" || change.FromLevel != 0 || change.ToLevel != 1 || change.Feature != "w3" || change.Segment != 0 {
+		t.Errorf("unexpected level change %+v", change)
+	}
+
+	if route.Distance <= 0 || route.Duration <= 0 {
+		t.Errorf("expected a distance and duration, got %v and %v", route.Distance, route.Duration)
+	}
+
+	_, err = svc.Route(context.Background(), from, to, "wheelchair", "en")
+	if !errors.Is(err, service.ErrRouteNotFound) {
+		t.Errorf("expected ErrRouteNotFound for wheelchair, got %v", err)
+	}
+
+	_, err = svc.Route(context.Background(), from, to, "bicycle", "en")
+	if !errors.Is(err, service.ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+
+	_, err = svc.Route(context.Background(), entities.Waypoint{Point: orb.Point{1, 1}}, to, service.DefaultRoutingProfile, "en")
+	if !errors.Is(err, service.ErrWaypointNotFound) {
+		t.Errorf("expected ErrWaypointNotFound, got %v", err)
+	}
+}
+
+func TestRoutingService_RouteStartingWithLevelChange(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	builder.AddPoint("n5", orb.Point{0.0001, 0.0001}, map[string]string{"highway": "elevator", "level": "0;1"})
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: builder.Build()})
+
+	from := entities.Waypoint{Feature: osm.NodeID(5).FeatureID()}
+	to := entities.Waypoint{Point: orb.Point{0.0002, 0.0001}, Level: 1}
+
+	route, err := svc.Route(context.Background(), from, to, service.DefaultRoutingProfile, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(route.LevelChanges) != 1 || route.LevelChanges[0].Segment != 0 {
+		t.Errorf("expected the level change at the start to reference the first segment, got %+v", route.LevelChanges)
+	}
+}
+
+func TestRoutingService_RouteInstructions(t *testing.T) {
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: newTestNavGraph()})
+
+	from := entities.Waypoint{Point: orb.Point{0.0009, 0.0001}, Level: 0}
+	to := entities.Waypoint{Point: orb.Point{0.0009, 0.0009}, Level: 1}
+
+	tests := []struct {
+		language string
+		depart   string
+		stairs   string
+		arrive   string
+	}{
+		{"en", "Start in the corridor", "Take the stairs up to level 1", "Arrive at the corridor"},
+		{"de-AT", "Starten Sie im Flur", "Nehmen Sie die Treppe nach oben zur Ebene 1", "Sie haben den Flur erreicht"},
+		{"fr", "Start in the corridor", "Take the stairs up to level 1", "Arrive at the corridor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			route, err := svc.Route(context.Background(), from, to, service.DefaultRoutingProfile, tt.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			texts := make([]string, 0, len(route.Instructions))
+			distance := 0.0
+			for _, instruction := range route.Instructions {
+				texts = append(texts, instruction.Text)
+				distance += instruction.Distance
+			}
+
+			if len(texts) < 3 || texts[0] != tt.depart || texts[len(texts)-1] != tt.arrive || !slices.Contains(texts, tt.stairs) {
+				t.Errorf("unexpected instructions %q", texts)
+			}
+
+			if math.Abs(distance-route.Distance) > 1e-6 {
+				t.Errorf("expected the instructions to cover %v m, got %v m", route.Distance, distance)
+			}
+		})
+	}
+
+	_, err := svc.Route(context.Background(), from, to, service.DefaultRoutingProfile, "not a language")
+	if !errors.Is(err, service.ErrInvalidLanguage) {
+		t.Errorf("expected ErrInvalidLanguage, got %v", err)
+	}
+}
+
+func TestRoutingService_RouteInstructionsTurnAround(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	builder.AddWay("w1", []navgraph.WayNode{{ID: 1, Point: orb.Point{0, 0}}, {ID: 2, Point: orb.Point{0.0001, 0}}, {ID: 3, Point: orb.Point{0, 0.000001}}},
+		map[string]string{"highway": "footway", "level": "0"})
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: builder.Build()})
+
+	from := entities.Waypoint{Point: orb.Point{0, 0}, Level: 0}
+	to := entities.Waypoint{Point: orb.Point{0, 0.000001}, Level: 0}
+
+	for language, text := range map[string]string{"en": "Turn around", "de": "Drehen Sie um"} {
+		route, err := svc.Route(context.Background(), from, to, service.DefaultRoutingProfile, language)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.ContainsFunc(route.Instructions, func(instruction entities.Instruction) bool { return instruction.Text == text }) {
+			t.Errorf("expected %q in the %s instructions, got %+v", text, language, route.Instructions)
+		}
+	}
+}
+
+func TestRoutingService_GetRoomGraph(t *testing.T) {
+	rect := func(minX, maxX float64) orb.Polygon {
+		return orb.Polygon{{{minX, 0}, {maxX, 0}, {maxX, 0.0001}, {minX, 0.0001}, {minX, 0}}}
+	}
+
+	builder := navgraph.NewBuilder()
+	builder.AddArea("w1", map[string]string{"indoor": "room", "level": "0", "ref": "1.01"}, rect(0, 0.0001))
+	builder.AddArea("w2", map[string]string{"indoor": "corridor", "level": "0"}, rect(0.0001, 0.0002))
+	builder.AddArea("w3", map[string]string{"indoor": "room", "level": "0"}, rect(0.0003, 0.0004))
+	builder.AddArea("w4", map[string]string{"indoor": "room", "level": "1"}, rect(0, 0.0001))
+	builder.AddPoint("n5", orb.Point{0.0001, 0.00005}, map[string]string{"door": "hinged", "level": "0", "name": "Gate B"})
+
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: builder.Build()})
+
+	level := 0.0
+	roomGraph, err := svc.GetRoomGraph(context.Background(), osm.WayID(100).FeatureID(), &level)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roomGraph.Rooms) != 3 {
+		t.Fatalf("expected 3 rooms on level 0, got %+v", roomGraph.Rooms)
+	}
+
+	expected := []entities.RoomConnection{
+		{From: "w1@0", To: "w2@0", Kind: "door", Via: "n5", Name: "Gate B", Point: [2]float64{0.0001, 0.00005}},
+	}
+	if !reflect.DeepEqual(roomGraph.Connections, expected) {
+		t.Errorf("expected connections %+v, got %+v", expected, roomGraph.Connections)
+	}
+
+	noDoor := make(map[string]bool)
+	for _, room := range roomGraph.Rooms {
+		noDoor[room.ID] = room.NoDoor
+	}
+	if !reflect.DeepEqual(noDoor, map[string]bool{"w1@0": false, "w2@0": false, "w3@0": true}) {
+		t.Errorf("unexpected no_door flags %v", noDoor)
+	}
+
+	_, err = svc.GetRoomGraph(context.Background(), osm.WayID(101).FeatureID(), nil)
+	if !errors.Is(err, service.ErrBuildingNotFound) {
+		t.Errorf("expected ErrBuildingNotFound, got %v", err)
+	}
+}
+
+func TestRoutingService_Evacuation(t *testing.T) {
+	builder := navgraph.NewBuilder()
+	builder.AddArea("w1", map[string]string{"indoor": "corridor", "level": "0"}, square(0.001))
+	builder.AddArea("w2", map[string]string{"indoor": "room", "level": "0", "name": "Office"},
+		orb.Polygon{{{0.0011, 0}, {0.002, 0}, {0.002, 0.001}, {0.0011, 0.001}, {0.0011, 0}}})
+	builder.AddArea("w3", map[string]string{"indoor": "room", "level": "0", "name": "Storage"},
+		orb.Polygon{{{0.003, 0}, {0.004, 0}, {0.004, 0.001}, {0.003, 0.001}, {0.003, 0}}})
+	builder.AddPoint("n4", orb.Point{0, 0.0005}, map[string]string{"entrance": "emergency", "level": "0"})
+	builder.AddWay("w5", []navgraph.WayNode{{ID: 6, Point: orb.Point{0.0009, 0.0005}}, {ID: 7, Point: orb.Point{0.0012, 0.0005}}},
+		map[string]string{"highway": "footway", "level": "0"})
+
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: builder.Build()})
+
+	route, err := svc.EvacuationRoute(context.Background(), entities.Waypoint{Point: orb.Point{0.0015, 0.0005}}, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if route.Profile != "evacuation" || route.Distance < 150 {
+		t.Errorf("expected an evacuation route of at least 150 m, got %s with %v m", route.Profile, route.Distance)
+	}
+
+	_, err = svc.EvacuationRoute(context.Background(), entities.Waypoint{Point: orb.Point{0.0035, 0.0005}}, "en")
+	if !errors.Is(err, service.ErrRouteNotFound) {
+		t.Errorf("expected ErrRouteNotFound from the storage, got %v", err)
+	}
+
+	plan, err := svc.GetEvacuationPlan(context.Background(), 0, orb.Bound{Min: orb.Point{-1, -1}, Max: orb.Point{1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reachable := make(map[string]any)
+	arrows := 0
+	for _, feat := range plan.Features {
+		switch feat.Properties["type"] {
+		case "room":
+			reachable[feat.Properties.MustString("name")] = feat.Properties["reachable"]
+		case "arrow":
+			arrows++
+		}
+	}
+
+	if !reflect.DeepEqual(reachable, map[string]any{"Office": true, "Storage": false}) {
+		t.Errorf("unexpected reachable rooms %v", reachable)
+	}
+
+	if arrows == 0 {
+		t.Error("expected evacuation arrows")
+	}
+}
+
+func TestRoutingService_Reachability(t *testing.T) {
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: newTestNavGraph()})
+	from := entities.Waypoint{Point: orb.Point{0.0009, 0.0001}, Level: 0}
+
+	reachability, err := svc.Reachability(context.Background(), from, []float64{1, 2}, service.DefaultRoutingProfile, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	levels := make(map[float64]int)
+	for _, feat := range reachability.Features {
+		levels[feat.Properties["level"].(float64)]++
+	}
+
+	if levels[0] != 2 || levels[1] == 0 {
+		t.Errorf("expected isochrones on both levels, got %v", levels)
+	}
+
+	for _, minutes := range [][]float64{nil, {0}, {61}, {math.NaN()}} {
+		_, err = svc.Reachability(context.Background(), from, minutes, service.DefaultRoutingProfile, 5)
+		if !errors.Is(err, service.ErrInvalidReachability) {
+			t.Errorf("expected ErrInvalidReachability for %v, got %v", minutes, err)
+		}
+	}
+
+	for _, resolution := range []float64{0.1, math.NaN()} {
+		_, err = svc.Reachability(context.Background(), from, []float64{1}, service.DefaultRoutingProfile, resolution)
+		if !errors.Is(err, service.ErrInvalidReachability) {
+			t.Errorf("expected ErrInvalidReachability for the resolution %v, got %v", resolution, err)
+		}
+	}
+}
+
+func TestRoutingService_Matrix(t *testing.T) {
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: newTestNavGraph()})
+
+	a := entities.Waypoint{Point: orb.Point{0.0009, 0.0001}, Level: 0}
+	b := entities.Waypoint{Point: orb.Point{0.0009, 0.0009}, Level: 0}
+	c := entities.Waypoint{Point: orb.Point{0.0009, 0.0009}, Level: 1}
+
+	matrix, err := svc.Matrix(context.Background(), []entities.Waypoint{a, b}, []entities.Waypoint{a, b, c}, "wheelchair")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(matrix.Distances) != 2 || len(matrix.Durations[1]) != 3 {
+		t.Fatalf("expected a 2x3 matrix, got %v", matrix.Distances)
+	}
+
+	if d := matrix.Distances[0][1]; d == nil || *d < 80 || *d > 100 {
+		t.Errorf("expected about 89 m from a to b, got %v", d)
+	}
+
+	if matrix.Distances[0][0] == nil || *matrix.Distances[0][0] != 0 {
+		t.Errorf("expected no distance from a to itself, got %v", matrix.Distances[0][0])
+	}
+
+	// wheelchairs can not use the stairs
+	if matrix.Distances[0][2] != nil || matrix.Durations[1][2] != nil {
+		t.Errorf("expected no route to the upper level, got %v", matrix.Distances)
+	}
+
+	_, err = svc.Matrix(context.Background(), nil, []entities.Waypoint{a}, service.DefaultRoutingProfile)
+	if !errors.Is(err, service.ErrInvalidMatrix) {
+		t.Errorf("expected ErrInvalidMatrix, got %v", err)
+	}
+
+	_, err = svc.Matrix(context.Background(), []entities.Waypoint{a}, []entities.Waypoint{{Point: orb.Point{1, 1}}}, service.DefaultRoutingProfile)
+	if !errors.Is(err, service.ErrWaypointNotFound) {
+		t.Errorf("expected ErrWaypointNotFound, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = svc.Matrix(ctx, []entities.Waypoint{a, b}, []entities.Waypoint{a, b, c}, service.DefaultRoutingProfile)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRoutingService_ExportGraph(t *testing.T) {
+	svc := service.NewRoutingService(fakeRoutingRepository{graph: newTestNavGraph()})
+
+	for _, path := range []string{"graph.geojson", "graph.GraphML", "edges.csv"} {
+		format, err := service.GraphExportFormat(path)
+		if err != nil {
+			t.Errorf("GraphExportFormat(%q) failed: %v", path, err)
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := svc.ExportGraph(context.Background(), &buf, format); err != nil || buf.Len() == 0 {
+			t.Errorf("expected %s export, got %d bytes and %v", format, buf.Len(), err)
+		}
+	}
+
+	if _, err := service.GraphExportFormat("graph.xlsx"); !errors.Is(err, service.ErrUnknownExportFormat) {
+		t.Errorf("expected ErrUnknownExportFormat, got %v", err)
+	}
+}
+
+type countingRoutingRepository struct {
+	fakeRoutingRepository
+	loads *int
+}
+
+func (f countingRoutingRepository) GetNavGraph(ctx context.Context) (*navgraph.Graph, error) {
+	*f.loads++
+	return f.fakeRoutingRepository.GetNavGraph(ctx)
+}
+
+func TestRoutingService_Invalidate(t *testing.T) {
+	loads := 0
+	svc := service.NewRoutingService(countingRoutingRepository{fakeRoutingRepository{graph: newTestNavGraph()}, &loads})
+	bound := orb.Bound{Min: orb.Point{-1, -1}, Max: orb.Point{1, 1}}
+
+	for range 2 {
+		if _, err := svc.GetGraph(context.Background(), bound, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("expected the graph to be loaded once, got %d loads", loads)
+	}
+
+	svc.Invalidate()
+	if _, err := svc.GetGraph(context.Background(), bound, nil); err != nil {
+		t.Fatal(err)
+	}
+	if loads != 2 {
+		t.Errorf("expected the graph to be loaded again after Invalidate, got %d loads", loads)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"strings"
+)
+
+const (
+	DefaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var (
+	ErrInvalidSearchQuery = errors.New("invalid search query")
+	ErrSearchUnavailable  = errors.New("search is unavailable")
+)
+
+type SearchService interface {
+	// Search returns the features matching the query, best match first. Without a level, all levels are searched.
+	Search(ctx context.Context, query string, bound orb.Bound, level *float64, limit int) (*geojson.FeatureCollection, error)
+}
+
+type searchService struct {
+	dataRepository repository.OsmDataRepository
+}
+
+func NewSearchService(dataRepository repository.OsmDataRepository) SearchService {
+	return &searchService{
+		dataRepository: dataRepository,
+	}
+}
+
+func (s *searchService) Search(ctx context.Context, query string, bound orb.Bound, level *float64, limit int) (*geojson.FeatureCollection, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("%w: query is empty", ErrInvalidSearchQuery)
+	}
+
+	if limit <= 0 || limit > maxSearchLimit {
+		return nil, fmt.Errorf("%w: limit %d must be in [1, %d]", ErrInvalidSearchQuery, limit, maxSearchLimit)
+	}
+
+	features, err := s.dataRepository.Search(ctx, query, bound, level, limit)
+	if errors.Is(err, repository.ErrSearchDisabled) {
+		return nil, fmt.Errorf("%w: %w", ErrSearchUnavailable, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error searching: %w", err)
+	}
+
+	out := geojson.NewFeatureCollection()
+	for _, feat := range features {
+		levels, err := osmlevel.Parse(feat.Properties.MustString("level", ""))
+		if err != nil {
+			levels = nil
+		}
+		feat.Properties["levels"] = levels
+
+		out.Append(feat)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/ptr"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"reflect"
+	"testing"
+)
+
+type fakeSearchRepository struct {
+	fakeOsmDataRepository
+	results []*geojson.Feature
+	err     error
+	// level and limit are the arguments of the last search
+	level *float64
+	limit int
+}
+
+func (f *fakeSearchRepository) Search(_ context.Context, _ string, _ orb.Bound, level *float64, limit int) ([]*geojson.Feature, error) {
+	f.level, f.limit = level, limit
+	return f.results, f.err
+}
+
+func TestSearchService_Search(t *testing.T) {
+	repo := &fakeSearchRepository{results: []*geojson.Feature{
+		newTestFeature("w1", orb.Point{0, 0}, "0;1"),
+		newTestFeature("n2", orb.Point{1, 1}, "roof"),
+	}}
+	svc := service.NewSearchService(repo)
+
+	results, err := svc.Search(context.Background(), "cafe", orb.Bound{}, ptr.Ptr(1.0), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the level filter is applied by the repository, which gets the requested limit
+	if repo.level == nil || *repo.level != 1 || repo.limit != 5 {
+		t.Errorf("expected the level 1 and the limit 5 to be passed, got %v and %d", repo.level, repo.limit)
+	}
+
+	if len(results.Features) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results.Features))
+	}
+
+	if levels := results.Features[0].Properties["levels"]; !reflect.DeepEqual(levels, []float64{0, 1}) {
+		t.Errorf("expected levels [0 1], got %v", levels)
+	}
+
+	if levels, _ := results.Features[1].Properties["levels"].([]float64); len(levels) != 0 {
+		t.Errorf("expected no levels for an invalid level tag, got %v", levels)
+	}
+}
+
+func TestSearchService_Search_Invalid(t *testing.T) {
+	svc := service.NewSearchService(&fakeSearchRepository{})
+
+	for name, limit := range map[string]int{"zero limit": 0, "limit too large": 101} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.Search(context.Background(), "cafe", orb.Bound{}, nil, limit)
+			if !errors.Is(err, service.ErrInvalidSearchQuery) {
+				t.Errorf("expected ErrInvalidSearchQuery, got %v", err)
+			}
+		})
+	}
+
+	_, err := svc.Search(context.Background(), "  ", orb.Bound{}, nil, 5)
+	if !errors.Is(err, service.ErrInvalidSearchQuery) {
+		t.Errorf("expected ErrInvalidSearchQuery for an empty query, got %v", err)
+	}
+}
+
+func TestSearchService_Search_Disabled(t *testing.T) {
+	svc := service.NewSearchService(&fakeSearchRepository{err: repository.ErrSearchDisabled})
+
+	_, err := svc.Search(context.Background(), "cafe", orb.Bound{}, nil, 5)
+	if !errors.Is(err, service.ErrSearchUnavailable) {
+		t.Errorf("expected ErrSearchUnavailable, got %v", err)
+	}
+}
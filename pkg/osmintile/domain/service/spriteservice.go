@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/sprite"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"io/fs"
+)
+
+var ErrUnsupportedPixelRatio = errors.New("unsupported pixel ratio")
+
+// spritePixelRatios are the pixel ratios sprite sheets are generated for, MapLibre requests @2x on high dpi screens
+var spritePixelRatios = []int{1, 2}
+
+type SpriteService interface {
+	GetSprite(ctx context.Context, pixelRatio int) (entities.Sprite, error)
+}
+
+type spriteService struct {
+	sprites map[int]entities.Sprite
+}
+
+// NewSpriteService rasterizes the svg icons in iconFS into sprite sheets for all supported pixel ratios.
+func NewSpriteService(iconFS fs.FS) (SpriteService, error) {
+	sprites := make(map[int]entities.Sprite, len(spritePixelRatios))
+
+	for _, pixelRatio := range spritePixelRatios {
+		sheet, err := sprite.Build(iconFS, pixelRatio)
+		if err != nil {
+			return nil, fmt.Errorf("error building sprite sheet @%dx: %w", pixelRatio, err)
+		}
+
+		index, err := json.Marshal(sheet.Index)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling sprite index @%dx: %w", pixelRatio, err)
+		}
+
+		image, err := sheet.PNG()
+		if err != nil {
+			return nil, fmt.Errorf("error encoding sprite sheet @%dx: %w", pixelRatio, err)
+		}
+
+		sprites[pixelRatio] = entities.Sprite{
+			Index: index,
+			Image: image,
+		}
+	}
+
+	return &spriteService{
+		sprites: sprites,
+	}, nil
+}
+
+func (s *spriteService) GetSprite(_ context.Context, pixelRatio int) (entities.Sprite, error) {
+	out, ok := s.sprites[pixelRatio]
+	if !ok {
+		return entities.Sprite{}, fmt.Errorf("%w: %d", ErrUnsupportedPixelRatio, pixelRatio)
+	}
+
+	return out, nil
+}
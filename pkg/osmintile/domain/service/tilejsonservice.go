@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"strings"
+)
+
+const (
+	tileJSONVersion     = "3.0.0"
+	tileJSONAttribution = "©Openstreetmap Contributors"
+	tileJSONCenterZoom  = 16
+)
+
+type TileJSONService interface {
+	GetTileJSON(ctx context.Context, level int) (entities.TileJSON, error)
+}
+
+type tileJSONService struct {
+	publicUrl      string
+	tilesService   MapTilesService
+	dataRepository repository.OsmDataRepository
+}
+
+func NewTileJSONService(publicUrl string, tilesService MapTilesService, dataRepository repository.OsmDataRepository) TileJSONService {
+	return &tileJSONService{
+		publicUrl:      publicUrl,
+		tilesService:   tilesService,
+		dataRepository: dataRepository,
+	}
+}
+
+func (t *tileJSONService) GetTileJSON(ctx context.Context, level int) (entities.TileJSON, error) {
+	bound, err := t.dataRepository.GetMapBounds(ctx)
+	if err != nil {
+		return entities.TileJSON{}, fmt.Errorf("error getting map bounds: %w", err)
+	}
+
+	center, err := t.dataRepository.GetMapCenter(ctx)
+	if err != nil {
+		return entities.TileJSON{}, fmt.Errorf("error getting map center: %w", err)
+	}
+
+	vectorLayers := t.tilesService.GetVectorLayers()
+
+	out := entities.TileJSON{
+		TileJSON:     tileJSONVersion,
+		Name:         fmt.Sprintf("OsmInTile level %d", level),
+		Description:  "Indoor vector tiles generated from OpenStreetMap data",
+		Attribution:  tileJSONAttribution,
+		Scheme:       "xyz",
+		Tiles:        []string{fmt.Sprintf("%s/tiles/%d/{z}/{x}/{y}", t.publicUrl, level)},
+		MinZoom:      tilesMinZoom,
+		MaxZoom:      tilesMaxZoom,
+		Bounds:       boundToArray(bound),
+		Center:       [3]float64{center.Lon(), center.Lat(), tileJSONCenterZoom},
+		VectorLayers: make([]entities.TileJSONVectorLayer, 0, len(vectorLayers)),
+	}
+
+	for _, layer := range vectorLayers {
+		fields := make(map[string]string, len(layer.Fields))
+		for name, typ := range layer.Fields {
+			fields[name] = typ
+		}
+
+		// attributes with dynamic names are no fields of their own, clients would list them as real attributes
+		description := layer.Description
+		if len(layer.FieldPrefixes) > 0 {
+			description += fmt.Sprintf(", with additional attributes starting with %s", strings.Join(layer.FieldPrefixes, ", "))
+		}
+
+		out.VectorLayers = append(out.VectorLayers, entities.TileJSONVectorLayer{
+			ID:          layer.ID,
+			Fields:      fields,
+			Description: description,
+			MinZoom:     layer.MinZoom,
+			MaxZoom:     layer.MaxZoom,
+		})
+	}
+
+	return out, nil
+}
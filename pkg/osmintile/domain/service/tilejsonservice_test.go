@@ -0,0 +1,56 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTileJSONService_GetTileJSON(t *testing.T) {
+	repo := fakeOsmDataRepository{}
+	svc := service.NewTileJSONService("http://localhost:8080", service.NewMapTilesService(repo), repo)
+
+	for _, level := range []int{0, -1, 2} {
+		tileJSON, err := svc.GetTileJSON(context.Background(), level)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tileJSON.Bounds != [4]float64{11.56, 48.13, 11.57, 48.14} {
+			t.Errorf("expected the bounds of the data, got %v", tileJSON.Bounds)
+		}
+
+		if tileJSON.Center[0] != 11.565 || tileJSON.Center[1] != 48.135 || tileJSON.Center[2] < float64(tileJSON.MinZoom) {
+			t.Errorf("expected the center of the data at a zoom with tiles, got %v", tileJSON.Center)
+		}
+
+		want := fmt.Sprintf("http://localhost:8080/tiles/%d/{z}/{x}/{y}", level)
+		if !slices.Equal(tileJSON.Tiles, []string{want}) {
+			t.Errorf("expected the tiles %s, got %v", want, tileJSON.Tiles)
+		}
+
+		fields := make(map[string][]string)
+		for _, layer := range tileJSON.VectorLayers {
+			for name := range layer.Fields {
+				fields[layer.ID] = append(fields[layer.ID], name)
+			}
+			slices.Sort(fields[layer.ID])
+
+			if !strings.Contains(layer.Description, "name:") {
+				t.Errorf("expected the description of %s to mention the name: attributes, got %q", layer.ID, layer.Description)
+			}
+		}
+
+		expected := map[string][]string{
+			"indoor-base": {"indoor", "name", "ref", "room"},
+			"indoor-poi":  {"amenity", "door", "entrance", "highway", "name", "ref", "shop"},
+		}
+		if !maps.EqualFunc(fields, expected, slices.Equal) {
+			t.Errorf("expected the fields %v, got %v", expected, fields)
+		}
+	}
+}
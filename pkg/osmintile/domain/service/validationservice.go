@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/indoorvalidation"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"sync"
+)
+
+// validationLayer is the name of the debug layer of the validation tiles
+const validationLayer = "validation"
+
+type ValidationService interface {
+	// Validate checks the imported indoor data, the result is cached until Invalidate is called.
+	Validate(ctx context.Context) (entities.ValidationReport, error)
+	// GetValidationGeoJSON returns the located issues as features, properties are type, feature, level and message.
+	GetValidationGeoJSON(ctx context.Context) (*geojson.FeatureCollection, error)
+	// GetValidationTile renders the located issues within the tile into the validation layer.
+	GetValidationTile(ctx context.Context, tile maptile.Tile, acceptGzip bool) ([]byte, error)
+	// GetGeometryRepairs returns the features whose polygons were repaired at import.
+	GetGeometryRepairs(ctx context.Context) ([]entities.GeometryRepair, error)
+	// Invalidate drops the cached issues, it has to be called after the data was imported again.
+	Invalidate()
+}
+
+type validationService struct {
+	dataRepository repository.OsmDataRepository
+
+	issuesMu sync.Mutex
+	issues   []indoorvalidation.Issue
+}
+
+func NewValidationService(dataRepository repository.OsmDataRepository) ValidationService {
+	return &validationService{
+		dataRepository: dataRepository,
+	}
+}
+
+func (v *validationService) Validate(ctx context.Context) (entities.ValidationReport, error) {
+	issues, err := v.getIssues(ctx)
+	if err != nil {
+		return entities.ValidationReport{}, err
+	}
+
+	report := entities.ValidationReport{
+		Issues: make([]entities.ValidationIssue, 0, len(issues)),
+		Counts: make(map[string]int),
+	}
+	for _, issue := range issues {
+		out := entities.ValidationIssue{
+			Type:    string(issue.Type),
+			Feature: osmid.Format(issue.Feature),
+			Level:   issue.Level,
+			Message: issue.Message,
+		}
+		if issue.Location != nil {
+			out.Location = geojson.NewGeometry(issue.Location)
+		}
+
+		report.Issues = append(report.Issues, out)
+		report.Counts[out.Type]++
+	}
+
+	return report, nil
+}
+
+func (v *validationService) GetValidationGeoJSON(ctx context.Context) (*geojson.FeatureCollection, error) {
+	issues, err := v.getIssues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := geojson.NewFeatureCollection()
+	for _, issue := range issues {
+		if issue.Location != nil {
+			out.Append(issueFeature(issue))
+		}
+	}
+
+	return out, nil
+}
+
+func (v *validationService) GetValidationTile(ctx context.Context, tile maptile.Tile, acceptGzip bool) ([]byte, error) {
+	issues, err := v.getIssues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bound := tile.Bound(1)
+	collection := geojson.NewFeatureCollection()
+	for _, issue := range issues {
+		if issue.Location == nil || !issue.Location.Bound().Intersects(bound) {
+			continue
+		}
+
+		// projecting to the tile modifies the geometries, which must not change the cached issues
+		feat := issueFeature(issue)
+		feat.Geometry = orb.Clone(feat.Geometry)
+		collection.Append(feat)
+	}
+
+	layers := mvt.NewLayers(map[string]*geojson.FeatureCollection{validationLayer: collection})
+	layers.ProjectToTile(tile)
+	layers.Clip(mvt.MapboxGLDefaultExtentBound)
+
+	var data []byte
+	if acceptGzip {
+		data, err = mvt.MarshalGzipped(layers)
+	} else {
+		data, err = mvt.Marshal(layers)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal layers failed: %w", err)
+	}
+
+	return data, nil
+}
+
+func (v *validationService) GetGeometryRepairs(ctx context.Context) ([]entities.GeometryRepair, error) {
+	repairs, err := v.dataRepository.GetGeometryRepairs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting geometry repairs: %w", err)
+	}
+	return repairs, nil
+}
+
+func (v *validationService) Invalidate() {
+	v.issuesMu.Lock()
+	defer v.issuesMu.Unlock()
+
+	v.issues = nil
+}
+
+func (v *validationService) getIssues(ctx context.Context) ([]indoorvalidation.Issue, error) {
+	v.issuesMu.Lock()
+	defer v.issuesMu.Unlock()
+
+	if v.issues != nil {
+		return v.issues, nil
+	}
+
+	data, err := v.dataRepository.GetValidationData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validation data: %w", err)
+	}
+
+	issues := indoorvalidation.Validate(data)
+	if issues == nil {
+		issues = make([]indoorvalidation.Issue, 0)
+	}
+
+	v.issues = issues
+	return v.issues, nil
+}
+
+func issueFeature(issue indoorvalidation.Issue) *geojson.Feature {
+	feat := geojson.NewFeature(issue.Location)
+	feat.Properties["type"] = string(issue.Type)
+	feat.Properties["feature"] = osmid.Format(issue.Feature)
+	feat.Properties["level"] = issue.Level
+	feat.Properties["message"] = issue.Message
+	return feat
+}
@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"context"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/indoorvalidation"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"testing"
+)
+
+type fakeValidationRepository struct {
+	fakeOsmDataRepository
+	data indoorvalidation.Data
+}
+
+func (f fakeValidationRepository) GetValidationData(context.Context) (indoorvalidation.Data, error) {
+	return f.data, nil
+}
+
+// newTestValidationData returns a building with an unclosed room and a door away from any boundary
+func newTestValidationData() indoorvalidation.Data {
+	return indoorvalidation.Data{
+		Nodes: map[int64]indoorvalidation.Node{
+			1: {Point: orb.Point{0, 0}},
+			2: {Point: orb.Point{0.001, 0}},
+			3: {Point: orb.Point{0.001, 0.001}},
+			4: {Point: orb.Point{0, 0.001}},
+			5: {Point: orb.Point{0.0005, 0.0005}, Tags: map[string]string{"door": "yes", "level": "0"}},
+		},
+		Ways: map[int64]indoorvalidation.Way{
+			10: {Nodes: []int64{1, 2, 3, 4, 1}, Tags: map[string]string{"building": "yes"}},
+			11: {Nodes: []int64{1, 2, 3}, Tags: map[string]string{"indoor": "room", "level": "0"}},
+		},
+	}
+}
+
+func TestValidationService_Validate(t *testing.T) {
+	svc := service.NewValidationService(fakeValidationRepository{data: newTestValidationData()})
+
+	report, err := svc.Validate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Issues) != 2 || report.Counts["unclosed_room"] != 1 || report.Counts["door_not_on_boundary"] != 1 {
+		t.Fatalf("expected an unclosed room and a misplaced door, got %+v", report.Issues)
+	}
+	for _, issue := range report.Issues {
+		if issue.Location == nil {
+			t.Errorf("issue %s of %s has no location", issue.Type, issue.Feature)
+		}
+	}
+
+	geojson, err := svc.GetValidationGeoJSON(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(geojson.Features) != 2 || geojson.Features[0].Properties["feature"] == "" {
+		t.Fatalf("expected 2 features with properties, got %+v", geojson.Features)
+	}
+
+	tile := maptile.At(orb.Point{0.0005, 0.0005}, 18)
+	data, err := svc.GetValidationTile(context.Background(), tile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		t.Error("expected the tile to contain the door issue")
+	}
+
+	// rendering the tile must not modify the cached issues
+	again, err := svc.Validate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if point := again.Issues[0].Location.Coordinates.(orb.Point); point != (orb.Point{0.0005, 0.0005}) {
+		t.Errorf("expected the door issue at its node, got %v", point)
+	}
+}
+
+type countingValidationRepository struct {
+	fakeOsmDataRepository
+	loads *int
+}
+
+func (f countingValidationRepository) GetValidationData(context.Context) (indoorvalidation.Data, error) {
+	*f.loads++
+	return newTestValidationData(), nil
+}
+
+func TestValidationService_Invalidate(t *testing.T) {
+	loads := 0
+	svc := service.NewValidationService(countingValidationRepository{loads: &loads})
+
+	for range 2 {
+		if _, err := svc.Validate(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected the issues to be cached, got %d loads", loads)
+	}
+
+	svc.Invalidate()
+	if _, err := svc.Validate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if loads != 2 {
+		t.Errorf("expected the issues to be validated again after invalidating, got %d loads", loads)
+	}
+}
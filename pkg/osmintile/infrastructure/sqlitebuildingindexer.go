@@ -0,0 +1,305 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/osm"
+	"log"
+	"slices"
+)
+
+// sqlitebuildingindexer rebuilds the building index from the imported data.
+// Buildings are all closed ways and multipolygons tagged with building=*,
+// features are linked to a building if their center lies within its outline.
+type sqlitebuildingindexer struct {
+	tx                                    *sql.Tx
+	getWayGeometryPreparedStatement       *sql.Stmt
+	getRelationGeometryPreparedStatement  *sql.Stmt
+	insertBuildingPreparedStatement       *sql.Stmt
+	updateBuildingLevelsPreparedStatement *sql.Stmt
+}
+
+type buildingCandidate struct {
+	id   osm.FeatureID
+	name sql.NullString
+}
+
+func (s *sqlitebuildingindexer) init(tx *sql.Tx) error {
+	s.tx = tx
+
+	if err := s.prepareStatements(); err != nil {
+		return fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlitebuildingindexer) prepareStatements() error {
+	var err error
+
+	s.getWayGeometryPreparedStatement, err = s.tx.Prepare(wayGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.getRelationGeometryPreparedStatement, err = s.tx.Prepare(relationGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.insertBuildingPreparedStatement, err = s.tx.Prepare(
+		"INSERT OR REPLACE INTO building (building_id, name, outline) VALUES (?, ?, ST_GeomFromWKB(?, 4326))",
+	)
+	if err != nil {
+		return err
+	}
+
+	s.updateBuildingLevelsPreparedStatement, err = s.tx.Prepare(
+		"UPDATE building SET levels = ? WHERE building_id = ?",
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *sqlitebuildingindexer) index(ctx context.Context) error {
+	for _, table := range []string{"building", "building_feature"} {
+		if _, err := s.tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	candidates, err := s.loadCandidates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load buildings: %w", err)
+	}
+
+	count := 0
+	for _, candidate := range candidates {
+		ok, err := s.insertBuilding(ctx, candidate)
+		if err != nil {
+			return fmt.Errorf("failed to insert building %s: %w", candidate.id, err)
+		}
+
+		if ok {
+			count++
+		}
+	}
+
+	if err := s.linkFeatures(ctx); err != nil {
+		return fmt.Errorf("failed to link features to buildings: %w", err)
+	}
+
+	if err := s.updateLevels(ctx); err != nil {
+		return fmt.Errorf("failed to update building levels: %w", err)
+	}
+
+	log.Printf("Indexed %d buildings", count)
+
+	return nil
+}
+
+func (s *sqlitebuildingindexer) loadCandidates(ctx context.Context) ([]buildingCandidate, error) {
+	rows, err := s.tx.QueryContext(ctx, `
+		SELECT 'way', way_tag.way_id,
+		(
+			SELECT name_tag.value FROM way_tag as name_tag
+			WHERE name_tag.way_id = way_tag.way_id AND name_tag.key = 'name'
+		) as name
+		FROM way_tag
+		WHERE way_tag.key = 'building'
+		UNION ALL
+		SELECT 'relation', relation_tag.relation_id,
+		(
+			SELECT name_tag.value FROM relation_tag as name_tag
+			WHERE name_tag.relation_id = relation_tag.relation_id AND name_tag.key = 'name'
+		) as name
+		FROM relation_tag
+		WHERE relation_tag.key = 'building'
+		  AND relation_tag.relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'type' AND relation_tag.value = 'multipolygon')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]buildingCandidate, 0)
+	for rows.Next() {
+		var typ string
+		var ref int64
+		candidate := buildingCandidate{}
+		if err := rows.Scan(&typ, &ref, &candidate.name); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		candidate.id, err = osm.Type(typ).FeatureID(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, candidate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+// insertBuilding inserts the building, if its geometry is an area
+func (s *sqlitebuildingindexer) insertBuilding(ctx context.Context, candidate buildingCandidate) (bool, error) {
+	stmt := s.getWayGeometryPreparedStatement
+	if candidate.id.Type() == osm.TypeRelation {
+		stmt = s.getRelationGeometryPreparedStatement
+	}
+
+	var wkbBytes []byte
+	if err := stmt.QueryRowContext(ctx, candidate.id.Ref()).Scan(&wkbBytes); err != nil {
+		return false, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	if wkbBytes == nil {
+		return false, nil
+	}
+
+	geom, err := wkb.Unmarshal(wkbBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmarshal WKB: %w", err)
+	}
+
+	switch geom.(type) {
+	case orb.Polygon, orb.MultiPolygon:
+	default:
+		return false, nil
+	}
+
+	_, err = s.insertBuildingPreparedStatement.ExecContext(ctx, osmid.Format(candidate.id), candidate.name, wkbBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert building: %w", err)
+	}
+
+	return true, nil
+}
+
+// linkFeatures links indoor features to the buildings containing the average of their vertices,
+// the candidate buildings are looked up in the spatial index of their outlines
+func (s *sqlitebuildingindexer) linkFeatures(ctx context.Context) error {
+	queries := []string{
+		`
+		INSERT INTO building_feature (building_id, feature_type, feature_id)
+		SELECT building.building_id, 'way', f.way_id
+		FROM (
+			SELECT way_node.way_id as way_id, MakePoint(AVG(X(node.geom)), AVG(Y(node.geom)), 4326) as geom
+			FROM way_node
+			JOIN node ON node.node_id = way_node.node_id
+			WHERE way_node.way_id IN (SELECT way_tag.way_id FROM way_tag WHERE way_tag.key IN ('indoor', 'level'))
+			  AND way_node.way_id NOT IN (SELECT way_tag.way_id FROM way_tag WHERE way_tag.key = 'building')
+			GROUP BY way_node.way_id
+		) as f
+		JOIN idx_building_outline ON idx_building_outline.xmin <= X(f.geom) AND idx_building_outline.xmax >= X(f.geom)
+			AND idx_building_outline.ymin <= Y(f.geom) AND idx_building_outline.ymax >= Y(f.geom)
+		JOIN building ON building.rowid = idx_building_outline.pkid AND ST_Contains(building.outline, f.geom)
+		`,
+		`
+		INSERT INTO building_feature (building_id, feature_type, feature_id)
+		SELECT building.building_id, 'relation', f.relation_id
+		FROM (
+			SELECT relation_member.relation_id as relation_id, MakePoint(AVG(X(node.geom)), AVG(Y(node.geom)), 4326) as geom
+			FROM relation_member
+			JOIN way_node ON way_node.way_id = relation_member.member_id
+			JOIN node ON node.node_id = way_node.node_id
+			WHERE relation_member.member_type = 'way'
+			  AND relation_member.relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key IN ('indoor', 'level'))
+			  AND relation_member.relation_id NOT IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'building')
+			GROUP BY relation_member.relation_id
+		) as f
+		JOIN idx_building_outline ON idx_building_outline.xmin <= X(f.geom) AND idx_building_outline.xmax >= X(f.geom)
+			AND idx_building_outline.ymin <= Y(f.geom) AND idx_building_outline.ymax >= Y(f.geom)
+		JOIN building ON building.rowid = idx_building_outline.pkid AND ST_Contains(building.outline, f.geom)
+		`,
+		`
+		INSERT INTO building_feature (building_id, feature_type, feature_id)
+		SELECT building.building_id, 'node', node.node_id
+		FROM node
+		JOIN idx_building_outline ON idx_building_outline.xmin <= X(node.geom) AND idx_building_outline.xmax >= X(node.geom)
+			AND idx_building_outline.ymin <= Y(node.geom) AND idx_building_outline.ymax >= Y(node.geom)
+		JOIN building ON building.rowid = idx_building_outline.pkid AND ST_Contains(building.outline, node.geom)
+		WHERE node.node_id IN (SELECT node_tag.node_id FROM node_tag)
+		`,
+	}
+
+	for _, query := range queries {
+		if _, err := s.tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// updateLevels stores the levels of the features linked to a building
+func (s *sqlitebuildingindexer) updateLevels(ctx context.Context) error {
+	rows, err := s.tx.QueryContext(ctx, `
+		SELECT building_feature.building_id, way_tag.value
+		FROM building_feature
+		JOIN way_tag ON way_tag.way_id = building_feature.feature_id AND way_tag.key = 'level'
+		WHERE building_feature.feature_type = 'way'
+		UNION ALL
+		SELECT building_feature.building_id, relation_tag.value
+		FROM building_feature
+		JOIN relation_tag ON relation_tag.relation_id = building_feature.feature_id AND relation_tag.key = 'level'
+		WHERE building_feature.feature_type = 'relation'
+		UNION ALL
+		SELECT building_feature.building_id, node_tag.value
+		FROM building_feature
+		JOIN node_tag ON node_tag.node_id = building_feature.feature_id AND node_tag.key = 'level'
+		WHERE building_feature.feature_type = 'node'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	levels := make(map[string][]float64)
+	for rows.Next() {
+		var buildingID, value string
+		if err := rows.Scan(&buildingID, &value); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		parsed, err := osmlevel.Parse(value)
+		if err != nil {
+			continue
+		}
+
+		levels[buildingID] = append(levels[buildingID], parsed...)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	for buildingID, buildingLevels := range levels {
+		slices.Sort(buildingLevels)
+
+		levelsJson, err := json.Marshal(slices.Compact(buildingLevels))
+		if err != nil {
+			return fmt.Errorf("failed to marshal levels: %w", err)
+		}
+
+		if _, err := s.updateBuildingLevelsPreparedStatement.ExecContext(ctx, string(levelsJson), buildingID); err != nil {
+			return fmt.Errorf("failed to update building: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/georepair"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/indoorvalidation"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/osm"
+	"log"
+)
+
+// sqlitegeometryrepairer repairs the polygons of indoor areas and buildings from the imported data.
+// Repaired polygons are stored in repaired_area together with the applied repairs, the geometry queries prefer them
+// over building the polygons from the nodes. It has to run before the indexers using the geometries.
+type sqlitegeometryrepairer struct {
+	tx                                  *sql.Tx
+	insertRepairedAreaPreparedStatement *sql.Stmt
+}
+
+func (s *sqlitegeometryrepairer) init(tx *sql.Tx) error {
+	s.tx = tx
+
+	var err error
+	s.insertRepairedAreaPreparedStatement, err = s.tx.Prepare(
+		"INSERT INTO repaired_area (feature_type, feature_id, repairs, geom) VALUES (?, ?, ?, ST_GeomFromWKB(?, 4326))",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlitegeometryrepairer) index(ctx context.Context) error {
+	if _, err := s.tx.ExecContext(ctx, "DELETE FROM repaired_area"); err != nil {
+		return fmt.Errorf("failed to clear repaired_area: %w", err)
+	}
+
+	loader := &sqlitevalidationloader{conn: s.tx}
+	data, err := loader.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load areas: %w", err)
+	}
+
+	counts := make(map[georepair.Repair]int)
+	repair := func(id osm.FeatureID, lines []orb.LineString) error {
+		polygons, repairs := georepair.Polygons(lines)
+		if len(repairs) == 0 || len(polygons) == 0 {
+			return nil
+		}
+
+		if err := s.insertRepairedArea(ctx, id, polygons, repairs); err != nil {
+			return fmt.Errorf("failed to insert repaired area %s: %w", id, err)
+		}
+
+		for _, r := range repairs {
+			counts[r]++
+		}
+		return nil
+	}
+
+	for relationID, relation := range data.Relations {
+		if relation.Tags["type"] != "multipolygon" || !isRepairableArea(relation.Tags) {
+			continue
+		}
+
+		lines, ok := relationLines(data, relation)
+		if !ok {
+			continue
+		}
+
+		if err := repair(osm.RelationID(relationID).FeatureID(), lines); err != nil {
+			return err
+		}
+	}
+
+	for wayID, way := range data.Ways {
+		if !isRepairableArea(way.Tags) {
+			continue
+		}
+
+		line, ok := wayLine(data, way)
+		if !ok {
+			continue
+		}
+
+		if err := repair(osm.WayID(wayID).FeatureID(), []orb.LineString{line}); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Repaired geometries: %d closed rings, %d spikes, %d self intersections, %d orientations, %d unrepairable",
+		counts[georepair.RepairClosedRing], counts[georepair.RepairSpike],
+		counts[georepair.RepairSelfIntersection], counts[georepair.RepairOrientation], counts[georepair.RepairUnrepairable])
+
+	return nil
+}
+
+func (s *sqlitegeometryrepairer) insertRepairedArea(ctx context.Context, id osm.FeatureID, polygons orb.MultiPolygon, repairs []georepair.Repair) error {
+	var geom orb.Geometry = polygons
+	if len(polygons) == 1 {
+		geom = polygons[0]
+	}
+
+	wkbBytes, err := wkb.Marshal(geom)
+	if err != nil {
+		return fmt.Errorf("failed to marshal WKB: %w", err)
+	}
+
+	repairsJSON, err := json.Marshal(repairs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repairs: %w", err)
+	}
+
+	_, err = s.insertRepairedAreaPreparedStatement.ExecContext(ctx, string(id.Type()), id.Ref(), string(repairsJSON), wkbBytes)
+	return err
+}
+
+// isRepairableArea reports whether the tags describe a room, area, corridor, level or building
+func isRepairableArea(tags map[string]string) bool {
+	switch tags["indoor"] {
+	case "room", "area", "corridor", "level":
+		return true
+	}
+
+	building, ok := tags["building"]
+	return ok && building != "no"
+}
+
+// relationLines returns the lines of the member ways of the relation, false is returned if any of them is incomplete
+func relationLines(data indoorvalidation.Data, relation indoorvalidation.Relation) ([]orb.LineString, bool) {
+	var lines []orb.LineString
+	for _, member := range relation.Members {
+		if member.Type != osm.TypeWay {
+			continue
+		}
+
+		way, ok := data.Ways[member.Ref]
+		if !ok {
+			return nil, false
+		}
+
+		line, ok := wayLine(data, way)
+		if !ok {
+			return nil, false
+		}
+		lines = append(lines, line)
+	}
+	return lines, true
+}
+
+// wayLine returns the line of the way, false is returned if nodes are missing
+func wayLine(data indoorvalidation.Data, way indoorvalidation.Way) (orb.LineString, bool) {
+	out := make(orb.LineString, 0, len(way.Nodes))
+	for _, nodeID := range way.Nodes {
+		node, ok := data.Nodes[nodeID]
+		if !ok {
+			return nil, false
+		}
+		out = append(out, node.Point)
+	}
+	return out, true
+}
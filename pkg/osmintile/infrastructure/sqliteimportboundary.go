@@ -0,0 +1,132 @@
+package infrastructure
+
+import (
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/idset"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/osm"
+)
+
+// importBoundaryMargin is the distance in degrees, about a kilometer, around the bound of the boundary within which
+// the positions of nodes are kept. Ways crossing the boundary are found through their segments with both nodes
+// within the margin, longer segments crossing it without a node inside are missed.
+const importBoundaryMargin = 0.01
+
+// importBoundary restricts an import to the features intersecting the polygon, a nil polygon keeps all features
+type importBoundary struct {
+	polygon orb.MultiPolygon
+	bound   orb.Bound
+	// features are the nodes within the polygon and the ways and relations with members intersecting it
+	features *idset.Set
+	// nodes are the positions of the nodes near the boundary, only they are needed to find crossing ways
+	nodes map[osm.NodeID]orb.Point
+}
+
+func newImportBoundary(polygon orb.MultiPolygon) *importBoundary {
+	b := &importBoundary{polygon: polygon, features: idset.New(), nodes: make(map[osm.NodeID]orb.Point)}
+	if polygon != nil {
+		b.bound = polygon.Bound()
+	}
+	return b
+}
+
+func (b *importBoundary) intersects(id osm.FeatureID) bool {
+	if b.polygon == nil {
+		return true
+	}
+
+	return b.features.Contains(id)
+}
+
+// crosses reports whether a segment of the way crosses the boundary or the closed way encloses it.
+// Segments with a node outside of the margin are not checked.
+func (b *importBoundary) crosses(nodes osm.WayNodes) bool {
+	line := make(orb.LineString, 0, len(nodes))
+	complete := true
+	for i, node := range nodes {
+		point, ok := b.nodes[node.ID]
+		if !ok {
+			complete = false
+			continue
+		}
+
+		if i > 0 {
+			if prev, ok := b.nodes[nodes[i-1].ID]; ok && b.segmentCrosses(prev, point) {
+				return true
+			}
+		}
+		line = append(line, point)
+	}
+
+	if !complete || len(line) < 4 || !line[0].Equal(line[len(line)-1]) {
+		return false
+	}
+
+	return planar.RingContains(orb.Ring(line), b.polygon[0][0][0])
+}
+
+func (b *importBoundary) segmentCrosses(p, q orb.Point) bool {
+	if !b.bound.Intersects(orb.Bound{Min: p, Max: p}.Extend(q)) {
+		return false
+	}
+
+	for _, polygon := range b.polygon {
+		for _, ring := range polygon {
+			for i := 0; i+1 < len(ring); i++ {
+				if _, ok := geoutil.Crossing(p, q, ring[i], ring[i+1], 0); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// boundaryImportPass finds the features intersecting the boundary. It relies on the order of osm files,
+// nodes come before ways and ways before relations, relations only intersect through relations listed before them.
+func (s *SqliteOsmDataRepository) boundaryImportPass(scanner osm.Scanner, boundary *importBoundary) error {
+	margin := boundary.bound.Pad(importBoundaryMargin)
+
+	anyIntersects := func(ids []osm.FeatureID) bool {
+		for _, id := range ids {
+			if boundary.features.Contains(id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for scanner.Scan() {
+		switch obj := scanner.Object().(type) {
+		case *osm.Node:
+			point := obj.Point()
+			if !margin.Contains(point) {
+				continue
+			}
+
+			boundary.nodes[obj.ID] = point
+			if boundary.bound.Contains(point) && planar.MultiPolygonContains(boundary.polygon, point) {
+				boundary.features.Add(obj.FeatureID())
+			}
+		case *osm.Way:
+			if anyIntersects(obj.Nodes.FeatureIDs()) || boundary.crosses(obj.Nodes) {
+				boundary.features.Add(obj.FeatureID())
+			}
+		case *osm.Relation:
+			if anyIntersects(obj.Members.FeatureIDs()) {
+				boundary.features.Add(obj.FeatureID())
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to import osm dump file: %w", err)
+	}
+
+	// the positions are only needed to find the ways within this pass
+	boundary.nodes = nil
+
+	return nil
+}
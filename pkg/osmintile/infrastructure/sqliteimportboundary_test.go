@@ -0,0 +1,88 @@
+package infrastructure
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+	"testing"
+)
+
+// sliceScanner scans the objects in order, like a sorted osm file
+type sliceScanner struct {
+	objects []osm.Object
+	next    int
+}
+
+func (s *sliceScanner) Scan() bool {
+	s.next++
+	return s.next <= len(s.objects)
+}
+
+func (s *sliceScanner) Object() osm.Object {
+	return s.objects[s.next-1]
+}
+
+func (s *sliceScanner) Err() error {
+	return nil
+}
+
+func (s *sliceScanner) Close() error {
+	return nil
+}
+
+func wayNodes(ids ...osm.NodeID) osm.WayNodes {
+	out := make(osm.WayNodes, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, osm.WayNode{ID: id})
+	}
+	return out
+}
+
+func TestBoundaryImportPass(t *testing.T) {
+	bound := orb.Bound{Min: orb.Point{11.5, 48.1}, Max: orb.Point{11.6, 48.2}}
+	boundary := newImportBoundary(orb.MultiPolygon{bound.ToPolygon()})
+
+	scanner := &sliceScanner{objects: []osm.Object{
+		&osm.Node{ID: 1, Lon: 11.55, Lat: 48.15},
+		&osm.Node{ID: 2, Lon: 11.499, Lat: 48.15},
+		&osm.Node{ID: 3, Lon: 11.601, Lat: 48.15},
+		&osm.Node{ID: 4, Lon: 13.4, Lat: 52.5},
+		&osm.Node{ID: 5, Lon: 11.49, Lat: 48.09},
+		&osm.Node{ID: 6, Lon: 11.61, Lat: 48.09},
+		&osm.Node{ID: 7, Lon: 11.61, Lat: 48.21},
+		&osm.Node{ID: 8, Lon: 11.49, Lat: 48.21},
+		// a node inside
+		&osm.Way{ID: 1, Nodes: wayNodes(1, 4)},
+		// crossing without a node inside
+		&osm.Way{ID: 2, Nodes: wayNodes(2, 3)},
+		// outside
+		&osm.Way{ID: 3, Nodes: wayNodes(2, 4)},
+		// enclosing the boundary
+		&osm.Way{ID: 4, Nodes: wayNodes(5, 6, 7, 8, 5)},
+		// not closed around the boundary
+		&osm.Way{ID: 5, Nodes: wayNodes(5, 6, 7)},
+		&osm.Relation{ID: 1, Members: osm.Members{{Type: osm.TypeWay, Ref: 2}}},
+		&osm.Relation{ID: 2, Members: osm.Members{{Type: osm.TypeNode, Ref: 4}, {Type: osm.TypeWay, Ref: 3}}},
+	}}
+
+	if err := (&SqliteOsmDataRepository{}).boundaryImportPass(scanner, boundary); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[osm.FeatureID]bool{
+		osm.NodeID(1).FeatureID():     true,
+		osm.NodeID(2).FeatureID():     false,
+		osm.NodeID(4).FeatureID():     false,
+		osm.WayID(1).FeatureID():      true,
+		osm.WayID(2).FeatureID():      true,
+		osm.WayID(3).FeatureID():      false,
+		osm.WayID(4).FeatureID():      true,
+		osm.WayID(5).FeatureID():      false,
+		osm.RelationID(1).FeatureID(): true,
+		osm.RelationID(2).FeatureID(): false,
+	}
+	for id, want := range tests {
+		if got := boundary.intersects(id); got != want {
+			t.Errorf("expected %s to intersect the boundary %v, got %v", id, want, got)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/idset"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulmach/osm"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// importProgressInterval is the minimal time between two progress logs of a pass
+	importProgressInterval = 10 * time.Second
+	// importProgressReportInterval is the minimal time between two calls of the progress callback within a pass
+	importProgressReportInterval = time.Second
+	// importObjectBuffer is the number of objects buffered between the scanner and the database writer
+	importObjectBuffer = 4096
+)
+
+// importPragmas tune sqlite for bulk inserts, the import runs in a single transaction and is rolled back on failure,
+// so the durability given up is not needed
+var importPragmas = []struct {
+	name  string
+	value string
+}{
+	{name: "synchronous", value: "OFF"},
+	{name: "cache_size", value: "-262144"},
+	{name: "temp_store", value: "MEMORY"},
+}
+
+// importIndexTables are the tables written by the insert pass, their indexes are created after the objects are loaded
+var importIndexTables = []any{"node", "node_tag", "way", "way_tag", "way_node", "relation", "relation_tag", "relation_member"}
+
+// tuneImportPragmas sets the importPragmas on the connection and returns a function restoring the previous values
+func tuneImportPragmas(ctx context.Context, conn *sql.Conn) (func(), error) {
+	previous := make([]string, len(importPragmas))
+	for i, pragma := range importPragmas {
+		err := conn.QueryRowContext(ctx, fmt.Sprintf("PRAGMA %s", pragma.name)).Scan(&previous[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pragma %s: %w", pragma.name, err)
+		}
+	}
+
+	restore := func() {
+		for i, pragma := range importPragmas {
+			_, err := conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA %s = %s", pragma.name, previous[i]))
+			if err != nil {
+				log.Printf("failed to restore pragma %s: %s", pragma.name, err.Error())
+			}
+		}
+	}
+
+	for _, pragma := range importPragmas {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA %s = %s", pragma.name, pragma.value))
+		if err != nil {
+			restore()
+			return nil, fmt.Errorf("failed to set pragma %s: %w", pragma.name, err)
+		}
+	}
+
+	return restore, nil
+}
+
+// dropImportIndexes drops the indexes of the importIndexTables and returns their statements for recreating them
+func dropImportIndexes(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	query := `SELECT name, sql FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL AND tbl_name IN (?, ?, ?, ?, ?, ?, ?, ?)`
+	rows, err := tx.QueryContext(ctx, query, importIndexTables...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var names, statements []string
+	for rows.Next() {
+		var name, statement string
+		if err := rows.Scan(&name, &statement); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		names = append(names, name)
+		statements = append(statements, statement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+
+	for _, name := range names {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf("DROP INDEX IF EXISTS %q", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to drop index %s: %w", name, err)
+		}
+	}
+
+	return statements, nil
+}
+
+// createImportIndexes recreates the indexes dropped by dropImportIndexes
+func createImportIndexes(ctx context.Context, tx *sql.Tx, statements []string) error {
+	for _, statement := range statements {
+		_, err := tx.ExecContext(ctx, statement)
+		if err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// insertImportPass scans the objects in a separate goroutine, while the included ones are written to the database.
+// It returns the number of inserted objects and removes them from includedObjects.
+func insertImportPass(ctx context.Context, scanner osm.Scanner, importer *sqliteosmobjectimporter, includedObjects *idset.Set) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := make(chan osm.Object, importObjectBuffer)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(objects)
+		for scanner.Scan() {
+			obj := scanner.Object()
+
+			// can be cast, as ObjectID == ElementID for OSM Elements
+			elemID := osm.ElementID(obj.ObjectID()).FeatureID()
+
+			if !includedObjects.Remove(elemID) {
+				continue
+			}
+
+			select {
+			case objects <- obj:
+			case <-ctx.Done():
+				scanErr <- ctx.Err()
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	count := 0
+	for obj := range objects {
+		if err := importer.importObject(ctx, obj); err != nil {
+			cancel()
+			for range objects {
+			}
+			return count, fmt.Errorf("failed to import osm database object: %w", err)
+		}
+		count++
+	}
+
+	if err := <-scanErr; err != nil {
+		return count, fmt.Errorf("failed to import osm dump file: %w", err)
+	}
+
+	if err := importer.flush(ctx); err != nil {
+		return count, fmt.Errorf("failed to import osm database object: %w", err)
+	}
+
+	return count, nil
+}
+
+// importProgress tracks the passes of an import, it logs their throughput and reports them to the progress callback
+type importProgress struct {
+	callback  func(entities.ImportProgress)
+	file      *countingReader
+	fileSize  int64
+	passCount int
+	start     time.Time
+
+	pass      string
+	passIndex int
+	passStart time.Time
+	scanned   int
+	kept      func() int
+	logged    time.Time
+	reported  time.Time
+}
+
+// newImportProgress tracks passCount passes over the file, callback may be nil
+func newImportProgress(file *countingReader, fileSize int64, passCount int, callback func(entities.ImportProgress)) *importProgress {
+	return &importProgress{
+		callback:  callback,
+		file:      file,
+		fileSize:  fileSize,
+		passCount: passCount,
+		start:     time.Now(),
+	}
+}
+
+// startPass starts the next pass, kept returns the number of objects kept so far
+func (p *importProgress) startPass(pass string, kept func() int) {
+	now := time.Now()
+	p.pass = pass
+	p.passIndex++
+	p.passStart = now
+	p.scanned = 0
+	p.kept = kept
+	p.logged = now
+
+	log.Printf("running %s (%d/%d)", p.pass, p.passIndex, p.passCount)
+	p.report(now)
+}
+
+func (p *importProgress) finishPass() {
+	p.report(time.Now())
+}
+
+func (p *importProgress) scan() {
+	p.scanned++
+	if p.scanned%1024 != 0 {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(p.logged) >= importProgressInterval {
+		p.logged = now
+		log.Printf("%s: %s", p.pass, p)
+	}
+	if now.Sub(p.reported) >= importProgressReportInterval {
+		p.report(now)
+	}
+}
+
+func (p *importProgress) report(now time.Time) {
+	p.reported = now
+	if p.callback != nil {
+		p.callback(p.progress(now))
+	}
+}
+
+func (p *importProgress) progress(now time.Time) entities.ImportProgress {
+	out := entities.ImportProgress{
+		Pass:           p.pass,
+		PassIndex:      p.passIndex,
+		PassCount:      p.passCount,
+		BytesRead:      min(p.file.Count(), p.fileSize),
+		FileSize:       p.fileSize,
+		ObjectsScanned: p.scanned,
+		ElapsedSeconds: now.Sub(p.start).Seconds(),
+	}
+	if p.kept != nil {
+		out.ObjectsKept = p.kept()
+	}
+
+	// the remaining scanning passes are assumed to take as long as the current one, the last pass does not scan
+	if p.scanned > 0 && out.BytesRead > 0 && p.fileSize > 0 {
+		fraction := float64(out.BytesRead) / float64(p.fileSize)
+		passSeconds := now.Sub(p.passStart).Seconds() / fraction
+		remainingPasses := max(p.passCount-1-p.passIndex, 0)
+		eta := passSeconds*(1-fraction) + passSeconds*float64(remainingPasses)
+		out.ETASeconds = &eta
+	}
+
+	return out
+}
+
+func (p *importProgress) String() string {
+	now := time.Now()
+	progress := p.progress(now)
+	rate := float64(p.scanned) / now.Sub(p.passStart).Seconds()
+
+	out := fmt.Sprintf("scanned %d objects, kept %d (%.0f objects/s, %.1f/%.1f MiB read)",
+		progress.ObjectsScanned, progress.ObjectsKept, rate, float64(progress.BytesRead)/(1<<20), float64(progress.FileSize)/(1<<20))
+	if progress.ETASeconds != nil {
+		out += fmt.Sprintf(", ETA %s", (time.Duration(*progress.ETASeconds) * time.Second).Round(time.Second))
+	}
+	return out
+}
+
+// progressScanner reports every scanned object to the progress
+type progressScanner struct {
+	osm.Scanner
+	progress *importProgress
+}
+
+func (s progressScanner) Scan() bool {
+	if !s.Scanner.Scan() {
+		return false
+	}
+	s.progress.scan()
+	return true
+}
+
+// countingReader counts the bytes read from the file since the last seek, the osm scanners read from other goroutines
+type countingReader struct {
+	file  io.ReadSeeker
+	count atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.file.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+func (r *countingReader) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.file.Seek(offset, whence)
+	if err == nil {
+		r.count.Store(position)
+	}
+	return position, err
+}
+
+func (r *countingReader) Count() int64 {
+	return r.count.Load()
+}
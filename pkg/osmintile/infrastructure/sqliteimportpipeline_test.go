@@ -0,0 +1,141 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/idset"
+	"github.com/paulmach/osm"
+	"testing"
+)
+
+// importTestSchema is the part of the schema written by the insert pass, node geometries are stored as text
+const importTestSchema = `
+CREATE TABLE node (node_id bigint NOT NULL PRIMARY KEY, geom text);
+CREATE INDEX node_node_id ON node (node_id);
+CREATE TABLE node_tag (node_id bigint NOT NULL, key text NOT NULL, value text NOT NULL);
+CREATE INDEX node_tag_node_id ON node_tag (node_id);
+CREATE TABLE way (way_id bigint NOT NULL PRIMARY KEY);
+CREATE INDEX way_way_id ON way (way_id);
+CREATE TABLE way_tag (way_id bigint NOT NULL, key text NOT NULL, value text NOT NULL);
+CREATE INDEX way_tag_way_id ON way_tag (way_id);
+CREATE TABLE way_node (way_id bigint NOT NULL, node_id bigint NOT NULL, sequence_id int NOT NULL);
+CREATE INDEX way_node_way_id ON way_node (way_id);
+CREATE TABLE relation (relation_id bigint NOT NULL PRIMARY KEY);
+CREATE INDEX relation_relation_id ON relation (relation_id);
+CREATE TABLE relation_tag (relation_id bigint NOT NULL, key text NOT NULL, value text NOT NULL);
+CREATE INDEX relation_tag_relation_id ON relation_tag (relation_id);
+CREATE TABLE relation_member (relation_id bigint NOT NULL, member_type text NOT NULL, member_id bigint NOT NULL,
+                              member_role text NOT NULL, sequence_id int NOT NULL);
+CREATE INDEX relation_member_relation_id ON relation_member (relation_id);
+`
+
+func init() {
+	// plain sqlite with stand-ins for the spatialite functions used by the insert pass
+	sql.Register("sqlite3_import_test", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			err := conn.RegisterFunc("MakePoint", func(x, y float64) string { return fmt.Sprintf("POINT(%v %v)", x, y) }, true)
+			if err != nil {
+				return err
+			}
+			return conn.RegisterFunc("SetSRID", func(geom string, srid int) string { return geom }, true)
+		},
+	})
+}
+
+func TestInsertImportPass(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3_import_test", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(ctx, importTestSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	statements, err := dropImportIndexes(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != len(importIndexTables) {
+		t.Fatalf("expected %d dropped indexes, got %d", len(importIndexTables), len(statements))
+	}
+
+	// more objects than fit into a batch, every second node is not included
+	includedObjects := idset.New()
+	var objects []osm.Object
+	way := &osm.Way{ID: 1, Tags: osm.Tags{{Key: "indoor", Value: "room"}}}
+	for i := range 2*importBatchRows + 1 {
+		node := &osm.Node{ID: osm.NodeID(i + 1), Lon: 11.5, Lat: 48.1, Tags: osm.Tags{{Key: "door", Value: "yes"}}}
+		objects = append(objects, node)
+		if i%2 == 0 {
+			includedObjects.Add(node.FeatureID())
+			way.Nodes = append(way.Nodes, osm.WayNode{ID: node.ID})
+		}
+	}
+	relation := &osm.Relation{ID: 1, Members: osm.Members{
+		{Type: osm.TypeWay, Ref: 1, Role: "outer"},
+		{Type: osm.TypeNode, Ref: 1 << 40, Role: "entrance"},
+	}}
+	objects = append(objects, way, relation)
+	includedObjects.Add(way.FeatureID())
+	includedObjects.Add(relation.FeatureID())
+
+	importer := &sqliteosmobjectimporter{}
+	if err := importer.init(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := insertImportPass(ctx, &sliceScanner{objects: objects}, importer, includedObjects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := createImportIndexes(ctx, tx, statements); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := importBatchRows + 1
+	if count != nodes+2 || includedObjects.Len() != 0 {
+		t.Errorf("expected %d inserted objects and none left, got %d and %d left", nodes+2, count, includedObjects.Len())
+	}
+
+	for table, expected := range map[string]int{
+		"node": nodes, "node_tag": nodes, "way": 1, "way_tag": 1, "way_node": nodes,
+		"relation": 1, "relation_tag": 0, "relation_member": 2,
+	} {
+		var rows int
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", table)).Scan(&rows); err != nil {
+			t.Fatal(err)
+		}
+		if rows != expected {
+			t.Errorf("expected %d rows in %s, got %d", expected, table, rows)
+		}
+	}
+
+	var memberID int64
+	if err := tx.QueryRowContext(ctx, "SELECT member_id FROM relation_member WHERE member_type = 'node'").Scan(&memberID); err != nil {
+		t.Fatal(err)
+	}
+	if memberID != 1<<40 {
+		t.Errorf("expected the member id %d, got %d", int64(1<<40), memberID)
+	}
+
+	var indexes int
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL").Scan(&indexes); err != nil {
+		t.Fatal(err)
+	}
+	if indexes != len(statements) {
+		t.Errorf("expected the %d indexes to be recreated, got %d", len(statements), indexes)
+	}
+}
@@ -0,0 +1,167 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/osm"
+	"log"
+)
+
+// sqliteindoorareaindexer rebuilds the indoor areas from the imported data.
+// Indoor areas are the ways and relations tagged indoor=room|area|corridor whose geometry is an area,
+// they are stored with their tags, so looking up the areas at a point is a single query on their spatial index.
+type sqliteindoorareaindexer struct {
+	tx                                   *sql.Tx
+	getWayGeometryPreparedStatement      *sql.Stmt
+	getRelationGeometryPreparedStatement *sql.Stmt
+	insertIndoorAreaPreparedStatement    *sql.Stmt
+}
+
+type indoorAreaCandidate struct {
+	id   osm.FeatureID
+	tags string
+}
+
+func (s *sqliteindoorareaindexer) init(tx *sql.Tx) error {
+	s.tx = tx
+
+	if err := s.prepareStatements(); err != nil {
+		return fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqliteindoorareaindexer) prepareStatements() error {
+	var err error
+
+	s.getWayGeometryPreparedStatement, err = s.tx.Prepare(wayGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.getRelationGeometryPreparedStatement, err = s.tx.Prepare(relationGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.insertIndoorAreaPreparedStatement, err = s.tx.Prepare(
+		"INSERT INTO indoor_area (feature_type, feature_id, tags, geom) VALUES (?, ?, ?, ST_GeomFromWKB(?, 4326))",
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// index has to run after the geometry repairer, the repaired polygons are preferred
+func (s *sqliteindoorareaindexer) index(ctx context.Context) error {
+	if _, err := s.tx.ExecContext(ctx, "DELETE FROM indoor_area"); err != nil {
+		return fmt.Errorf("failed to clear indoor_area: %w", err)
+	}
+
+	candidates, err := s.loadCandidates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load indoor areas: %w", err)
+	}
+
+	count := 0
+	for _, candidate := range candidates {
+		ok, err := s.insertIndoorArea(ctx, candidate)
+		if err != nil {
+			return fmt.Errorf("failed to insert indoor area %s: %w", candidate.id, err)
+		}
+
+		if ok {
+			count++
+		}
+	}
+
+	log.Printf("Indexed %d indoor areas", count)
+
+	return nil
+}
+
+func (s *sqliteindoorareaindexer) loadCandidates(ctx context.Context) ([]indoorAreaCandidate, error) {
+	rows, err := s.tx.QueryContext(ctx, `
+		SELECT 'way', way_tag.way_id,
+		(
+			SELECT json_group_object(tag.key, tag.value) FROM way_tag as tag WHERE tag.way_id = way_tag.way_id
+		) as tags
+		FROM way_tag
+		WHERE way_tag.key = 'indoor' AND way_tag.value IN ('room', 'area', 'corridor')
+		UNION ALL
+		SELECT 'relation', relation_tag.relation_id,
+		(
+			SELECT json_group_object(tag.key, tag.value) FROM relation_tag as tag WHERE tag.relation_id = relation_tag.relation_id
+		) as tags
+		FROM relation_tag
+		WHERE relation_tag.key = 'indoor' AND relation_tag.value IN ('room', 'area', 'corridor')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]indoorAreaCandidate, 0)
+	for rows.Next() {
+		var typ string
+		var ref int64
+		candidate := indoorAreaCandidate{}
+		if err := rows.Scan(&typ, &ref, &candidate.tags); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		candidate.id, err = osm.Type(typ).FeatureID(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, candidate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+// insertIndoorArea inserts the indoor area, if its geometry is an area
+func (s *sqliteindoorareaindexer) insertIndoorArea(ctx context.Context, candidate indoorAreaCandidate) (bool, error) {
+	stmt := s.getWayGeometryPreparedStatement
+	if candidate.id.Type() == osm.TypeRelation {
+		stmt = s.getRelationGeometryPreparedStatement
+	}
+
+	var wkbBytes []byte
+	if err := stmt.QueryRowContext(ctx, candidate.id.Ref()).Scan(&wkbBytes); err != nil {
+		return false, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	if wkbBytes == nil {
+		return false, nil
+	}
+
+	geom, err := wkb.Unmarshal(wkbBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmarshal WKB: %w", err)
+	}
+
+	switch geom.(type) {
+	case orb.Polygon, orb.MultiPolygon:
+	default:
+		return false, nil
+	}
+
+	_, err = s.insertIndoorAreaPreparedStatement.ExecContext(ctx, string(candidate.id.Type()), candidate.id.Ref(), candidate.tags, wkbBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert indoor area: %w", err)
+	}
+
+	return true, nil
+}
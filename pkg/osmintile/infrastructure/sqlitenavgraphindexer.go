@@ -0,0 +1,368 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/osm"
+	"log"
+)
+
+// sqlitenavgraphindexer derives the indoor navigation graph from the imported data.
+// Walkable areas are all indoor=room|area|corridor features, doors are nodes tagged with door=* or entrance=*,
+// levels are connected by highway=elevator nodes, highway=steps ways and stairs or elevator rooms.
+type sqlitenavgraphindexer struct {
+	tx                                   *sql.Tx
+	getWayGeometryPreparedStatement      *sql.Stmt
+	getRelationGeometryPreparedStatement *sql.Stmt
+	insertNodePreparedStatement          *sql.Stmt
+	insertEdgePreparedStatement          *sql.Stmt
+	insertAreaPreparedStatement          *sql.Stmt
+}
+
+func (s *sqlitenavgraphindexer) init(tx *sql.Tx) error {
+	s.tx = tx
+
+	if err := s.prepareStatements(); err != nil {
+		return fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlitenavgraphindexer) prepareStatements() error {
+	var err error
+
+	s.getWayGeometryPreparedStatement, err = s.tx.Prepare(wayGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.getRelationGeometryPreparedStatement, err = s.tx.Prepare(relationGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.insertNodePreparedStatement, err = s.tx.Prepare(
+		"INSERT INTO nav_node (node_id, level, kind, feature, tags, geom) VALUES (?, ?, ?, ?, ?, MakePoint(?, ?, 4326))",
+	)
+	if err != nil {
+		return err
+	}
+
+	s.insertEdgePreparedStatement, err = s.tx.Prepare(
+		"INSERT INTO nav_edge (from_node, to_node, kind, length, levels, oneway, wheelchair, feature, cost) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return err
+	}
+
+	s.insertAreaPreparedStatement, err = s.tx.Prepare(
+		"INSERT INTO nav_area (area_id, level, tags, nodes, geom) VALUES (?, ?, ?, ?, ST_GeomFromWKB(?, 4326))",
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *sqlitenavgraphindexer) index(ctx context.Context) error {
+	for _, table := range []string{"nav_node", "nav_edge", "nav_area"} {
+		if _, err := s.tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	builder := navgraph.NewBuilder()
+
+	if err := s.loadAreas(ctx, builder); err != nil {
+		return fmt.Errorf("failed to load areas: %w", err)
+	}
+
+	if err := s.loadPoints(ctx, builder); err != nil {
+		return fmt.Errorf("failed to load doors and elevators: %w", err)
+	}
+
+	if err := s.loadWays(ctx, builder); err != nil {
+		return fmt.Errorf("failed to load footways: %w", err)
+	}
+
+	graph := builder.Build()
+
+	if err := s.insertGraph(ctx, graph); err != nil {
+		return fmt.Errorf("failed to insert graph: %w", err)
+	}
+
+	log.Printf("Indexed navigation graph with %d nodes, %d edges and %d areas", len(graph.Nodes), len(graph.Edges), len(graph.Areas))
+
+	return nil
+}
+
+func (s *sqlitenavgraphindexer) loadAreas(ctx context.Context, builder *navgraph.Builder) error {
+	rows, err := s.tx.QueryContext(ctx, `
+		SELECT 'way', way_tag.way_id,
+		(
+			SELECT json_group_object(t.key, t.value) FROM way_tag as t WHERE t.way_id = way_tag.way_id
+		) as json
+		FROM way_tag
+		WHERE way_tag.key = 'indoor' AND way_tag.value IN ('room', 'area', 'corridor')
+		UNION ALL
+		SELECT 'relation', relation_tag.relation_id,
+		(
+			SELECT json_group_object(t.key, t.value) FROM relation_tag as t WHERE t.relation_id = relation_tag.relation_id
+		) as json
+		FROM relation_tag
+		WHERE relation_tag.key = 'indoor' AND relation_tag.value IN ('room', 'area', 'corridor')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	type area struct {
+		id   osm.FeatureID
+		tags map[string]string
+	}
+
+	areas := make([]area, 0)
+	for rows.Next() {
+		var typ, tagsStr string
+		var ref int64
+		if err := rows.Scan(&typ, &ref, &tagsStr); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		id, err := osm.Type(typ).FeatureID(ref)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		tags := make(map[string]string)
+		if err := json.Unmarshal([]byte(tagsStr), &tags); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to unmarshal tags: %w", err)
+		}
+
+		areas = append(areas, area{id: id, tags: tags})
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	for _, area := range areas {
+		geom, err := s.queryGeometry(ctx, area.id)
+		if err != nil {
+			return fmt.Errorf("failed to get geometry of %s: %w", area.id, err)
+		}
+
+		if geom != nil {
+			builder.AddArea(osmid.Format(area.id), area.tags, geom)
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlitenavgraphindexer) loadPoints(ctx context.Context, builder *navgraph.Builder) error {
+	rows, err := s.tx.QueryContext(ctx, `
+		SELECT node.node_id, X(node.geom), Y(node.geom),
+		(
+			SELECT json_group_object(t.key, t.value) FROM node_tag as t WHERE t.node_id = node.node_id
+		) as json
+		FROM node
+		WHERE node.node_id IN (
+			SELECT node_tag.node_id FROM node_tag
+			WHERE node_tag.key IN ('door', 'entrance')
+			   OR (node_tag.key = 'highway' AND node_tag.value = 'elevator')
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nodeID int64
+		var point orb.Point
+		var tagsStr string
+		if err := rows.Scan(&nodeID, &point[0], &point[1], &tagsStr); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		tags := make(map[string]string)
+		if err := json.Unmarshal([]byte(tagsStr), &tags); err != nil {
+			return fmt.Errorf("failed to unmarshal tags: %w", err)
+		}
+
+		builder.AddPoint(osmid.Format(osm.NodeID(nodeID).FeatureID()), point, tags)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlitenavgraphindexer) loadWays(ctx context.Context, builder *navgraph.Builder) error {
+	// only indoor footways are part of the graph, outdoor paths of the extract would land on level 0 and bloat the graph
+	const wayFilter = `
+		SELECT way_tag.way_id FROM way_tag
+		WHERE way_tag.key = 'highway' AND way_tag.value IN ('footway', 'corridor', 'steps', 'path', 'pedestrian')
+		  AND (
+			way_tag.way_id IN (SELECT t.way_id FROM way_tag as t WHERE t.key IN ('indoor', 'level'))
+			OR way_tag.way_id IN (SELECT building_feature.feature_id FROM building_feature WHERE building_feature.feature_type = 'way')
+		  )
+	`
+
+	tags := make(map[int64]map[string]string)
+	tagRows, err := s.tx.QueryContext(ctx, `
+		SELECT way_tag.way_id, way_tag.key, way_tag.value
+		FROM way_tag
+		WHERE way_tag.way_id IN (`+wayFilter+`)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var wayID int64
+		var key, value string
+		if err := tagRows.Scan(&wayID, &key, &value); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if tags[wayID] == nil {
+			tags[wayID] = make(map[string]string)
+		}
+		tags[wayID][key] = value
+	}
+
+	if err := tagRows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	nodeRows, err := s.tx.QueryContext(ctx, `
+		SELECT way_node.way_id, way_node.node_id, X(node.geom), Y(node.geom)
+		FROM way_node
+		JOIN node ON node.node_id = way_node.node_id
+		WHERE way_node.way_id IN (`+wayFilter+`)
+		ORDER BY way_node.way_id, way_node.sequence_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer nodeRows.Close()
+
+	wayID := int64(-1)
+	var nodes []navgraph.WayNode
+	flush := func() {
+		if wayID >= 0 {
+			builder.AddWay(osmid.Format(osm.WayID(wayID).FeatureID()), nodes, tags[wayID])
+		}
+	}
+
+	for nodeRows.Next() {
+		var rowWayID int64
+		node := navgraph.WayNode{}
+		if err := nodeRows.Scan(&rowWayID, &node.ID, &node.Point[0], &node.Point[1]); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if rowWayID != wayID {
+			flush()
+			wayID, nodes = rowWayID, nil
+		}
+		nodes = append(nodes, node)
+	}
+	flush()
+
+	if err := nodeRows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return nil
+}
+
+// queryGeometry returns the geometry of the way or relation, nil is returned if it has none
+func (s *sqlitenavgraphindexer) queryGeometry(ctx context.Context, id osm.FeatureID) (orb.Geometry, error) {
+	stmt := s.getWayGeometryPreparedStatement
+	if id.Type() == osm.TypeRelation {
+		stmt = s.getRelationGeometryPreparedStatement
+	}
+
+	var wkbBytes []byte
+	if err := stmt.QueryRowContext(ctx, id.Ref()).Scan(&wkbBytes); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	if wkbBytes == nil {
+		return nil, nil
+	}
+
+	geom, err := wkb.Unmarshal(wkbBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal WKB: %w", err)
+	}
+
+	return geom, nil
+}
+
+func (s *sqlitenavgraphindexer) insertGraph(ctx context.Context, graph *navgraph.Graph) error {
+	for _, node := range graph.Nodes {
+		tagsJson, err := json.Marshal(node.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+
+		_, err = s.insertNodePreparedStatement.ExecContext(ctx,
+			node.ID, node.Level, string(node.Kind), node.Feature, string(tagsJson), node.Point.Lon(), node.Point.Lat(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert node: %w", err)
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		_, err := s.insertEdgePreparedStatement.ExecContext(ctx,
+			edge.From, edge.To, string(edge.Kind), edge.Length, edge.Levels, edge.Oneway, edge.Wheelchair, edge.Feature, edge.Cost,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert edge: %w", err)
+		}
+	}
+
+	for _, area := range graph.Areas {
+		tagsJson, err := json.Marshal(area.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+
+		nodesJson, err := json.Marshal(area.Nodes)
+		if err != nil {
+			return fmt.Errorf("failed to marshal nodes: %w", err)
+		}
+
+		geomBytes, err := wkb.Marshal(area.Geometry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal geometry: %w", err)
+		}
+
+		_, err = s.insertAreaPreparedStatement.ExecContext(ctx, area.ID, area.Level, string(tagsJson), string(nodesJson), geomBytes)
+		if err != nil {
+			return fmt.Errorf("failed to insert area: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -5,9 +5,17 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/paulkoehlerdev/OsmInTile/migrations"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/geoutil"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/idset"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/indoorvalidation"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/navgraph"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
 	_ "github.com/paulkoehlerdev/OsmInTile/pkg/libraries/sqlitedriver"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/encoding/wkb"
@@ -20,171 +28,1144 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 )
 
 var _ repository.OsmDataRepository = (*SqliteOsmDataRepository)(nil)
 
+// wayGeometryQuery builds the geometry of the way bound to the parameter.
+// Repaired polygons are preferred, otherwise closed ways become polygons, BuildArea returns NULL for open ways.
+const wayGeometryQuery = `
+	SELECT ST_AsBinary(IFNULL(
+		(SELECT repaired_area.geom FROM repaired_area WHERE repaired_area.feature_type = 'way' AND repaired_area.feature_id = ?1),
+		IFNULL(BuildArea(l.geom), l.geom)
+	)) as geom
+	FROM (
+		SELECT MakeLine(n.geom) as geom
+		FROM (
+			SELECT node.geom as geom
+			FROM way_node
+			JOIN node ON node.node_id = way_node.node_id
+			WHERE way_node.way_id = ?1
+			ORDER BY way_node.sequence_id
+		) as n
+	) as l
+`
+
+// relationGeometryQuery builds the geometry of the relation bound to the parameter from its member ways.
+// Repaired polygons are preferred, otherwise multipolygons become polygons, Polygonize returns NULL for other relations.
+const relationGeometryQuery = `
+	SELECT ST_AsBinary(IFNULL(
+		(SELECT repaired_area.geom FROM repaired_area WHERE repaired_area.feature_type = 'relation' AND repaired_area.feature_id = ?1),
+		IFNULL(Polygonize(m.geom), Collect(m.geom))
+	)) as geom
+	FROM (
+		SELECT (
+			SELECT MakeLine(geom)
+			FROM node
+			JOIN main.way_node wn on node.node_id = wn.node_id
+			WHERE wn.way_id = relation_member.member_id
+			ORDER BY wn.sequence_id
+		) as geom
+		FROM relation_member
+		WHERE relation_member.relation_id = ?1
+		  AND relation_member.member_type = 'way'
+	) as m
+`
+
 func NewSqliteOsmDataRepository(sqliteConnString string) (*SqliteOsmDataRepository, error) {
 	sqlConn, err := sql.Open("sqlite3_custom", sqliteConnString)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open osm database connection: %w", err)
+		return nil, fmt.Errorf("failed to open osm database connection: %w", err)
+	}
+
+	return (&SqliteOsmDataRepository{
+		conn: sqlConn,
+	}).init()
+}
+
+type SqliteOsmDataRepository struct {
+	conn                          *sql.DB
+	getBasePreparedStatement      *sql.Stmt
+	getPoisPreparedStatement      *sql.Stmt
+	getMapBoundsPreparedStatement *sql.Stmt
+	getMapCenterPreparedStatement *sql.Stmt
+
+	getLevelTagsPreparedStatement        *sql.Stmt
+	getNodeGeometryPreparedStatement     *sql.Stmt
+	getWayGeometryPreparedStatement      *sql.Stmt
+	getRelationGeometryPreparedStatement *sql.Stmt
+	getBuildingsPreparedStatement        *sql.Stmt
+	getBuildingPreparedStatement         *sql.Stmt
+	getBuildingsAtPreparedStatement      *sql.Stmt
+	getFeatureTagsPreparedStatement      *sql.Stmt
+	getRelationMembersPreparedStatement  *sql.Stmt
+	getIndoorAreasAtPreparedStatement    *sql.Stmt
+	getPoisWithinPreparedStatement       *sql.Stmt
+	searchPreparedStatement              *sql.Stmt
+	getNavNodesPreparedStatement         *sql.Stmt
+	getNavEdgesPreparedStatement         *sql.Stmt
+	getNavAreasPreparedStatement         *sql.Stmt
+	getGeometryRepairsPreparedStatement  *sql.Stmt
+
+	// searchEnabled is false if sqlite is built without fts5, see prepareSearch
+	searchEnabled bool
+}
+
+func (s *SqliteOsmDataRepository) init() (*SqliteOsmDataRepository, error) {
+	err := s.prepareDatabase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare database: %w", err)
+	}
+
+	s.getBasePreparedStatement, err = s.conn.Prepare(`
+		SELECT ST_AsBinary(IFNULL(
+			(SELECT repaired_area.geom FROM repaired_area WHERE repaired_area.feature_type = 'way' AND repaired_area.feature_id = way.way_id),
+			(
+				SELECT BuildArea(MakeLine(geom))
+				FROM node
+				JOIN main.way_node wn on node.node_id = wn.node_id
+				WHERE wn.way_id = way.way_id
+			)
+		)) as geom,
+		(
+		    SELECT json_group_object(way_tag.key, way_tag.value)
+		    FROM way_tag
+		    WHERE way_tag.way_id = way.way_id
+		      AND (way_tag.key IN ('indoor', 'room', 'name', 'ref') OR way_tag.key LIKE 'name:%')
+		) as json
+		FROM way
+		WHERE way.way_id IN (SELECT way_tag.way_id FROM way_tag WHERE way_tag.key = 'indoor')
+		  AND way.way_id IN (SELECT way_tag.way_id FROM way_tag WHERE way_tag.key = 'level' AND way_tag.value LIKE ?)
+		  AND ((SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MAX(way_node.sequence_id) FROM way_node WHERE way_node.way_id = way.way_id) as n) =
+			   (SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MIN(way_node.sequence_id) FROM way_node WHERE way_node.way_id = way.way_id) as n)
+			OR way.way_id IN (SELECT repaired_area.feature_id FROM repaired_area WHERE repaired_area.feature_type = 'way'))
+		  AND way.way_id IN (SELECT DISTINCT way_node.way_id FROM way_node JOIN node on way_node.node_id = node.node_id WHERE ST_Intersects(node.geom, ST_GeomFromWKB(?)))
+		  AND (? = '' OR way.way_id IN (SELECT building_feature.feature_id FROM building_feature WHERE building_feature.feature_type = 'way' AND building_feature.building_id = ?))
+		UNION ALL
+		SELECT ST_AsBinary(Polygonize(s.geom)) as geom,
+	   	(
+		    SELECT json_group_object(relation_tag.key, relation_tag.value)
+		    FROM relation_tag
+		    WHERE relation_tag.relation_id = s.relation_id
+		      AND (relation_tag.key IN ('indoor', 'room', 'name', 'ref') OR relation_tag.key LIKE 'name:%')
+		) as json
+		FROM
+		(SELECT (
+			SELECT MakeLine(geom)
+			FROM node
+		 	JOIN main.way_node wn on node.node_id = wn.node_id
+			WHERE wn.way_id = relation_member.member_id
+			ORDER BY wn.sequence_id
+		) as geom, relation_id
+		FROM relation_member
+		WHERE relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'indoor')
+		  AND relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'level' AND relation_tag.value LIKE ?)
+		  AND relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'type' AND relation_tag.value = 'multipolygon')
+		  AND relation_id NOT IN (SELECT repaired_area.feature_id FROM repaired_area WHERE repaired_area.feature_type = 'relation')
+		  AND member_type = 'way'
+		  AND (SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MAX(way_node.sequence_id) FROM way_node WHERE way_node.way_id = relation_member.member_id) as n) =
+			  (SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MIN(way_node.sequence_id) FROM way_node WHERE way_node.way_id = relation_member.member_id) as n)
+		  AND member_id IN (SELECT DISTINCT way_node.way_id FROM way_node JOIN node on way_node.node_id = node.node_id WHERE ST_Intersects(node.geom, ST_GeomFromWKB(?)))
+		  AND (? = '' OR relation_id IN (SELECT building_feature.feature_id FROM building_feature WHERE building_feature.feature_type = 'relation' AND building_feature.building_id = ?))
+		ORDER BY member_role = 'outer' DESC, sequence_id) as s
+		GROUP BY s.relation_id
+		UNION ALL
+		SELECT ST_AsBinary(repaired_area.geom) as geom,
+		(
+		    SELECT json_group_object(relation_tag.key, relation_tag.value)
+		    FROM relation_tag
+		    WHERE relation_tag.relation_id = repaired_area.feature_id
+		      AND (relation_tag.key IN ('indoor', 'room', 'name', 'ref') OR relation_tag.key LIKE 'name:%')
+		) as json
+		FROM repaired_area
+		WHERE repaired_area.feature_type = 'relation'
+		  AND repaired_area.feature_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'indoor')
+		  AND repaired_area.feature_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'level' AND relation_tag.value LIKE ?)
+		  AND ST_Intersects(repaired_area.geom, ST_GeomFromWKB(?, 4326))
+		  AND (? = '' OR repaired_area.feature_id IN (SELECT building_feature.feature_id FROM building_feature WHERE building_feature.feature_type = 'relation' AND building_feature.building_id = ?))
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	s.getPoisPreparedStatement, err = s.conn.Prepare(`
+		SELECT ST_AsBinary(node.geom) as geom,
+		(
+		    SELECT json_group_object(node_tag.key, node_tag.value)
+		    FROM node_tag
+		    WHERE node_tag.node_id = node.node_id
+		      AND (node_tag.key IN ('amenity', 'shop', 'highway', 'entrance', 'door', 'name', 'ref') OR node_tag.key LIKE 'name:%')
+		) as json,
+		(SELECT node_tag.value FROM node_tag WHERE node_tag.node_id = node.node_id AND node_tag.key = 'level') as level
+		FROM node
+		WHERE node.node_id IN (
+			SELECT node_tag.node_id FROM node_tag
+			WHERE node_tag.key IN ('amenity', 'shop', 'entrance')
+			   OR (node_tag.key = 'highway' AND node_tag.value = 'elevator')
+		)
+		  AND node.node_id IN (SELECT node_tag.node_id FROM node_tag WHERE node_tag.key = 'level')
+		  AND ST_Intersects(node.geom, ST_GeomFromWKB(?, 4326))
+		  AND (? = '' OR node.node_id IN (SELECT building_feature.feature_id FROM building_feature WHERE building_feature.feature_type = 'node' AND building_feature.building_id = ?))
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	s.getMapBoundsPreparedStatement, err = s.conn.Prepare(`
+		SELECT ST_AsBinary(Extent(n.geom)) as geom
+		FROM (SELECT Collect(geom) as geom FROM node) as n
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	s.getMapCenterPreparedStatement, err = s.conn.Prepare(`
+		SELECT ST_AsBinary(Centroid(n.geom)) as geom
+		FROM (SELECT Collect(geom) as geom FROM node) as n
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	if err := s.prepareFeatureStatements(); err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	return s, nil
+}
+
+// prepareFeatureStatements prepares the statements querying single features and their level information
+func (s *SqliteOsmDataRepository) prepareFeatureStatements() error {
+	var err error
+
+	s.getLevelTagsPreparedStatement, err = s.conn.Prepare(`
+		SELECT level_tag.value as level,
+		(
+			SELECT ref_tag.value FROM way_tag as ref_tag
+			WHERE ref_tag.way_id = level_tag.way_id AND ref_tag.key = 'level:ref'
+		) as ref,
+		ST_AsBinary(Extent(node.geom)) as geom
+		FROM way_tag as level_tag
+		JOIN way_node ON way_node.way_id = level_tag.way_id
+		JOIN node ON node.node_id = way_node.node_id
+		WHERE level_tag.key = 'level'
+		GROUP BY level_tag.way_id
+		HAVING MbrIntersects(Extent(node.geom), BuildMbr(?, ?, ?, ?, 4326))
+		UNION ALL
+		SELECT level_tag.value as level,
+		(
+			SELECT ref_tag.value FROM relation_tag as ref_tag
+			WHERE ref_tag.relation_id = level_tag.relation_id AND ref_tag.key = 'level:ref'
+		) as ref,
+		ST_AsBinary(Extent(node.geom)) as geom
+		FROM relation_tag as level_tag
+		JOIN relation_member ON relation_member.relation_id = level_tag.relation_id AND relation_member.member_type = 'way'
+		JOIN way_node ON way_node.way_id = relation_member.member_id
+		JOIN node ON node.node_id = way_node.node_id
+		WHERE level_tag.key = 'level'
+		GROUP BY level_tag.relation_id
+		HAVING MbrIntersects(Extent(node.geom), BuildMbr(?, ?, ?, ?, 4326))
+		UNION ALL
+		SELECT level_tag.value as level,
+		(
+			SELECT ref_tag.value FROM node_tag as ref_tag
+			WHERE ref_tag.node_id = level_tag.node_id AND ref_tag.key = 'level:ref'
+		) as ref,
+		ST_AsBinary(node.geom) as geom
+		FROM node_tag as level_tag
+		JOIN node ON node.node_id = level_tag.node_id
+		WHERE level_tag.key = 'level'
+		  AND MbrIntersects(node.geom, BuildMbr(?, ?, ?, ?, 4326))
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getNodeGeometryPreparedStatement, err = s.conn.Prepare(`
+		SELECT ST_AsBinary(node.geom) as geom
+		FROM node
+		WHERE node.node_id = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getWayGeometryPreparedStatement, err = s.conn.Prepare(wayGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.getRelationGeometryPreparedStatement, err = s.conn.Prepare(relationGeometryQuery)
+	if err != nil {
+		return err
+	}
+
+	s.getBuildingsPreparedStatement, err = s.conn.Prepare(`
+		SELECT building.building_id, building.name, building.levels, ST_AsBinary(Envelope(building.outline)) as geom
+		FROM building
+		WHERE MbrIntersects(building.outline, BuildMbr(?, ?, ?, ?, 4326))
+		ORDER BY building.building_id
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getBuildingPreparedStatement, err = s.conn.Prepare(`
+		SELECT building.building_id, building.name, building.levels, ST_AsBinary(building.outline) as geom
+		FROM building
+		WHERE building.building_id = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getBuildingsAtPreparedStatement, err = s.conn.Prepare(`
+		SELECT building.building_id, building.name, building.levels, ST_AsBinary(Envelope(building.outline)) as geom
+		FROM building
+		WHERE MbrContains(building.outline, MakePoint(?, ?, 4326))
+		  AND ST_Contains(building.outline, MakePoint(?, ?, 4326))
+		ORDER BY ST_Area(building.outline)
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getFeatureTagsPreparedStatement, err = s.conn.Prepare(`
+		SELECT node_tag.key, node_tag.value FROM node_tag WHERE ? = 'node' AND node_tag.node_id = ?
+		UNION ALL
+		SELECT way_tag.key, way_tag.value FROM way_tag WHERE ? = 'way' AND way_tag.way_id = ?
+		UNION ALL
+		SELECT relation_tag.key, relation_tag.value FROM relation_tag WHERE ? = 'relation' AND relation_tag.relation_id = ?
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getRelationMembersPreparedStatement, err = s.conn.Prepare(`
+		SELECT relation_member.member_type, relation_member.member_id, relation_member.member_role
+		FROM relation_member
+		WHERE relation_member.relation_id = ?
+		ORDER BY relation_member.sequence_id
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getPoisWithinPreparedStatement, err = s.conn.Prepare(`
+		SELECT node.node_id, ST_AsBinary(node.geom) as geom,
+		(
+		    SELECT json_group_object(node_tag.key, node_tag.value)
+		    FROM node_tag
+		    WHERE node_tag.node_id = node.node_id
+		) as json
+		FROM node
+		WHERE node.node_id IN (
+			SELECT node_tag.node_id FROM node_tag
+			WHERE node_tag.key IN ('amenity', 'shop', 'entrance')
+			   OR (node_tag.key = 'highway' AND node_tag.value = 'elevator')
+		)
+		  AND MbrIntersects(node.geom, BuildMbr(?, ?, ?, ?, 4326))
+	`)
+	if err != nil {
+		return err
+	}
+
+	if s.searchEnabled {
+		// name is weighted highest, followed by ref, translated names and the shop or amenity
+		s.searchPreparedStatement, err = s.conn.Prepare(`
+			SELECT search.feature_type, search.feature_id, search.level, search.name, search.ref, search.category,
+			       bm25(search, 0, 0, 0, 0, 0, 0, 10.0, 5.0, 8.0, 2.0) as rank
+			FROM search
+			WHERE search MATCH ?1
+			  AND search.lon BETWEEN ?2 AND ?3
+			  AND search.lat BETWEEN ?4 AND ?5
+			  AND (?6 IS NULL OR instr(search.levels, ';' || ?6 || ';') > 0)
+			ORDER BY rank
+			LIMIT ?7
+		`)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.getNavNodesPreparedStatement, err = s.conn.Prepare(`
+		SELECT nav_node.node_id, X(nav_node.geom), Y(nav_node.geom), nav_node.level, nav_node.kind, nav_node.feature, nav_node.tags
+		FROM nav_node
+		ORDER BY nav_node.node_id
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getNavEdgesPreparedStatement, err = s.conn.Prepare(`
+		SELECT nav_edge.from_node, nav_edge.to_node, nav_edge.kind, nav_edge.length, nav_edge.levels,
+		       nav_edge.oneway, nav_edge.wheelchair, nav_edge.feature, nav_edge.cost
+		FROM nav_edge
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getNavAreasPreparedStatement, err = s.conn.Prepare(`
+		SELECT nav_area.area_id, nav_area.level, nav_area.tags, nav_area.nodes, ST_AsBinary(nav_area.geom) as geom
+		FROM nav_area
+	`)
+	if err != nil {
+		return err
+	}
+
+	s.getGeometryRepairsPreparedStatement, err = s.conn.Prepare(`
+		SELECT repaired_area.feature_type, repaired_area.feature_id, repaired_area.repairs
+		FROM repaired_area
+		ORDER BY repaired_area.feature_type, repaired_area.feature_id
+	`)
+	if err != nil {
+		return err
+	}
+
+	// the spatial index finds the indoor areas whose extent contains the point, the exact check happens on the geometry
+	s.getIndoorAreasAtPreparedStatement, err = s.conn.Prepare(`
+		SELECT indoor_area.feature_type, indoor_area.feature_id, indoor_area.tags, ST_AsBinary(indoor_area.geom) as geom
+		FROM idx_indoor_area_geom
+		JOIN indoor_area ON indoor_area.rowid = idx_indoor_area_geom.pkid
+		WHERE idx_indoor_area_geom.xmin <= ?1 AND idx_indoor_area_geom.xmax >= ?1
+		  AND idx_indoor_area_geom.ymin <= ?2 AND idx_indoor_area_geom.ymax >= ?2
+		ORDER BY indoor_area.feature_type = 'relation', indoor_area.feature_id
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SqliteOsmDataRepository) prepareDatabase() error {
+	file, err := migrations.FS.ReadFile("schema.sql")
+	if err != nil {
+		return fmt.Errorf("failed to open schema file: %w", err)
+	}
+
+	for _, query := range strings.Split(string(file), ";") {
+		if _, err := s.conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to execute schema file at query %s: %w", query, err)
+		}
+	}
+
+	if err := s.migrateNavEdge(); err != nil {
+		return err
+	}
+
+	return s.prepareSearch()
+}
+
+// migrateNavEdge renames the column area of nav_edge of databases created by older versions to feature
+func (s *SqliteOsmDataRepository) migrateNavEdge() error {
+	var count int
+	err := s.conn.QueryRow("SELECT count(*) FROM pragma_table_info('nav_edge') WHERE name = 'area'").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to query nav_edge columns: %w", err)
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	if _, err := s.conn.Exec("ALTER TABLE nav_edge RENAME COLUMN area TO feature"); err != nil {
+		return fmt.Errorf("failed to rename nav_edge column area to feature: %w", err)
+	}
+
+	return nil
+}
+
+// prepareSearch creates the full text search table. It needs sqlite to be built with fts5 (build tag sqlite_fts5),
+// without it search is disabled instead of failing, as the rest of the server does not depend on it.
+func (s *SqliteOsmDataRepository) prepareSearch() error {
+	var fts5 bool
+	if err := s.conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		return fmt.Errorf("failed to check for fts5: %w", err)
+	}
+
+	if !fts5 {
+		log.Printf("sqlite is built without fts5, search is disabled (build with the tag sqlite_fts5)")
+		return nil
+	}
+
+	// the search table is filled at import, outdated versions are dropped and filled again by the next import
+	var table string
+	err := s.conn.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'search'").Scan(&table)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to query search table: %w", err)
+	}
+
+	if table != "" && !strings.Contains(table, "levels") {
+		log.Printf("search table is outdated, search is empty until the next import")
+		if _, err := s.conn.Exec("DROP TABLE search"); err != nil {
+			return fmt.Errorf("failed to drop outdated search table: %w", err)
+		}
+	}
+
+	file, err := migrations.FS.ReadFile("search.sql")
+	if err != nil {
+		return fmt.Errorf("failed to open search schema file: %w", err)
+	}
+
+	if _, err := s.conn.Exec(string(file)); err != nil {
+		return fmt.Errorf("failed to create search table: %w", err)
+	}
+
+	s.searchEnabled = true
+	return nil
+}
+
+func (s *SqliteOsmDataRepository) GetBase(ctx context.Context, level int, building osm.FeatureID, bound orb.Bound) (*geojson.FeatureCollection, error) {
+	boundStr, err := wkb.MarshalToHex(bound.ToPolygon())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bound: %w", err)
+	}
+
+	levelStr := fmt.Sprintf("%%%d%%", level)
+	buildingStr := formatBuildingFilter(building)
+	rows, err := s.getBasePreparedStatement.QueryContext(ctx,
+		levelStr, boundStr, buildingStr, buildingStr,
+		levelStr, boundStr, buildingStr, buildingStr,
+		levelStr, boundStr, buildingStr, buildingStr,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return s.loadWBKRowsAndJsonPropertiesIntoGeojson(rows)
+}
+
+func (s *SqliteOsmDataRepository) GetPois(ctx context.Context, level int, building osm.FeatureID, bound orb.Bound) (*geojson.FeatureCollection, error) {
+	boundBytes, err := wkb.Marshal(bound.ToPolygon())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bound: %w", err)
+	}
+
+	buildingStr := formatBuildingFilter(building)
+	rows, err := s.getPoisPreparedStatement.QueryContext(ctx, boundBytes, buildingStr, buildingStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	// level tags are lists and ranges like 0;1 or -1-2, they are matched after parsing them
+	out := geojson.NewFeatureCollection()
+	for rows.Next() {
+		var wbkBytes []byte
+		var propertiesStr, levelStr string
+		if err := rows.Scan(&wbkBytes, &propertiesStr, &levelStr); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if !osmlevel.Contains(levelStr, float64(level)) {
+			continue
+		}
+
+		geom, err := wkb.Unmarshal(wbkBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal geom: %w", err)
+		}
+
+		feat := geojson.NewFeature(geom)
+		if err := json.Unmarshal([]byte(propertiesStr), &feat.Properties); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal properties: %w", err)
+		}
+
+		out.Append(feat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetMapBounds(ctx context.Context) (orb.Bound, error) {
+	row := s.getMapBoundsPreparedStatement.QueryRowContext(ctx)
+
+	var wkbBytes []byte
+	err := row.Scan(&wkbBytes)
+	if err != nil {
+		return orb.Bound{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	geom, err := wkb.Unmarshal(wkbBytes)
+	if err != nil {
+		return orb.Bound{}, fmt.Errorf("failed to unmarshal WKB: %w", err)
+	}
+
+	return geom.Bound(), nil
+}
+
+func (s *SqliteOsmDataRepository) GetMapCenter(ctx context.Context) (orb.Point, error) {
+	row := s.getMapCenterPreparedStatement.QueryRowContext(ctx)
+
+	var wkbBytes []byte
+	err := row.Scan(&wkbBytes)
+	if err != nil {
+		return orb.Point{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	geom, err := wkb.Unmarshal(wkbBytes)
+	if err != nil {
+		return orb.Point{}, fmt.Errorf("failed to unmarshal WKB: %w", err)
+	}
+
+	point, ok := geom.(orb.Point)
+	if !ok {
+		return orb.Point{}, fmt.Errorf("failed to cast to point, geom is not a point: %w", err)
+	}
+
+	return point, nil
+}
+
+func (s *SqliteOsmDataRepository) GetLevelTags(ctx context.Context, bound orb.Bound) ([]entities.LevelTag, error) {
+	mbr := []any{bound.Min.Lon(), bound.Min.Lat(), bound.Max.Lon(), bound.Max.Lat()}
+
+	args := make([]any, 0, 3*len(mbr))
+	for range 3 {
+		args = append(args, mbr...)
+	}
+
+	rows, err := s.getLevelTagsPreparedStatement.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]entities.LevelTag, 0)
+	for rows.Next() {
+		var level string
+		var ref sql.NullString
+		var wkbBytes []byte
+		if err := rows.Scan(&level, &ref, &wkbBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		geom, err := wkb.Unmarshal(wkbBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal geom: %w", err)
+		}
+
+		out = append(out, entities.LevelTag{
+			Level:    level,
+			LevelRef: ref.String,
+			Bound:    geom.Bound(),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetFeatureGeometry(ctx context.Context, id osm.FeatureID) (orb.Geometry, error) {
+	var row *sql.Row
+	switch id.Type() {
+	case osm.TypeNode:
+		row = s.getNodeGeometryPreparedStatement.QueryRowContext(ctx, id.Ref())
+	case osm.TypeWay:
+		row = s.getWayGeometryPreparedStatement.QueryRowContext(ctx, id.Ref())
+	case osm.TypeRelation:
+		row = s.getRelationGeometryPreparedStatement.QueryRowContext(ctx, id.Ref())
+	default:
+		return nil, fmt.Errorf("unsupported feature type %s", id.Type())
+	}
+
+	var wkbBytes []byte
+	err := row.Scan(&wkbBytes)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && wkbBytes == nil) {
+		return nil, fmt.Errorf("feature %s: %w", id, repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	geom, err := wkb.Unmarshal(wkbBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal WKB: %w", err)
+	}
+
+	return geom, nil
+}
+
+func (s *SqliteOsmDataRepository) GetBuildings(ctx context.Context, bound orb.Bound) ([]entities.Building, error) {
+	rows, err := s.getBuildingsPreparedStatement.QueryContext(ctx, bound.Min.Lon(), bound.Min.Lat(), bound.Max.Lon(), bound.Max.Lat())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]entities.Building, 0)
+	for rows.Next() {
+		building, _, err := s.scanBuilding(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, building)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetBuilding(ctx context.Context, id osm.FeatureID) (entities.Building, error) {
+	row := s.getBuildingPreparedStatement.QueryRowContext(ctx, osmid.Format(id))
+
+	building, outline, err := s.scanBuilding(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Building{}, fmt.Errorf("building %s: %w", id, repository.ErrNotFound)
+	}
+	if err != nil {
+		return entities.Building{}, err
+	}
+
+	building.Outline = geojson.NewGeometry(outline)
+
+	return building, nil
+}
+
+func (s *SqliteOsmDataRepository) GetBuildingsAt(ctx context.Context, point orb.Point) ([]entities.Building, error) {
+	rows, err := s.getBuildingsAtPreparedStatement.QueryContext(ctx, point.Lon(), point.Lat(), point.Lon(), point.Lat())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]entities.Building, 0)
+	for rows.Next() {
+		building, _, err := s.scanBuilding(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, building)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetFeatureTags(ctx context.Context, id osm.FeatureID) (map[string]string, error) {
+	typ, ref := string(id.Type()), id.Ref()
+	rows, err := s.getFeatureTagsPreparedStatement.QueryContext(ctx, typ, ref, typ, ref, typ, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		out[key] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetRelationMembers(ctx context.Context, id osm.RelationID) ([]entities.RelationMember, error) {
+	rows, err := s.getRelationMembersPreparedStatement.QueryContext(ctx, int64(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]entities.RelationMember, 0)
+	for rows.Next() {
+		member := entities.RelationMember{}
+		if err := rows.Scan(&member.Type, &member.ID, &member.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		out = append(out, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetGeometryRepairs(ctx context.Context) ([]entities.GeometryRepair, error) {
+	rows, err := s.getGeometryRepairsPreparedStatement.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]entities.GeometryRepair, 0)
+	for rows.Next() {
+		var typ, repairsStr string
+		var ref int64
+		if err := rows.Scan(&typ, &ref, &repairsStr); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		id, err := osm.Type(typ).FeatureID(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		repair := entities.GeometryRepair{Feature: osmid.Format(id)}
+		if err := json.Unmarshal([]byte(repairsStr), &repair.Repairs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal repairs: %w", err)
+		}
+
+		out = append(out, repair)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetIndoorAreasAt(ctx context.Context, point orb.Point) ([]*geojson.Feature, error) {
+	rows, err := s.getIndoorAreasAtPreparedStatement.QueryContext(ctx, point.Lon(), point.Lat())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]*geojson.Feature, 0)
+	for rows.Next() {
+		var typ, tagsStr string
+		var ref int64
+		var wkbBytes []byte
+		if err := rows.Scan(&typ, &ref, &tagsStr, &wkbBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		geom, err := wkb.Unmarshal(wkbBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal WKB: %w", err)
+		}
+
+		if !geoutil.Contains(geom, point) {
+			continue
+		}
+
+		id, err := osm.Type(typ).FeatureID(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse feature id: %w", err)
+		}
+
+		feat := geojson.NewFeature(geom)
+		feat.ID = osmid.Format(id)
+		if err := json.Unmarshal([]byte(tagsStr), &feat.Properties); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+		}
+
+		out = append(out, feat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetPoisWithin(ctx context.Context, bound orb.Bound) ([]*geojson.Feature, error) {
+	rows, err := s.getPoisWithinPreparedStatement.QueryContext(ctx, bound.Min.Lon(), bound.Min.Lat(), bound.Max.Lon(), bound.Max.Lat())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]*geojson.Feature, 0)
+	for rows.Next() {
+		var nodeID int64
+		var wkbBytes []byte
+		var propertiesStr string
+		if err := rows.Scan(&nodeID, &wkbBytes, &propertiesStr); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		geom, err := wkb.Unmarshal(wkbBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal geom: %w", err)
+		}
+
+		feat := geojson.NewFeature(geom)
+		feat.ID = osmid.Format(osm.NodeID(nodeID).FeatureID())
+
+		err = json.Unmarshal([]byte(propertiesStr), &feat.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal properties: %w", err)
+		}
+
+		out = append(out, feat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) Search(ctx context.Context, query string, bound orb.Bound, level *float64, limit int) ([]*geojson.Feature, error) {
+	if !s.searchEnabled {
+		return nil, repository.ErrSearchDisabled
+	}
+
+	match := searchMatchExpression(query)
+	if match == "" {
+		return []*geojson.Feature{}, nil
+	}
+
+	var levelStr sql.NullString
+	if level != nil {
+		levelStr = sql.NullString{String: osmlevel.Format(*level), Valid: true}
+	}
+
+	rows, err := s.searchPreparedStatement.QueryContext(ctx, match, bound.Min.Lon(), bound.Max.Lon(), bound.Min.Lat(), bound.Max.Lat(), levelStr, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
+	defer rows.Close()
 
-	return (&SqliteOsmDataRepository{
-		conn: sqlConn,
-	}).init()
-}
+	type searchRow struct {
+		id                         osm.FeatureID
+		level, name, ref, category sql.NullString
+		rank                       float64
+	}
 
-type SqliteOsmDataRepository struct {
-	conn                          *sql.DB
-	getBasePreparedStatement      *sql.Stmt
-	getMapBoundsPreparedStatement *sql.Stmt
-	getMapCenterPreparedStatement *sql.Stmt
-}
+	hits := make([]searchRow, 0)
+	for rows.Next() {
+		var typ string
+		var ref int64
+		hit := searchRow{}
+		if err := rows.Scan(&typ, &ref, &hit.level, &hit.name, &hit.ref, &hit.category, &hit.rank); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
 
-func (s *SqliteOsmDataRepository) init() (*SqliteOsmDataRepository, error) {
-	err := s.prepareDatabase()
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare database: %w", err)
+		hit.id, err = osm.Type(typ).FeatureID(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse feature id: %w", err)
+		}
+
+		hits = append(hits, hit)
 	}
 
-	s.getBasePreparedStatement, err = s.conn.Prepare(`
-		SELECT ST_AsBinary((
-			SELECT BuildArea(MakeLine(geom))
-			FROM node
-			JOIN main.way_node wn on node.node_id = wn.node_id
-			WHERE wn.way_id = way.way_id
-		)) as geom,
-		(
-		    SELECT json_group_object(way_tag.key, way_tag.value)
-		    FROM way_tag
-		    WHERE way_tag.way_id = way.way_id
-		      AND way_tag.key IN ('indoor', 'room')
-		) as json
-		FROM way
-		WHERE way.way_id IN (SELECT way_tag.way_id FROM way_tag WHERE way_tag.key = 'indoor')
-		  AND way.way_id IN (SELECT way_tag.way_id FROM way_tag WHERE way_tag.key = 'level' AND way_tag.value LIKE ?)
-		  AND (SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MAX(way_node.sequence_id) FROM way_node WHERE way_node.way_id = way.way_id) as n) =
-			  (SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MIN(way_node.sequence_id) FROM way_node WHERE way_node.way_id = way.way_id) as n)
-		  AND way.way_id IN (SELECT DISTINCT way_node.way_id FROM way_node JOIN node on way_node.node_id = node.node_id WHERE ST_Intersects(node.geom, ST_GeomFromWKB(?)))
-		UNION ALL
-		SELECT ST_AsBinary(Polygonize(s.geom)) as geom,
-	   	(
-		    SELECT json_group_object(relation_tag.key, relation_tag.value)
-		    FROM relation_tag
-		    WHERE relation_tag.relation_id = s.relation_id
-		      AND relation_tag.key IN ('indoor', 'room')
-		) as json
-		FROM
-		(SELECT (
-			SELECT MakeLine(geom)
-			FROM node
-		 	JOIN main.way_node wn on node.node_id = wn.node_id
-			WHERE wn.way_id = relation_member.member_id
-			ORDER BY wn.sequence_id
-		) as geom, relation_id
-		FROM relation_member
-		WHERE relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'indoor')
-		  AND relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'level' AND relation_tag.value LIKE ?)
-		  AND relation_id IN (SELECT relation_tag.relation_id FROM relation_tag WHERE relation_tag.key = 'type' AND relation_tag.value = 'multipolygon')
-		  AND member_type = 'way'
-		  AND (SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MAX(way_node.sequence_id) FROM way_node WHERE way_node.way_id = relation_member.member_id) as n) =
-			  (SELECT n.node_id FROM (SELECT way_node.node_id as node_id, MIN(way_node.sequence_id) FROM way_node WHERE way_node.way_id = relation_member.member_id) as n)
-		  AND member_id IN (SELECT DISTINCT way_node.way_id FROM way_node JOIN node on way_node.node_id = node.node_id WHERE ST_Intersects(node.geom, ST_GeomFromWKB(?)))
-		ORDER BY member_role = 'outer' DESC, sequence_id) as s
-		GROUP BY s.relation_id
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
 	}
 
-	s.getMapBoundsPreparedStatement, err = s.conn.Prepare(`
-		SELECT ST_AsBinary(Extent(n.geom)) as geom
-		FROM (SELECT Collect(geom) as geom FROM node) as n
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	out := make([]*geojson.Feature, 0, len(hits))
+	for _, hit := range hits {
+		geom, err := s.GetFeatureGeometry(ctx, hit.id)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		feat := geojson.NewFeature(geom)
+		feat.ID = osmid.Format(hit.id)
+		feat.Properties["rank"] = hit.rank
+		for key, value := range map[string]sql.NullString{"level": hit.level, "name": hit.name, "ref": hit.ref, "category": hit.category} {
+			if value.Valid {
+				feat.Properties[key] = value.String
+			}
+		}
+
+		out = append(out, feat)
 	}
 
-	s.getMapCenterPreparedStatement, err = s.conn.Prepare(`
-		SELECT ST_AsBinary(Centroid(n.geom)) as geom
-		FROM (SELECT Collect(geom) as geom FROM node) as n
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	return out, nil
+}
+
+func (s *SqliteOsmDataRepository) GetNavGraph(ctx context.Context) (*navgraph.Graph, error) {
+	graph := &navgraph.Graph{}
+
+	if err := s.loadNavNodes(ctx, graph); err != nil {
+		return nil, fmt.Errorf("failed to load nodes: %w", err)
 	}
 
-	return s, nil
+	if err := s.loadNavEdges(ctx, graph); err != nil {
+		return nil, fmt.Errorf("failed to load edges: %w", err)
+	}
+
+	if err := s.loadNavAreas(ctx, graph); err != nil {
+		return nil, fmt.Errorf("failed to load areas: %w", err)
+	}
+
+	return graph, nil
 }
 
-func (s *SqliteOsmDataRepository) prepareDatabase() error {
-	file, err := migrations.FS.ReadFile("schema.sql")
+func (s *SqliteOsmDataRepository) GetValidationData(ctx context.Context) (indoorvalidation.Data, error) {
+	loader := &sqlitevalidationloader{conn: s.conn}
+	return loader.load(ctx)
+}
+
+func (s *SqliteOsmDataRepository) loadNavNodes(ctx context.Context, graph *navgraph.Graph) error {
+	rows, err := s.getNavNodesPreparedStatement.QueryContext(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to open schema file: %w", err)
+		return fmt.Errorf("failed to execute query: %w", err)
 	}
+	defer rows.Close()
 
-	for _, query := range strings.Split(string(file), ";") {
-		if _, err := s.conn.Exec(query); err != nil {
-			return fmt.Errorf("failed to execute schema file at query %s: %w", query, err)
+	for rows.Next() {
+		node := navgraph.Node{}
+		var kind, tagsStr string
+		if err := rows.Scan(&node.ID, &node.Point[0], &node.Point[1], &node.Level, &kind, &node.Feature, &tagsStr); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if node.ID != int64(len(graph.Nodes)) {
+			return fmt.Errorf("failed to load node %d: ids are not contiguous", node.ID)
+		}
+
+		node.Kind = navgraph.NodeKind(kind)
+		if err := json.Unmarshal([]byte(tagsStr), &node.Tags); err != nil {
+			return fmt.Errorf("failed to unmarshal tags: %w", err)
 		}
+
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
 	}
 
 	return nil
 }
 
-func (s *SqliteOsmDataRepository) GetBase(ctx context.Context, level int, bound orb.Bound) (*geojson.FeatureCollection, error) {
-	boundStr, err := wkb.MarshalToHex(bound.ToPolygon())
+func (s *SqliteOsmDataRepository) loadNavEdges(ctx context.Context, graph *navgraph.Graph) error {
+	rows, err := s.getNavEdgesPreparedStatement.QueryContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal bound: %w", err)
+		return fmt.Errorf("failed to execute query: %w", err)
 	}
+	defer rows.Close()
 
-	levelStr := fmt.Sprintf("%%%d%%", level)
-	rows, err := s.getBasePreparedStatement.QueryContext(ctx, levelStr, boundStr, levelStr, boundStr)
+	for rows.Next() {
+		edge := navgraph.Edge{}
+		var kind string
+		err := rows.Scan(&edge.From, &edge.To, &kind, &edge.Length, &edge.Levels, &edge.Oneway, &edge.Wheelchair, &edge.Feature, &edge.Cost)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		edge.Kind = navgraph.EdgeKind(kind)
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SqliteOsmDataRepository) loadNavAreas(ctx context.Context, graph *navgraph.Graph) error {
+	rows, err := s.getNavAreasPreparedStatement.QueryContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
-	return s.loadWBKRowsAndJsonPropertiesIntoGeojson(rows)
-}
+	for rows.Next() {
+		area := navgraph.Area{}
+		var tagsStr, nodesStr string
+		var wkbBytes []byte
+		if err := rows.Scan(&area.ID, &area.Level, &tagsStr, &nodesStr, &wkbBytes); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
 
-func (s *SqliteOsmDataRepository) GetMapBounds(ctx context.Context) (orb.Bound, error) {
-	row := s.getMapBoundsPreparedStatement.QueryRowContext(ctx)
+		if err := json.Unmarshal([]byte(tagsStr), &area.Tags); err != nil {
+			return fmt.Errorf("failed to unmarshal tags: %w", err)
+		}
 
-	var wkbBytes []byte
-	err := row.Scan(&wkbBytes)
-	if err != nil {
-		return orb.Bound{}, fmt.Errorf("failed to scan row: %w", err)
+		if err := json.Unmarshal([]byte(nodesStr), &area.Nodes); err != nil {
+			return fmt.Errorf("failed to unmarshal nodes: %w", err)
+		}
+
+		area.Geometry, err = wkb.Unmarshal(wkbBytes)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal geom: %w", err)
+		}
+
+		graph.Areas = append(graph.Areas, area)
 	}
 
-	geom, err := wkb.Unmarshal(wkbBytes)
-	if err != nil {
-		return orb.Bound{}, fmt.Errorf("failed to unmarshal WKB: %w", err)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
 	}
 
-	return geom.Bound(), nil
+	return nil
 }
 
-func (s *SqliteOsmDataRepository) GetMapCenter(ctx context.Context) (orb.Point, error) {
-	row := s.getMapCenterPreparedStatement.QueryRowContext(ctx)
+// searchMatchExpression turns the user input into a fts5 query, matching every word as a prefix
+func searchMatchExpression(query string) string {
+	words := strings.Fields(query)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+
+	return strings.Join(terms, " ")
+}
 
+func (s *SqliteOsmDataRepository) scanBuilding(row interface{ Scan(dest ...any) error }) (entities.Building, orb.Geometry, error) {
+	var name sql.NullString
+	var levelsStr string
 	var wkbBytes []byte
-	err := row.Scan(&wkbBytes)
-	if err != nil {
-		return orb.Point{}, fmt.Errorf("failed to scan row: %w", err)
+
+	building := entities.Building{}
+	if err := row.Scan(&building.ID, &name, &levelsStr, &wkbBytes); err != nil {
+		return entities.Building{}, nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	building.Name = name.String
+
+	if err := json.Unmarshal([]byte(levelsStr), &building.Levels); err != nil {
+		return entities.Building{}, nil, fmt.Errorf("failed to unmarshal levels: %w", err)
 	}
 
 	geom, err := wkb.Unmarshal(wkbBytes)
 	if err != nil {
-		return orb.Point{}, fmt.Errorf("failed to unmarshal WKB: %w", err)
+		return entities.Building{}, nil, fmt.Errorf("failed to unmarshal WKB: %w", err)
 	}
 
-	point, ok := geom.(orb.Point)
-	if !ok {
-		return orb.Point{}, fmt.Errorf("failed to cast to point, geom is not a point: %w", err)
-	}
+	bound := geom.Bound()
+	building.Bounds = [4]float64{bound.Min.Lon(), bound.Min.Lat(), bound.Max.Lon(), bound.Max.Lat()}
 
-	return point, nil
+	return building, geom, nil
+}
+
+// formatBuildingFilter formats the building for the building_feature filter of queries, the zero id disables the filter
+func formatBuildingFilter(building osm.FeatureID) string {
+	if building == 0 {
+		return ""
+	}
+	return osmid.Format(building)
 }
 
 func (s *SqliteOsmDataRepository) loadWBKRowsAndJsonPropertiesIntoGeojson(rows *sql.Rows) (*geojson.FeatureCollection, error) {
@@ -220,18 +1201,33 @@ func (s *SqliteOsmDataRepository) loadWBKRowsAndJsonPropertiesIntoGeojson(rows *
 // relation["indoor"]["indoor"!="yes"]
 // relation["buildingpart"~"room|verticalpassage|corridor"]
 // relation[~"amenity|shop|railway|highway|building:levels"~"."]
+// relation["building"]
 // way["indoor"]["indoor"!="yes"]
 // way["buildingpart"~"room|verticalpassage|corridor"]
 // way[~"amenity|shop|railway|highway|building:levels"~"."]
+// way["building"]
 // node[~"amenity|shop|railway|highway|door|entrance"~"."]
-func (s *SqliteOsmDataRepository) Import(ctx context.Context, path string) error {
+func (s *SqliteOsmDataRepository) Import(ctx context.Context, path string, options repository.ImportOptions) error {
 	f, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("failed to open osm dump file: %w", err)
 	}
 	defer f.Close()
 
-	tx, err := s.conn.BeginTx(ctx, nil)
+	// pragmas are set per connection, so the import keeps its own
+	conn, err := s.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get osm database connection: %w", err)
+	}
+	defer conn.Close()
+
+	restorePragmas, err := tuneImportPragmas(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("failed to tune osm database: %w", err)
+	}
+	defer restorePragmas()
+
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to start osm database transaction: %w", err)
 	}
@@ -243,68 +1239,162 @@ func (s *SqliteOsmDataRepository) Import(ctx context.Context, path string) error
 		return fmt.Errorf("failed to create sqliteimporter: %w", err)
 	}
 
-	includedObjects := make(map[osm.FeatureID]struct{})
+	includedObjects := idset.New()
+
+	boundary := newImportBoundary(options.Boundary)
+
+	scanPasses := []struct {
+		name string
+		pass func(osm.Scanner, *idset.Set, *importBoundary) error
+	}{
+		{name: "relation filter pass", pass: s.relationImportPass},
+		{name: "way filter pass", pass: s.wayImportPass},
+		{name: "node filter pass", pass: s.nodeImportPass},
+	}
+
+	// the filter passes are followed by the insert and the index pass
+	passCount := len(scanPasses) + 2
+	if boundary.polygon != nil {
+		passCount++
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat osm dump file: %w", err)
+	}
+
+	file := &countingReader{file: f}
+	progress := newImportProgress(file, stat.Size(), passCount, options.Progress)
+
+	if boundary.polygon != nil {
+		scanner, err := s.createImportScanner(ctx, file, path)
+		if err != nil {
+			return fmt.Errorf("failed to create import scanner: %w", err)
+		}
+
+		progress.startPass("boundary pass", func() int { return boundary.features.Len() })
 
-	scanPasses := []func(osm.Scanner, map[osm.FeatureID]struct{}) error{
-		s.relationImportPass,
-		s.wayImportPass,
-		s.nodeImportPass,
+		err = s.boundaryImportPass(progressScanner{Scanner: scanner, progress: progress}, boundary)
+		if err != nil {
+			return fmt.Errorf("failed to find objects within boundary: %w", err)
+		}
+
+		progress.finishPass()
+		log.Printf("found %d objects intersecting the boundary (%s, %s)", boundary.features.Len(), progress, importMemoryUsage(boundary.features))
 	}
 
 	// apply filter passes
-	for i, pass := range scanPasses {
-		scanner, err := s.createImportScanner(ctx, f, path)
+	for _, scanPass := range scanPasses {
+		scanner, err := s.createImportScanner(ctx, file, path)
 		if err != nil {
 			return fmt.Errorf("failed to create import scanner: %w", err)
 		}
 
-		log.Printf("running pass (%d/%d)", i+1, len(scanPasses))
+		progress.startPass(scanPass.name, includedObjects.Len)
 
-		err = pass(scanner, includedObjects)
+		err = scanPass.pass(progressScanner{Scanner: scanner, progress: progress}, includedObjects, boundary)
 		if err != nil {
 			return fmt.Errorf("failed to import objects: %w", err)
 		}
 
-		log.Printf("finished import with %d objects (%d/%d)", len(includedObjects), i+1, len(scanPasses))
+		progress.finishPass()
+		log.Printf("finished %s with %d objects (%s, %s)", scanPass.name, includedObjects.Len(), progress, importMemoryUsage(includedObjects))
+	}
+
+	// indexes are created once after the load, which is faster than updating them for every row
+	indexStatements, err := dropImportIndexes(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to defer osm database indexes: %w", err)
 	}
 
-	scanner, err := s.createImportScanner(ctx, f, path)
+	scanner, err := s.createImportScanner(ctx, file, path)
 	if err != nil {
 		return fmt.Errorf("failed to create import scanner: %w", err)
 	}
 
-	count := 0
+	// apply insert pass, the kept objects are removed from includedObjects while scanning
+	included := includedObjects.Len()
+	progress.startPass("insert pass", func() int { return included - includedObjects.Len() })
 
-	// apply insert pass
-	for scanner.Scan() {
-		obj := scanner.Object()
+	count, err := insertImportPass(ctx, progressScanner{Scanner: scanner, progress: progress}, &sqlimporter, includedObjects)
+	if err != nil {
+		return err
+	}
 
-		// can be cast, as ObjectID == ElementID for OSM Elements
-		elemID := osm.ElementID(obj.ObjectID()).FeatureID()
+	progress.finishPass()
+	log.Printf("Imported %d objects. %d Objects not found (%s)", count, includedObjects.Len(), progress)
 
-		if _, ok := includedObjects[elemID]; !ok {
-			continue
-		}
+	progress.startPass("index pass", func() int { return count })
 
-		delete(includedObjects, elemID)
-		err := sqlimporter.importObject(obj)
-		if err != nil {
-			return fmt.Errorf("failed to import osm database object: %w", err)
-		}
+	start := time.Now()
+	err = createImportIndexes(ctx, tx, indexStatements)
+	if err != nil {
+		return fmt.Errorf("failed to create osm database indexes: %w", err)
+	}
 
-		count++
+	log.Printf("created %d indexes in %s", len(indexStatements), time.Since(start).Round(time.Millisecond))
+
+	geometryRepairer := sqlitegeometryrepairer{}
+	err = geometryRepairer.init(tx)
+	if err != nil {
+		return fmt.Errorf("failed to create geometry repairer: %w", err)
 	}
 
-	log.Printf("Imported %d objects. %d Objects not found", count, len(includedObjects))
+	err = geometryRepairer.index(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to repair geometries: %w", err)
+	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("failed to import osm dump file: %w", err)
+	buildingIndexer := sqlitebuildingindexer{}
+	err = buildingIndexer.init(tx)
+	if err != nil {
+		return fmt.Errorf("failed to create building indexer: %w", err)
+	}
+
+	err = buildingIndexer.index(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to index buildings: %w", err)
+	}
+
+	indoorAreaIndexer := sqliteindoorareaindexer{}
+	err = indoorAreaIndexer.init(tx)
+	if err != nil {
+		return fmt.Errorf("failed to create indoor area indexer: %w", err)
+	}
+
+	err = indoorAreaIndexer.index(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to index indoor areas: %w", err)
+	}
+
+	searchIndexer := sqlitesearchindexer{enabled: s.searchEnabled}
+	err = searchIndexer.init(tx)
+	if err != nil {
+		return fmt.Errorf("failed to create search indexer: %w", err)
+	}
+
+	err = searchIndexer.index(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to index search: %w", err)
+	}
+
+	navGraphIndexer := sqlitenavgraphindexer{}
+	err = navGraphIndexer.init(tx)
+	if err != nil {
+		return fmt.Errorf("failed to create navigation graph indexer: %w", err)
+	}
+
+	err = navGraphIndexer.index(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to index navigation graph: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit osm database transaction: %w", err)
 	}
 
+	progress.finishPass()
+
 	return nil
 }
 
@@ -313,11 +1403,12 @@ func (s *SqliteOsmDataRepository) Import(ctx context.Context, path string) error
 // relation["indoor"]["indoor"!="yes"]
 // relation["buildingpart"~"room|verticalpassage|corridor"]
 // relation[~"amenity|shop|railway|highway|building:levels"~"."]
-func (s *SqliteOsmDataRepository) relationImportPass(scanner osm.Scanner, includedObjects map[osm.FeatureID]struct{}) error {
+// relation["building"]
+func (s *SqliteOsmDataRepository) relationImportPass(scanner osm.Scanner, includedObjects *idset.Set, boundary *importBoundary) error {
 	includeRelation := func(relation *osm.Relation) {
-		includedObjects[relation.FeatureID()] = struct{}{}
+		includedObjects.Add(relation.FeatureID())
 		for _, member := range relation.Members.FeatureIDs() {
-			includedObjects[member] = struct{}{}
+			includedObjects.Add(member)
 		}
 	}
 
@@ -329,11 +1420,15 @@ func (s *SqliteOsmDataRepository) relationImportPass(scanner osm.Scanner, includ
 		}
 
 		// early return for passthrough
-		if _, ok := includedObjects[relation.FeatureID()]; ok {
+		if includedObjects.Contains(relation.FeatureID()) {
 			includeRelation(relation)
 			continue
 		}
 
+		if !boundary.intersects(relation.FeatureID()) {
+			continue
+		}
+
 		tags := relation.TagMap()
 
 		// relation["indoor"]["indoor"!="yes"]
@@ -361,6 +1456,12 @@ func (s *SqliteOsmDataRepository) relationImportPass(scanner osm.Scanner, includ
 			includeRelation(relation)
 			continue
 		}
+
+		// relation["building"]
+		if _, ok := tags["building"]; ok {
+			includeRelation(relation)
+			continue
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -375,11 +1476,12 @@ func (s *SqliteOsmDataRepository) relationImportPass(scanner osm.Scanner, includ
 // way["indoor"]["indoor"!="yes"]
 // way["buildingpart"~"room|verticalpassage|corridor"]
 // way[~"amenity|shop|railway|highway|building:levels"~"."]
-func (s *SqliteOsmDataRepository) wayImportPass(scanner osm.Scanner, includedObjects map[osm.FeatureID]struct{}) error {
+// way["building"]
+func (s *SqliteOsmDataRepository) wayImportPass(scanner osm.Scanner, includedObjects *idset.Set, boundary *importBoundary) error {
 	includeWay := func(way *osm.Way) {
-		includedObjects[way.FeatureID()] = struct{}{}
+		includedObjects.Add(way.FeatureID())
 		for _, node := range way.Nodes.FeatureIDs() {
-			includedObjects[node] = struct{}{}
+			includedObjects.Add(node)
 		}
 	}
 
@@ -392,11 +1494,15 @@ func (s *SqliteOsmDataRepository) wayImportPass(scanner osm.Scanner, includedObj
 		}
 
 		// early return for passthrough
-		if _, ok := includedObjects[way.FeatureID()]; ok {
+		if includedObjects.Contains(way.FeatureID()) {
 			includeWay(way)
 			continue
 		}
 
+		if !boundary.intersects(way.FeatureID()) {
+			continue
+		}
+
 		tags := way.TagMap()
 
 		// way["indoor"]["indoor"!="yes"]
@@ -424,6 +1530,12 @@ func (s *SqliteOsmDataRepository) wayImportPass(scanner osm.Scanner, includedObj
 			includeWay(way)
 			continue
 		}
+
+		// way["building"]
+		if _, ok := tags["building"]; ok {
+			includeWay(way)
+			continue
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -436,7 +1548,7 @@ func (s *SqliteOsmDataRepository) wayImportPass(scanner osm.Scanner, includedObj
 // nodeImportPass puts the neccessary objectids for the following filters into the list of imports
 // Filters in Overpass notation: (inferred from https://openlevelup.net/ api requests)
 // node[~"amenity|shop|railway|highway|door|entrance"~"."]
-func (s *SqliteOsmDataRepository) nodeImportPass(scanner osm.Scanner, includedObjects map[osm.FeatureID]struct{}) error {
+func (s *SqliteOsmDataRepository) nodeImportPass(scanner osm.Scanner, includedObjects *idset.Set, boundary *importBoundary) error {
 	for scanner.Scan() {
 		obj := scanner.Object()
 
@@ -447,8 +1559,11 @@ func (s *SqliteOsmDataRepository) nodeImportPass(scanner osm.Scanner, includedOb
 
 		tags := node.TagMap()
 
-		if _, ok := includedObjects[node.FeatureID()]; ok {
-			includedObjects[node.FeatureID()] = struct{}{}
+		if includedObjects.Contains(node.FeatureID()) {
+			continue
+		}
+
+		if !boundary.intersects(node.FeatureID()) {
 			continue
 		}
 
@@ -461,7 +1576,7 @@ func (s *SqliteOsmDataRepository) nodeImportPass(scanner osm.Scanner, includedOb
 			}
 		}
 		if contains {
-			includedObjects[node.FeatureID()] = struct{}{}
+			includedObjects.Add(node.FeatureID())
 			continue
 		}
 	}
@@ -473,6 +1588,13 @@ func (s *SqliteOsmDataRepository) nodeImportPass(scanner osm.Scanner, includedOb
 	return nil
 }
 
+// importMemoryUsage describes the memory used by the id set and the heap
+func importMemoryUsage(ids *idset.Set) string {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return fmt.Sprintf("id set %.1f MiB, heap %.1f MiB", float64(ids.Bytes())/(1<<20), float64(stats.HeapAlloc)/(1<<20))
+}
+
 func (s *SqliteOsmDataRepository) createImportScanner(ctx context.Context, r io.ReadSeeker, path string) (osm.Scanner, error) {
 	_, err := r.Seek(0, io.SeekStart)
 	if err != nil {
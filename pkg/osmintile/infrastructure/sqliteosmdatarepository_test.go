@@ -2,7 +2,14 @@ package infrastructure_test
 
 import (
 	"context"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/ptr"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/repository"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/infrastructure"
+	"github.com/paulmach/orb"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -13,7 +20,7 @@ func testSetup(t *testing.T) *infrastructure.SqliteOsmDataRepository {
 		return nil
 	}
 
-	err = repo.Import(context.Background(), "/app/data/stachus-latest.osm.pbf")
+	err = repo.Import(context.Background(), "/app/data/stachus-latest.osm.pbf", repository.ImportOptions{})
 	if err != nil {
 		t.Fatal(err)
 		return nil
@@ -25,3 +32,115 @@ func testSetup(t *testing.T) *infrastructure.SqliteOsmDataRepository {
 func TestSqliteOsmDataRepository_Import(t *testing.T) {
 	testSetup(t)
 }
+
+func TestSqliteOsmDataRepository_Search(t *testing.T) {
+	repo := testSetup(t)
+	world := orb.Bound{Min: orb.Point{-180, -90}, Max: orb.Point{180, 90}}
+
+	all, err := repo.Search(context.Background(), "a", world, nil, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	onLevel, err := repo.Search(context.Background(), "a", world, ptr.Ptr(0.0), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(onLevel) > len(all) {
+		t.Errorf("expected the level filter to narrow the %d results, got %d", len(all), len(onLevel))
+	}
+
+	for _, feat := range onLevel {
+		if !osmlevel.Contains(feat.Properties.MustString("level", ""), 0) {
+			t.Errorf("expected %v to be on level 0, got level %q", feat.ID, feat.Properties.MustString("level", ""))
+		}
+	}
+}
+
+const poiLevelsOsm = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+  <node id="1" lat="48.1391" lon="11.5655"><tag k="amenity" v="cafe"/><tag k="level" v="1"/><tag k="name" v="one"/></node>
+  <node id="2" lat="48.1392" lon="11.5656"><tag k="amenity" v="cafe"/><tag k="level" v="10"/><tag k="name" v="ten"/></node>
+  <node id="3" lat="48.1393" lon="11.5657"><tag k="amenity" v="cafe"/><tag k="level" v="-1"/><tag k="name" v="minus one"/></node>
+  <node id="4" lat="48.1394" lon="11.5658"><tag k="amenity" v="cafe"/><tag k="level" v="0-2"/><tag k="name" v="range"/></node>
+  <node id="5" lat="48.1395" lon="11.5659"><tag k="amenity" v="cafe"/><tag k="level" v="21;11"/><tag k="name" v="list"/></node>
+</osm>
+`
+
+func TestSqliteOsmDataRepository_GetPois(t *testing.T) {
+	repo, err := infrastructure.NewSqliteOsmDataRepository(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "pois.osm")
+	if err := os.WriteFile(path, []byte(poiLevelsOsm), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Import(context.Background(), path, repository.ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	world := orb.Bound{Min: orb.Point{-180, -90}, Max: orb.Point{180, 90}}
+	pois, err := repo.GetPois(context.Background(), 1, 0, world)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, feat := range pois.Features {
+		names = append(names, feat.Properties.MustString("name", ""))
+	}
+	slices.Sort(names)
+
+	if !slices.Equal(names, []string{"one", "range"}) {
+		t.Errorf("expected the pois on level 1 and in the range 0-2, got %v", names)
+	}
+}
+
+const indoorAreasOsm = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+  <node id="1" lat="48.1390" lon="11.5650"/>
+  <node id="2" lat="48.1390" lon="11.5660"/>
+  <node id="3" lat="48.1400" lon="11.5660"/>
+  <node id="4" lat="48.1400" lon="11.5650"/>
+  <way id="1">
+    <nd ref="1"/><nd ref="2"/><nd ref="3"/><nd ref="4"/><nd ref="1"/>
+    <tag k="indoor" v="room"/><tag k="level" v="0"/><tag k="name" v="hall"/>
+  </way>
+</osm>
+`
+
+func TestSqliteOsmDataRepository_GetIndoorAreasAt(t *testing.T) {
+	repo, err := infrastructure.NewSqliteOsmDataRepository(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "rooms.osm")
+	if err := os.WriteFile(path, []byte(indoorAreasOsm), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Import(context.Background(), path, repository.ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	areas, err := repo.GetIndoorAreasAt(context.Background(), orb.Point{11.5655, 48.1395})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(areas) != 1 || areas[0].ID != "w1" || areas[0].Properties.MustString("name", "") != "hall" {
+		t.Errorf("expected the room w1 with its tags, got %v", areas)
+	}
+
+	areas, err = repo.GetIndoorAreasAt(context.Background(), orb.Point{11.5665, 48.1395})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(areas) != 0 {
+		t.Errorf("expected no area outside of the room, got %v", areas)
+	}
+}
@@ -1,20 +1,27 @@
 package infrastructure
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/paulmach/osm"
+	"strings"
 )
 
+// importBatchRows is the number of rows inserted per statement, which keeps the parameters of the widest table
+// below the limit of 999 of older sqlite versions
+const importBatchRows = 128
+
+// sqliteosmobjectimporter inserts osm objects in batches, flush has to be called after the last object
 type sqliteosmobjectimporter struct {
-	insertNodePreparedStatement           *sql.Stmt
-	insertNodeTagPreparedStatement        *sql.Stmt
-	insertWayPreparedStatement            *sql.Stmt
-	insertWayTagPreparedStatement         *sql.Stmt
-	insertWayNodePreparedStatement        *sql.Stmt
-	insertRelationPreparedStatement       *sql.Stmt
-	insertRelationTagPreparedStatement    *sql.Stmt
-	insertRelationMemberPreparedStatement *sql.Stmt
+	insertNode           *sqlitebatchinsert
+	insertNodeTag        *sqlitebatchinsert
+	insertWay            *sqlitebatchinsert
+	insertWayTag         *sqlitebatchinsert
+	insertWayNode        *sqlitebatchinsert
+	insertRelation       *sqlitebatchinsert
+	insertRelationTag    *sqlitebatchinsert
+	insertRelationMember *sqlitebatchinsert
 }
 
 func (s *sqliteosmobjectimporter) init(tx *sql.Tx) error {
@@ -25,77 +32,76 @@ func (s *sqliteosmobjectimporter) init(tx *sql.Tx) error {
 	return nil
 }
 
-func (s *sqliteosmobjectimporter) importObject(obj osm.Object) error {
+func (s *sqliteosmobjectimporter) importObject(ctx context.Context, obj osm.Object) error {
 	if node, ok := obj.(*osm.Node); ok {
-		return s.importNode(node)
+		return s.importNode(ctx, node)
 	}
 
 	if way, ok := obj.(*osm.Way); ok {
-		return s.importWay(way)
+		return s.importWay(ctx, way)
 	}
 
 	if relation, ok := obj.(*osm.Relation); ok {
-		return s.importRelation(relation)
+		return s.importRelation(ctx, relation)
 	}
 
 	return fmt.Errorf("unexpected object type %T", obj)
 }
 
+// flush inserts the rows of all incomplete batches
+func (s *sqliteosmobjectimporter) flush(ctx context.Context) error {
+	for _, batch := range []*sqlitebatchinsert{
+		s.insertNode, s.insertNodeTag,
+		s.insertWay, s.insertWayTag, s.insertWayNode,
+		s.insertRelation, s.insertRelationTag, s.insertRelationMember,
+	} {
+		if err := batch.flush(ctx); err != nil {
+			return fmt.Errorf("failed to insert %s: %w", batch.table, err)
+		}
+	}
+
+	return nil
+}
+
 func (s *sqliteosmobjectimporter) prepareStatements(tx *sql.Tx) error {
 	var err error
 
-	s.insertNodePreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO node (node_id, geom) VALUES (?, SetSRID(MakePoint(?, ?), 4326))",
-	)
+	s.insertNode, err = newSqliteBatchInsert(tx, "node", "node_id, geom", "(?, SetSRID(MakePoint(?, ?), 4326))")
 	if err != nil {
 		return err
 	}
 
-	s.insertNodeTagPreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO node_tag (node_id, key, value) VALUES (?, ?, ?)",
-	)
+	s.insertNodeTag, err = newSqliteBatchInsert(tx, "node_tag", "node_id, key, value", "(?, ?, ?)")
 	if err != nil {
 		return err
 	}
 
-	s.insertWayPreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO way (way_id) VALUES (?)",
-	)
+	s.insertWay, err = newSqliteBatchInsert(tx, "way", "way_id", "(?)")
 	if err != nil {
 		return err
 	}
 
-	s.insertWayTagPreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO way_tag (way_id, key, value) VALUES (?, ?, ?)",
-	)
+	s.insertWayTag, err = newSqliteBatchInsert(tx, "way_tag", "way_id, key, value", "(?, ?, ?)")
 	if err != nil {
 		return err
 	}
 
-	s.insertWayNodePreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO way_node (way_id, node_id, sequence_id) VALUES (?, ?, ?)",
-	)
+	s.insertWayNode, err = newSqliteBatchInsert(tx, "way_node", "way_id, node_id, sequence_id", "(?, ?, ?)")
 	if err != nil {
 		return err
 	}
 
-	s.insertRelationPreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO relation (relation_id) VALUES (?)",
-	)
+	s.insertRelation, err = newSqliteBatchInsert(tx, "relation", "relation_id", "(?)")
 	if err != nil {
 		return err
 	}
 
-	s.insertRelationTagPreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO relation_tag (relation_id, key, value) VALUES (?, ?, ?)",
-	)
+	s.insertRelationTag, err = newSqliteBatchInsert(tx, "relation_tag", "relation_id, key, value", "(?, ?, ?)")
 	if err != nil {
 		return err
 	}
 
-	s.insertRelationMemberPreparedStatement, err = tx.Prepare(
-		"INSERT OR REPLACE INTO relation_member (relation_id, member_type, member_id, member_role, sequence_id) VALUES (?, ?, ?, ?, ?)",
-	)
+	s.insertRelationMember, err = newSqliteBatchInsert(tx, "relation_member", "relation_id, member_type, member_id, member_role, sequence_id", "(?, ?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
@@ -103,15 +109,13 @@ func (s *sqliteosmobjectimporter) prepareStatements(tx *sql.Tx) error {
 	return nil
 }
 
-func (s *sqliteosmobjectimporter) importNode(node *osm.Node) error {
-	_, err := s.insertNodePreparedStatement.Exec(node.ID, node.Lon, node.Lat)
-	if err != nil {
+func (s *sqliteosmobjectimporter) importNode(ctx context.Context, node *osm.Node) error {
+	if err := s.insertNode.add(ctx, node.ID, node.Lon, node.Lat); err != nil {
 		return fmt.Errorf("failed to insert node: %w", err)
 	}
 
-	for key, value := range node.TagMap() {
-		_, err := s.insertNodeTagPreparedStatement.Exec(node.ID, key, value)
-		if err != nil {
+	for _, tag := range node.Tags {
+		if err := s.insertNodeTag.add(ctx, node.ID, tag.Key, tag.Value); err != nil {
 			return fmt.Errorf("failed to insert node_tag: %w", err)
 		}
 	}
@@ -119,22 +123,19 @@ func (s *sqliteosmobjectimporter) importNode(node *osm.Node) error {
 	return nil
 }
 
-func (s *sqliteosmobjectimporter) importWay(way *osm.Way) error {
-	_, err := s.insertWayPreparedStatement.Exec(way.ID)
-	if err != nil {
+func (s *sqliteosmobjectimporter) importWay(ctx context.Context, way *osm.Way) error {
+	if err := s.insertWay.add(ctx, way.ID); err != nil {
 		return fmt.Errorf("failed to insert way: %w", err)
 	}
 
-	for key, value := range way.TagMap() {
-		_, err := s.insertWayTagPreparedStatement.Exec(way.ID, key, value)
-		if err != nil {
+	for _, tag := range way.Tags {
+		if err := s.insertWayTag.add(ctx, way.ID, tag.Key, tag.Value); err != nil {
 			return fmt.Errorf("failed to insert way_tag: %w", err)
 		}
 	}
 
 	for sequenceID, node := range way.Nodes {
-		_, err := s.insertWayNodePreparedStatement.Exec(way.ID, node.ID, sequenceID)
-		if err != nil {
+		if err := s.insertWayNode.add(ctx, way.ID, node.ID, sequenceID); err != nil {
 			return fmt.Errorf("failed to insert way_node: %w", err)
 		}
 	}
@@ -142,26 +143,88 @@ func (s *sqliteosmobjectimporter) importWay(way *osm.Way) error {
 	return nil
 }
 
-func (s *sqliteosmobjectimporter) importRelation(relation *osm.Relation) error {
-
-	_, err := s.insertRelationPreparedStatement.Exec(relation.ID)
-	if err != nil {
+func (s *sqliteosmobjectimporter) importRelation(ctx context.Context, relation *osm.Relation) error {
+	if err := s.insertRelation.add(ctx, relation.ID); err != nil {
 		return fmt.Errorf("failed to insert relation: %w", err)
 	}
 
-	for key, value := range relation.TagMap() {
-		_, err := s.insertRelationTagPreparedStatement.Exec(relation.ID, key, value)
-		if err != nil {
+	for _, tag := range relation.Tags {
+		if err := s.insertRelationTag.add(ctx, relation.ID, tag.Key, tag.Value); err != nil {
 			return fmt.Errorf("failed to insert relation_tag: %w", err)
 		}
 	}
 
 	for sequenceID, member := range relation.Members {
-		_, err := s.insertRelationMemberPreparedStatement.Exec(relation.ID, member.Type, int32(member.Ref), member.Role, sequenceID)
-		if err != nil {
+		if err := s.insertRelationMember.add(ctx, relation.ID, member.Type, member.Ref, member.Role, sequenceID); err != nil {
 			return fmt.Errorf("failed to insert relation_member: %w", err)
 		}
 	}
 
 	return nil
 }
+
+// sqlitebatchinsert collects the rows of a table and inserts them with multi-row statements of importBatchRows rows
+type sqlitebatchinsert struct {
+	tx      *sql.Tx
+	table   string
+	prefix  string
+	row     string
+	columns int
+	args    []any
+
+	insertBatchPreparedStatement *sql.Stmt
+}
+
+// newSqliteBatchInsert prepares the insert of rows into the columns of the table, row is the placeholder of a row
+func newSqliteBatchInsert(tx *sql.Tx, table string, columns string, row string) (*sqlitebatchinsert, error) {
+	b := &sqlitebatchinsert{
+		tx:      tx,
+		table:   table,
+		prefix:  fmt.Sprintf("INSERT OR REPLACE INTO %s (%s) VALUES ", table, columns),
+		row:     row,
+		columns: strings.Count(row, "?"),
+	}
+	b.args = make([]any, 0, b.columns*importBatchRows)
+
+	var err error
+	b.insertBatchPreparedStatement, err = tx.Prepare(b.query(importBatchRows))
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *sqlitebatchinsert) query(rows int) string {
+	var builder strings.Builder
+	builder.WriteString(b.prefix)
+	for i := range rows {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(b.row)
+	}
+	return builder.String()
+}
+
+func (b *sqlitebatchinsert) add(ctx context.Context, args ...any) error {
+	b.args = append(b.args, args...)
+	if len(b.args) < b.columns*importBatchRows {
+		return nil
+	}
+
+	_, err := b.insertBatchPreparedStatement.ExecContext(ctx, b.args...)
+	b.args = b.args[:0]
+	return err
+}
+
+// flush inserts the rows of an incomplete batch
+func (b *sqlitebatchinsert) flush(ctx context.Context) error {
+	if len(b.args) == 0 {
+		return nil
+	}
+
+	_, err := b.tx.ExecContext(ctx, b.query(len(b.args)/b.columns), b.args...)
+	b.args = b.args[:0]
+	return err
+}
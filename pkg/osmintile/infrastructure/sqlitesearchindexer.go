@@ -0,0 +1,154 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmlevel"
+	"log"
+	"strings"
+)
+
+// searchIndexQuery fills the search table for one feature type. The center of a feature is the average of its
+// vertices, it is only used for filtering by bbox. Ways and relations have to be indoor features or pois.
+const searchIndexQuery = `
+	INSERT INTO search (feature_type, feature_id, level, lon, lat, name, names, ref, category)
+	SELECT '%[1]s', center.feature_id,
+	(SELECT t.value FROM %[1]s_tag as t WHERE t.%[1]s_id = center.feature_id AND t.key = 'level'),
+	center.lon, center.lat,
+	(SELECT t.value FROM %[1]s_tag as t WHERE t.%[1]s_id = center.feature_id AND t.key = 'name'),
+	(SELECT group_concat(t.value, ' ') FROM %[1]s_tag as t WHERE t.%[1]s_id = center.feature_id AND t.key LIKE 'name:%%'),
+	(SELECT t.value FROM %[1]s_tag as t WHERE t.%[1]s_id = center.feature_id AND t.key = 'ref'),
+	(SELECT group_concat(t.value, ' ') FROM %[1]s_tag as t WHERE t.%[1]s_id = center.feature_id AND t.key IN ('shop', 'amenity'))
+	FROM (%[2]s) as center
+	WHERE center.feature_id IN (
+		SELECT t.%[1]s_id FROM %[1]s_tag as t
+		WHERE t.key IN ('name', 'ref', 'shop', 'amenity') OR t.key LIKE 'name:%%'
+	)
+	  AND center.feature_id IN (SELECT t.%[1]s_id FROM %[1]s_tag as t WHERE %[3]s)
+`
+
+// sqlitesearchindexer rebuilds the full text search index from the imported data, it does nothing if search is disabled.
+type sqlitesearchindexer struct {
+	enabled bool
+	tx      *sql.Tx
+
+	updateLevelsPreparedStatement *sql.Stmt
+}
+
+func (s *sqlitesearchindexer) init(tx *sql.Tx) error {
+	s.tx = tx
+	if !s.enabled {
+		return nil
+	}
+
+	var err error
+	s.updateLevelsPreparedStatement, err = tx.Prepare(`UPDATE search SET levels = ? WHERE rowid = ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlitesearchindexer) index(ctx context.Context) error {
+	if !s.enabled {
+		log.Printf("Skipping search index, search is disabled")
+		return nil
+	}
+
+	if _, err := s.tx.ExecContext(ctx, "DELETE FROM search"); err != nil {
+		return fmt.Errorf("failed to clear search: %w", err)
+	}
+
+	indoorFilter := "(t.key = 'indoor' AND t.value != 'yes') OR t.key IN ('shop', 'amenity')"
+	queries := []string{
+		fmt.Sprintf(searchIndexQuery, "node", `
+			SELECT node.node_id as feature_id, X(node.geom) as lon, Y(node.geom) as lat
+			FROM node
+		`, "1 = 1"),
+		fmt.Sprintf(searchIndexQuery, "way", `
+			SELECT way_node.way_id as feature_id, AVG(X(node.geom)) as lon, AVG(Y(node.geom)) as lat
+			FROM way_node
+			JOIN node ON node.node_id = way_node.node_id
+			GROUP BY way_node.way_id
+		`, indoorFilter),
+		fmt.Sprintf(searchIndexQuery, "relation", `
+			SELECT relation_member.relation_id as feature_id, AVG(X(node.geom)) as lon, AVG(Y(node.geom)) as lat
+			FROM relation_member
+			JOIN way_node ON way_node.way_id = relation_member.member_id
+			JOIN node ON node.node_id = way_node.node_id
+			WHERE relation_member.member_type = 'way'
+			GROUP BY relation_member.relation_id
+		`, indoorFilter),
+	}
+
+	for _, query := range queries {
+		if _, err := s.tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+	}
+
+	if err := s.indexLevels(ctx); err != nil {
+		return fmt.Errorf("failed to index levels: %w", err)
+	}
+
+	var count int
+	if err := s.tx.QueryRowContext(ctx, "SELECT count(*) FROM search").Scan(&count); err != nil {
+		return fmt.Errorf("failed to count search entries: %w", err)
+	}
+
+	log.Printf("Indexed %d search entries", count)
+
+	return nil
+}
+
+// indexLevels fills the levels column from the level tags, so that searches can be filtered by level in sql
+func (s *sqlitesearchindexer) indexLevels(ctx context.Context) error {
+	rows, err := s.tx.QueryContext(ctx, "SELECT rowid, level FROM search WHERE level IS NOT NULL")
+	if err != nil {
+		return fmt.Errorf("failed to query levels: %w", err)
+	}
+	defer rows.Close()
+
+	levels := make(map[int64]string)
+	for rows.Next() {
+		var rowID int64
+		var level string
+		if err := rows.Scan(&rowID, &level); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if formatted := formatSearchLevels(level); formatted != "" {
+			levels[rowID] = formatted
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	for rowID, formatted := range levels {
+		if _, err := s.updateLevelsPreparedStatement.ExecContext(ctx, formatted, rowID); err != nil {
+			return fmt.Errorf("failed to update levels: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// formatSearchLevels formats the levels of the level tag value as ;0;1;, invalid values have no levels
+func formatSearchLevels(value string) string {
+	levels, err := osmlevel.Parse(value)
+	if err != nil {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString(";")
+	for _, level := range levels {
+		builder.WriteString(osmlevel.Format(level))
+		builder.WriteString(";")
+	}
+	return builder.String()
+}
@@ -0,0 +1,37 @@
+package infrastructure
+
+import "testing"
+
+func TestFormatSearchLevels(t *testing.T) {
+	tests := map[string]string{
+		"0":     ";0;",
+		"-1;0":  ";-1;0;",
+		"0-2":   ";0;1;2;",
+		"1.5":   ";1.5;",
+		"roof":  "",
+		"":      "",
+		"0-500": "",
+	}
+
+	for value, expected := range tests {
+		if got := formatSearchLevels(value); got != expected {
+			t.Errorf("formatSearchLevels(%q) = %q, expected %q", value, got, expected)
+		}
+	}
+}
+
+func TestSearchMatchExpression(t *testing.T) {
+	tests := map[string]string{
+		"cafe":          `"cafe"*`,
+		" main  hall ":  `"main"* "hall"*`,
+		`say "hi"`:      `"say"* """hi"""*`,
+		"   ":           "",
+		"Hörsaal 1.001": `"Hörsaal"* "1.001"*`,
+	}
+
+	for query, expected := range tests {
+		if got := searchMatchExpression(query); got != expected {
+			t.Errorf("searchMatchExpression(%q) = %q, expected %q", query, got, expected)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/indoorvalidation"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+)
+
+const (
+	// validationRelationFilter selects the multipolygons of indoor features and buildings
+	validationRelationFilter = `
+		SELECT relation_tag.relation_id FROM relation_tag
+		WHERE relation_tag.key = 'type' AND relation_tag.value = 'multipolygon'
+		  AND relation_tag.relation_id IN (
+			SELECT t.relation_id FROM relation_tag as t WHERE t.key IN ('indoor', 'building')
+		  )
+	`
+	// validationWayFilter selects the ways of indoor features and buildings and the member ways of the multipolygons
+	validationWayFilter = `
+		SELECT way_tag.way_id FROM way_tag WHERE way_tag.key IN ('indoor', 'building')
+		UNION
+		SELECT relation_member.member_id FROM relation_member
+		WHERE relation_member.member_type = 'way' AND relation_member.relation_id IN (` + validationRelationFilter + `)
+	`
+	// validationDoorFilter selects the doors
+	validationDoorFilter = `SELECT node_tag.node_id FROM node_tag WHERE node_tag.key = 'door'`
+)
+
+// sqlitequeryer is implemented by *sql.DB and *sql.Tx
+type sqlitequeryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// sqlitevalidationloader loads the indoor features and buildings together with their nodes and member ways,
+// which are checked by indoorvalidation and repaired by georepair.
+type sqlitevalidationloader struct {
+	conn sqlitequeryer
+	data indoorvalidation.Data
+}
+
+func (s *sqlitevalidationloader) load(ctx context.Context) (indoorvalidation.Data, error) {
+	s.data = indoorvalidation.Data{
+		Nodes:     make(map[int64]indoorvalidation.Node),
+		Ways:      make(map[int64]indoorvalidation.Way),
+		Relations: make(map[int64]indoorvalidation.Relation),
+	}
+
+	if err := s.loadRelations(ctx); err != nil {
+		return indoorvalidation.Data{}, fmt.Errorf("failed to load relations: %w", err)
+	}
+
+	if err := s.loadWays(ctx); err != nil {
+		return indoorvalidation.Data{}, fmt.Errorf("failed to load ways: %w", err)
+	}
+
+	if err := s.loadNodes(ctx); err != nil {
+		return indoorvalidation.Data{}, fmt.Errorf("failed to load nodes: %w", err)
+	}
+
+	return s.data, nil
+}
+
+func (s *sqlitevalidationloader) loadRelations(ctx context.Context) error {
+	err := s.queryTags(ctx, `
+		SELECT relation_tag.relation_id, relation_tag.key, relation_tag.value FROM relation_tag
+		WHERE relation_tag.relation_id IN (`+validationRelationFilter+`)
+	`, func(id int64, key, value string) {
+		relation := s.data.Relations[id]
+		if relation.Tags == nil {
+			relation.Tags = make(map[string]string)
+		}
+		relation.Tags[key] = value
+		s.data.Relations[id] = relation
+	})
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT relation_member.relation_id, relation_member.member_type, relation_member.member_id, relation_member.member_role
+		FROM relation_member
+		WHERE relation_member.relation_id IN (`+validationRelationFilter+`)
+		ORDER BY relation_member.relation_id, relation_member.sequence_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var relationID int64
+		var typ string
+		member := indoorvalidation.Member{}
+		if err := rows.Scan(&relationID, &typ, &member.Ref, &member.Role); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		member.Type = osm.Type(typ)
+		relation := s.data.Relations[relationID]
+		relation.Members = append(relation.Members, member)
+		s.data.Relations[relationID] = relation
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlitevalidationloader) loadWays(ctx context.Context) error {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT way_node.way_id, way_node.node_id FROM way_node
+		WHERE way_node.way_id IN (`+validationWayFilter+`)
+		ORDER BY way_node.way_id, way_node.sequence_id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wayID, nodeID int64
+		if err := rows.Scan(&wayID, &nodeID); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		way := s.data.Ways[wayID]
+		way.Nodes = append(way.Nodes, nodeID)
+		s.data.Ways[wayID] = way
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return s.queryTags(ctx, `
+		SELECT way_tag.way_id, way_tag.key, way_tag.value FROM way_tag
+		WHERE way_tag.way_id IN (`+validationWayFilter+`)
+	`, func(id int64, key, value string) {
+		way, ok := s.data.Ways[id]
+		if !ok {
+			return
+		}
+		if way.Tags == nil {
+			way.Tags = make(map[string]string)
+		}
+		way.Tags[key] = value
+		s.data.Ways[id] = way
+	})
+}
+
+func (s *sqlitevalidationloader) loadNodes(ctx context.Context) error {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT node.node_id, X(node.geom), Y(node.geom) FROM node
+		WHERE node.node_id IN (
+			SELECT way_node.node_id FROM way_node WHERE way_node.way_id IN (`+validationWayFilter+`)
+			UNION
+			`+validationDoorFilter+`
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nodeID int64
+		var point orb.Point
+		if err := rows.Scan(&nodeID, &point[0], &point[1]); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		s.data.Nodes[nodeID] = indoorvalidation.Node{Point: point}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return s.queryTags(ctx, `
+		SELECT node_tag.node_id, node_tag.key, node_tag.value FROM node_tag
+		WHERE node_tag.node_id IN (`+validationDoorFilter+`)
+	`, func(id int64, key, value string) {
+		node, ok := s.data.Nodes[id]
+		if !ok {
+			return
+		}
+		if node.Tags == nil {
+			node.Tags = make(map[string]string)
+		}
+		node.Tags[key] = value
+		s.data.Nodes[id] = node
+	})
+}
+
+// queryTags calls add for every id, key and value returned by the query
+func (s *sqlitevalidationloader) queryTags(ctx context.Context, query string, add func(id int64, key, value string)) error {
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var key, value string
+		if err := rows.Scan(&id, &key, &value); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		add(id, key, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+)
+
+func BuildingsRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /buildings", func(w http.ResponseWriter, req *http.Request) {
+		bound, err := parseOptionalBound(req.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		buildings, err := application.GetBuildings(req.Context(), bound)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(buildings)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	mux.HandleFunc("GET /buildings/{id}", func(w http.ResponseWriter, req *http.Request) {
+		buildingID, err := osmid.Parse(req.PathValue("id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		building, err := application.GetBuilding(req.Context(), buildingID)
+		if errors.Is(err, service.ErrBuildingNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(building)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
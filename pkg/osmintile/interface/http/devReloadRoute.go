@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"net/http"
+)
+
+// DevReloadRoute streams a server-sent event to connected browsers whenever styles or static files change.
+func DevReloadRoute(mux *http.ServeMux, app application.Application) {
+	mux.HandleFunc("GET /dev/reload", func(w http.ResponseWriter, req *http.Request) {
+		events, err := app.SubscribeDevReload(req.Context())
+		if errors.Is(err, application.ErrDevModeDisabled) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for range events {
+			if _, err := w.Write([]byte("event: reload\ndata: {}\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+)
+
+func EvacuationRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /evacuation/route", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		from, err := parseWaypoint(query.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		language := query.Get("lang")
+		if language == "" {
+			language = service.DefaultInstructionLanguage
+		}
+
+		route, err := application.EvacuationRoute(req.Context(), from, language)
+		if errors.Is(err, service.ErrInvalidLanguage) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrWaypointNotFound) || errors.Is(err, service.ErrRouteNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(route)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	mux.HandleFunc("GET /evacuation/plan.geojson", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		bound, err := parseOptionalBound(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		level, err := parseOptionalLevel(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if level == nil {
+			http.Error(w, "missing level", http.StatusBadRequest)
+			return
+		}
+
+		plan, err := application.GetEvacuationPlan(req.Context(), *level, bound)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/geo+json")
+
+		err = json.NewEncoder(w).Encode(plan)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
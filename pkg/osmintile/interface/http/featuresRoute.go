@@ -0,0 +1,45 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"github.com/paulmach/osm"
+	"net/http"
+	"strconv"
+)
+
+func FeaturesRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /features/{type}/{id}", func(w http.ResponseWriter, req *http.Request) {
+		ref, err := strconv.ParseInt(req.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, err := osm.Type(req.PathValue("type")).FeatureID(ref)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		feature, err := application.GetFeature(req.Context(), id)
+		if errors.Is(err, service.ErrFeatureNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/geo+json")
+
+		err = json.NewEncoder(w).Encode(feature)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
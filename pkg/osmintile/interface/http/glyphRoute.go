@@ -0,0 +1,41 @@
+package http
+
+import (
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+	"strings"
+)
+
+func GlyphRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /fonts/{fontstack}/{range}", func(w http.ResponseWriter, req *http.Request) {
+		glyphRange, ok := strings.CutSuffix(req.PathValue("range"), ".pbf")
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+
+		glyphs, err := application.GetGlyphs(req.Context(), req.PathValue("fontstack"), glyphRange)
+		if errors.Is(err, service.ErrInvalidGlyphRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrGlyphsNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+
+		_, err = w.Write(glyphs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
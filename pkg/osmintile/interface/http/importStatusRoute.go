@@ -0,0 +1,37 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"net/http"
+)
+
+// ImportStatusRoute serves the state and progress of the import, it stays available while the import is running.
+// The route needs no authentication, the local file path and the error are only included for requests with the
+// admin token as bearer token. Without an admin token they are never served, the server log has them.
+func ImportStatusRoute(mux *http.ServeMux, application application.Application, adminToken string) {
+	mux.HandleFunc("GET /admin/import/status", func(w http.ResponseWriter, req *http.Request) {
+		status := application.GetImportStatus()
+		if !hasAdminToken(req, adminToken) {
+			status.File, status.Error = "", ""
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		err := json.NewEncoder(w).Encode(status)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+func hasAdminToken(req *http.Request, adminToken string) bool {
+	if adminToken == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+adminToken)) == 1
+}
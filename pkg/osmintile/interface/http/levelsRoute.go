@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+)
+
+func LevelsRoute(mux *http.ServeMux, application application.Application) {
+	writeLevels := func(w http.ResponseWriter, levels []entities.Level) {
+		w.Header().Set("Content-Type", "application/json")
+
+		err := json.NewEncoder(w).Encode(levels)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	mux.HandleFunc("GET /levels", func(w http.ResponseWriter, req *http.Request) {
+		bound, err := parseOptionalBound(req.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		levels, err := application.GetLevels(req.Context(), bound)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeLevels(w, levels)
+	})
+
+	mux.HandleFunc("GET /buildings/{id}/levels", func(w http.ResponseWriter, req *http.Request) {
+		buildingID, err := osmid.Parse(req.PathValue("id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		levels, err := application.GetBuildingLevels(req.Context(), buildingID)
+		if errors.Is(err, service.ErrBuildingNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeLevels(w, levels)
+	})
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+)
+
+func LocateRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /locate", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		point, err := parsePoint(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		level, err := parseOptionalLevel(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		radius, err := parseOptionalFloat(query, "radius", service.DefaultLocateRadius)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		location, err := application.Locate(req.Context(), point, level, radius)
+		if errors.Is(err, service.ErrInvalidRadius) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(location)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
@@ -1,13 +1,49 @@
 package http
 
 import (
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
 	"net/http"
+	"strconv"
 )
 
 func MapStyleRoute(mux *http.ServeMux, application application.Application) {
 	mux.HandleFunc("GET /style.json", func(w http.ResponseWriter, req *http.Request) {
-		style, err := application.GetMapStyle(req.Context())
+		params := entities.DefaultMapStyleParams()
+
+		query := req.URL.Query()
+		if levelStr := query.Get("level"); levelStr != "" {
+			level, err := strconv.Atoi(levelStr)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			params.Level = level
+		}
+
+		if theme := query.Get("theme"); theme != "" {
+			params.Theme = theme
+		}
+
+		params.Language = query.Get("lang")
+
+		if buildingStr := query.Get("building"); buildingStr != "" {
+			building, err := osmid.Parse(buildingStr)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			params.Building = building
+		}
+
+		style, err := application.GetMapStyle(req.Context(), params)
+		if errors.Is(err, service.ErrUnknownTheme) || errors.Is(err, service.ErrInvalidLanguage) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -1,7 +1,9 @@
 package http
 
 import (
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulmach/osm"
 	"net/http"
 	"strconv"
 	"strings"
@@ -38,10 +40,19 @@ func MapTileRoute(mux *http.ServeMux, application application.Application) {
 			return
 		}
 
+		var building osm.FeatureID
+		if buildingStr := req.URL.Query().Get("building"); buildingStr != "" {
+			building, err = osmid.Parse(buildingStr)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		encodings := req.Header.Get("Accept-Encoding")
 		acceptGzip := strings.Contains(encodings, "gzip")
 
-		tile, err := application.GetTile(req.Context(), level, uint32(x), uint32(y), uint32(z), acceptGzip)
+		tile, err := application.GetTile(req.Context(), level, building, uint32(x), uint32(y), uint32(z), acceptGzip)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		}
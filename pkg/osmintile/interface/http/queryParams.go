@@ -0,0 +1,148 @@
+package http
+
+import (
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/ptr"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulmach/orb"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseFiniteFloat parses a float, other than strconv.ParseFloat it rejects NaN and infinities
+func parseFiniteFloat(value string) (float64, error) {
+	out, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsNaN(out) || math.IsInf(out, 0) {
+		return 0, fmt.Errorf("%q is not a finite number", value)
+	}
+
+	return out, nil
+}
+
+// parseOptionalBound parses the bbox query parameter, the whole world is returned if it is missing
+func parseOptionalBound(query url.Values) (orb.Bound, error) {
+	bboxStr := query.Get("bbox")
+	if bboxStr == "" {
+		return orb.Bound{Min: orb.Point{-180, -90}, Max: orb.Point{180, 90}}, nil
+	}
+
+	return parseBound(bboxStr)
+}
+
+// parseBound parses a bounding box in the form minLon,minLat,maxLon,maxLat
+func parseBound(value string) (orb.Bound, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return orb.Bound{}, fmt.Errorf("invalid bbox %q: expected minLon,minLat,maxLon,maxLat", value)
+	}
+
+	coords := [4]float64{}
+	for i, part := range parts {
+		coord, err := parseFiniteFloat(strings.TrimSpace(part))
+		if err != nil {
+			return orb.Bound{}, fmt.Errorf("invalid bbox %q: %w", value, err)
+		}
+		coords[i] = coord
+	}
+
+	if coords[0] > coords[2] || coords[1] > coords[3] {
+		return orb.Bound{}, fmt.Errorf("invalid bbox %q: min is greater than max", value)
+	}
+
+	return orb.Bound{Min: orb.Point{coords[0], coords[1]}, Max: orb.Point{coords[2], coords[3]}}, nil
+}
+
+// parsePoint parses the lat and lon query parameters
+func parsePoint(query url.Values) (orb.Point, error) {
+	lat, err := parseFiniteFloat(query.Get("lat"))
+	if err != nil || lat < -90 || lat > 90 {
+		return orb.Point{}, fmt.Errorf("invalid lat %q", query.Get("lat"))
+	}
+
+	lon, err := parseFiniteFloat(query.Get("lon"))
+	if err != nil || lon < -180 || lon > 180 {
+		return orb.Point{}, fmt.Errorf("invalid lon %q", query.Get("lon"))
+	}
+
+	return orb.Point{lon, lat}, nil
+}
+
+// parseOptionalLevel parses the level query parameter, nil is returned if it is missing
+func parseOptionalLevel(query url.Values) (*float64, error) {
+	levelStr := query.Get("level")
+	if levelStr == "" {
+		return nil, nil
+	}
+
+	level, err := parseFiniteFloat(levelStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid level %q: %w", levelStr, err)
+	}
+
+	return ptr.Ptr(level), nil
+}
+
+// parseOptionalFloat parses the named query parameter, fallback is returned if it is missing
+func parseOptionalFloat(query url.Values, name string, fallback float64) (float64, error) {
+	valueStr := query.Get(name)
+	if valueStr == "" {
+		return fallback, nil
+	}
+
+	value, err := parseFiniteFloat(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, valueStr, err)
+	}
+
+	return value, nil
+}
+
+// parseOptionalInt parses the named query parameter, fallback is returned if it is missing
+func parseOptionalInt(query url.Values, name string, fallback int) (int, error) {
+	valueStr := query.Get(name)
+	if valueStr == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, valueStr, err)
+	}
+
+	return value, nil
+}
+
+// parseWaypoint parses a waypoint in the form lat,lon,level or the short id of a feature, e.g. w123 for a room.
+// The level is required, as a point is ambiguous in buildings with multiple levels.
+func parseWaypoint(value string) (entities.Waypoint, error) {
+	if !strings.Contains(value, ",") {
+		id, err := osmid.Parse(value)
+		if err != nil {
+			return entities.Waypoint{}, fmt.Errorf("invalid waypoint %q: %w", value, err)
+		}
+		return entities.Waypoint{Feature: id}, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return entities.Waypoint{}, fmt.Errorf("invalid waypoint %q: expected lat,lon,level", value)
+	}
+
+	coords := [3]float64{}
+	for i, part := range parts {
+		coord, err := parseFiniteFloat(strings.TrimSpace(part))
+		if err != nil {
+			return entities.Waypoint{}, fmt.Errorf("invalid waypoint %q: %w", value, err)
+		}
+		coords[i] = coord
+	}
+
+	return entities.Waypoint{Point: orb.Point{coords[1], coords[0]}, Level: coords[2]}, nil
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+	"strings"
+)
+
+func ReachabilityRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /reachability", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		from, err := parseWaypoint(query.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		minutes, err := parseMinutes(query.Get("minutes"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resolution, err := parseOptionalFloat(query, "resolution", service.DefaultReachabilityResolution)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		profile := query.Get("profile")
+		if profile == "" {
+			profile = service.DefaultRoutingProfile
+		}
+
+		reachability, err := application.Reachability(req.Context(), from, minutes, profile, resolution)
+		if errors.Is(err, service.ErrInvalidReachability) || errors.Is(err, service.ErrUnknownProfile) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrWaypointNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/geo+json")
+
+		err = json.NewEncoder(w).Encode(reachability)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+// parseMinutes parses a comma separated list of minutes, e.g. 1,3,5
+func parseMinutes(value string) ([]float64, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing minutes")
+	}
+
+	var out []float64
+	for _, part := range strings.Split(value, ",") {
+		minutes, err := parseFiniteFloat(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid minutes %q: %w", value, err)
+		}
+		out = append(out, minutes)
+	}
+
+	return out, nil
+}
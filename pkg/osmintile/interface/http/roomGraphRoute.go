@@ -0,0 +1,116 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/graphml"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/libraries/osmid"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+)
+
+var roomGraphKeys = []graphml.Key{
+	{ID: "feature", For: "node", Type: "string"},
+	{ID: "level", For: "node", Type: "double"},
+	{ID: "indoor", For: "node", Type: "string"},
+	{ID: "name", For: "node", Type: "string"},
+	{ID: "ref", For: "node", Type: "string"},
+	{ID: "doors", For: "node", Type: "int"},
+	{ID: "no_door", For: "node", Type: "boolean"},
+	{ID: "kind", For: "edge", Type: "string"},
+	{ID: "via", For: "edge", Type: "string"},
+	{ID: "door_name", For: "edge", Type: "string"},
+	{ID: "lon", For: "edge", Type: "double"},
+	{ID: "lat", For: "edge", Type: "double"},
+}
+
+func RoomGraphRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /buildings/{id}/rooms/graph", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		buildingID, err := osmid.Parse(req.PathValue("id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		level, err := parseOptionalLevel(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		format := query.Get("format")
+		if format != "" && format != "json" && format != "graphml" {
+			http.Error(w, fmt.Sprintf("invalid format %q: expected json or graphml", format), http.StatusBadRequest)
+			return
+		}
+
+		roomGraph, err := application.GetRoomGraph(req.Context(), buildingID, level)
+		if errors.Is(err, service.ErrBuildingNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if format == "graphml" {
+			w.Header().Set("Content-Type", "application/graphml+xml")
+
+			err = graphml.Write(w, roomGraphML(roomGraph))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(roomGraph)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+func roomGraphML(roomGraph entities.RoomGraph) graphml.Graph {
+	out := graphml.Graph{Keys: roomGraphKeys}
+
+	for _, room := range roomGraph.Rooms {
+		out.Nodes = append(out.Nodes, graphml.Node{
+			ID: room.ID,
+			Data: map[string]any{
+				"feature": room.Feature,
+				"level":   room.Level,
+				"indoor":  room.Indoor,
+				"name":    room.Name,
+				"ref":     room.Ref,
+				"doors":   room.Doors,
+				"no_door": room.NoDoor,
+			},
+		})
+	}
+
+	for _, connection := range roomGraph.Connections {
+		out.Edges = append(out.Edges, graphml.Edge{
+			Source: connection.From,
+			Target: connection.To,
+			Data: map[string]any{
+				"kind":      connection.Kind,
+				"via":       connection.Via,
+				"door_name": connection.Name,
+				"lon":       connection.Point[0],
+				"lat":       connection.Point[1],
+			},
+		})
+	}
+
+	return out
+}
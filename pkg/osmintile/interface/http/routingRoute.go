@@ -0,0 +1,159 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+)
+
+// maxMatrixBodySize limits the size of matrix requests in bytes
+const maxMatrixBodySize = 1 << 20
+
+func RoutingRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /route", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		from, err := parseWaypoint(query.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		to, err := parseWaypoint(query.Get("to"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		profile := query.Get("profile")
+		if profile == "" {
+			profile = service.DefaultRoutingProfile
+		}
+
+		language := query.Get("lang")
+		if language == "" {
+			language = service.DefaultInstructionLanguage
+		}
+
+		route, err := application.Route(req.Context(), from, to, profile, language)
+		if errors.Is(err, service.ErrUnknownProfile) || errors.Is(err, service.ErrInvalidLanguage) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrWaypointNotFound) || errors.Is(err, service.ErrRouteNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(route)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	mux.HandleFunc("GET /routing/graph.geojson", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+
+		bound, err := parseOptionalBound(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		level, err := parseOptionalLevel(query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		graph, err := application.GetRoutingGraph(req.Context(), bound, level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/geo+json")
+
+		err = json.NewEncoder(w).Encode(graph)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	mux.HandleFunc("POST /routing/matrix", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Origins      []string `json:"origins"`
+			Destinations []string `json:"destinations"`
+			Profile      string   `json:"profile"`
+		}
+
+		err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxMatrixBodySize)).Decode(&body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		origins, err := parseWaypoints(body.Origins)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		destinations, err := parseWaypoints(body.Destinations)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if body.Profile == "" {
+			body.Profile = service.DefaultRoutingProfile
+		}
+
+		matrix, err := application.Matrix(req.Context(), origins, destinations, body.Profile)
+		if errors.Is(err, service.ErrInvalidMatrix) || errors.Is(err, service.ErrUnknownProfile) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrWaypointNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(matrix)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
+
+func parseWaypoints(values []string) ([]entities.Waypoint, error) {
+	out := make([]entities.Waypoint, 0, len(values))
+	for _, value := range values {
+		waypoint, err := parseWaypoint(value)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, waypoint)
+	}
+
+	return out, nil
+}
@@ -2,15 +2,53 @@ package http
 
 import (
 	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"io/fs"
 	"net"
 	"net/http"
+	"strings"
 )
 
-func ServeApplication(l net.Listener, application application.Application) error {
+func ServeApplication(l net.Listener, application application.Application, staticFS fs.FS, adminToken string) error {
 	mux := http.NewServeMux()
-	WebPageRoute(mux)
+	WebPageRoute(mux, staticFS)
 	MapStyleRoute(mux, application)
 	MapTileRoute(mux, application)
+	TileJSONRoute(mux, application)
+	SpriteRoute(mux, application)
+	GlyphRoute(mux, application)
+	BuildingsRoute(mux, application)
+	LevelsRoute(mux, application)
+	FeaturesRoute(mux, application)
+	LocateRoute(mux, application)
+	SearchRoute(mux, application)
+	RoutingRoute(mux, application)
+	RoomGraphRoute(mux, application)
+	EvacuationRoute(mux, application)
+	ReachabilityRoute(mux, application)
+	ValidationRoute(mux, application)
+	ImportStatusRoute(mux, application, adminToken)
+	DevReloadRoute(mux, application)
 
-	return http.Serve(l, mux)
+	return http.Serve(l, importGuard(mux, application))
+}
+
+// importGuard answers with 503 Service Unavailable while an import is running or after it failed, except for the
+// admin routes. The data is incomplete until the import is committed and must not end up in the caches of the services.
+func importGuard(next http.Handler, application application.Application) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, "/admin/") {
+			switch application.GetImportStatus().State {
+			case entities.ImportStateRunning:
+				w.Header().Set("Retry-After", "10")
+				http.Error(w, "import is running", http.StatusServiceUnavailable)
+				return
+			case entities.ImportStateFailed:
+				http.Error(w, "import failed", http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, req)
+	})
 }
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"errors"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/service"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// spriteFileRegex matches the files MapLibre requests for a sprite, e.g. sprite.json or sprite@2x.png
+var spriteFileRegex = regexp.MustCompile(`^sprite(?:@(\d+)x)?\.(json|png)$`)
+
+func SpriteRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /sprites/{file}", func(w http.ResponseWriter, req *http.Request) {
+		match := spriteFileRegex.FindStringSubmatch(req.PathValue("file"))
+		if match == nil {
+			http.NotFound(w, req)
+			return
+		}
+
+		pixelRatio := 1
+		if match[1] != "" {
+			var err error
+			pixelRatio, err = strconv.Atoi(match[1])
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		sprite, err := application.GetSprite(req.Context(), pixelRatio)
+		if errors.Is(err, service.ErrUnsupportedPixelRatio) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := sprite.Image
+		w.Header().Set("Content-Type", "image/png")
+		if match[2] == "json" {
+			data = sprite.Index
+			w.Header().Set("Content-Type", "application/json")
+		}
+
+		_, err = w.Write(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/domain/entities"
+	"net/http"
+	"strconv"
+)
+
+func TileJSONRoute(mux *http.ServeMux, application application.Application) {
+	serveTileJSON := func(w http.ResponseWriter, req *http.Request, level int) {
+		tileJSON, err := application.GetTileJSON(req.Context(), level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(tileJSON)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	mux.HandleFunc("GET /tiles.json", func(w http.ResponseWriter, req *http.Request) {
+		level := entities.DefaultMapStyleLevel
+		if levelStr := req.URL.Query().Get("level"); levelStr != "" {
+			var err error
+			level, err = strconv.Atoi(levelStr)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		serveTileJSON(w, req, level)
+	})
+
+	mux.HandleFunc("GET /tiles/{level}/tiles.json", func(w http.ResponseWriter, req *http.Request) {
+		level, err := strconv.Atoi(req.PathValue("level"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		serveTileJSON(w, req, level)
+	})
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/paulkoehlerdev/OsmInTile/pkg/osmintile/application"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func ValidationRoute(mux *http.ServeMux, application application.Application) {
+	mux.HandleFunc("GET /validation/report.json", func(w http.ResponseWriter, req *http.Request) {
+		report, err := application.Validate(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(report)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	mux.HandleFunc("GET /validation/report.geojson", func(w http.ResponseWriter, req *http.Request) {
+		report, err := application.GetValidationGeoJSON(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/geo+json")
+
+		err = json.NewEncoder(w).Encode(report)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	mux.HandleFunc("GET /validation/repairs.json", func(w http.ResponseWriter, req *http.Request) {
+		repairs, err := application.GetGeometryRepairs(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = json.NewEncoder(w).Encode(repairs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	mux.HandleFunc("GET /validation/tiles/{z}/{x}/{y}", func(w http.ResponseWriter, req *http.Request) {
+		z, err := strconv.Atoi(req.PathValue("z"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		x, err := strconv.Atoi(req.PathValue("x"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		y, err := strconv.Atoi(req.PathValue("y"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		acceptGzip := strings.Contains(req.Header.Get("Accept-Encoding"), "gzip")
+
+		tile, err := application.GetValidationTile(req.Context(), uint32(x), uint32(y), uint32(z), acceptGzip)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if acceptGzip {
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+
+		_, err = w.Write(tile)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
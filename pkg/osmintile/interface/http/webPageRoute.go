@@ -1,15 +1,15 @@
 package http
 
 import (
-	"github.com/paulkoehlerdev/OsmInTile/static"
+	"io/fs"
 	"net/http"
 	"regexp"
 )
 
 var allowedFilesRegex = regexp.MustCompile("^.+\\.(js|css|html)$")
 
-func WebPageRoute(mux *http.ServeMux) {
-	fileServ := http.FileServerFS(static.FS)
+func WebPageRoute(mux *http.ServeMux, staticFS fs.FS) {
+	fileServ := http.FileServerFS(staticFS)
 
 	mux.HandleFunc("GET /", func(w http.ResponseWriter, req *http.Request) {
 		if req.URL.Path != "/" && !allowedFilesRegex.MatchString(req.URL.Path) {
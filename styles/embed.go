@@ -2,5 +2,5 @@ package styles
 
 import "embed"
 
-//go:embed all:*.json
+//go:embed all:*.json all:themes/*.json all:icons/*.svg
 var FS embed.FS